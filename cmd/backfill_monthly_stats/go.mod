@@ -0,0 +1,3 @@
+module storichallenge/cmd/backfill_monthly_stats
+
+go 1.19
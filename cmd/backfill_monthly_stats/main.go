@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"storichallenge_layer/config"
+	"storichallenge_layer/services"
+)
+
+// Recomputes the monthly_stats table from the transaction table, and the
+// opening balances of the balance months from their amounts. Run it once
+// after creating the table or column, or to repair an account whose figures
+// drifted.
+func main() {
+	accountID := flag.Int64("account-id", 0, "only rebuild this account (default: all accounts)")
+	flag.Parse()
+
+	cfg, err := config.LoadDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	accountService, err := services.NewAccountService(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = accountService.RebuildMonthlyStats(*accountID)
+	if err != nil {
+		log.Fatalf("Failed to rebuild monthly stats: %v", err)
+	}
+
+	err = accountService.RebuildOpeningBalances(*accountID)
+	if err != nil {
+		log.Fatalf("Failed to rebuild opening balances: %v", err)
+	}
+
+	if *accountID == 0 {
+		log.Println("Monthly stats and opening balances rebuilt for every account.")
+	} else {
+		log.Printf("Monthly stats and opening balances rebuilt for account %d.", *accountID)
+	}
+}
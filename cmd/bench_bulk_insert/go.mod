@@ -0,0 +1,3 @@
+module storichallenge/cmd/bench_bulk_insert
+
+go 1.19
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"storichallenge_layer/config"
+	"storichallenge_layer/generator"
+	"storichallenge_layer/models"
+	"storichallenge_layer/services"
+)
+
+// Measures transaction insert throughput against the configured database,
+// comparing CreateTransaction row by row with the CreateTransactions batch
+// path. Point it at a scratch database: it creates accounts and rows.
+func main() {
+	rows := flag.Int("rows", 100000, "transactions to insert per mode")
+	accounts := flag.Int("accounts", 10, "accounts the transactions are spread over")
+	batchSize := flag.Int("batch-size", 5000, "transactions per CreateTransactions call")
+	single := flag.Bool("single", false, "also measure row-by-row inserts (slow)")
+	flag.Parse()
+
+	cfg, err := config.LoadDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	accountService, err := services.NewAccountService(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	scenario := generator.DefaultScenario()
+	scenario.Seed = time.Now().UnixNano()
+	scenario.Accounts = *accounts
+	scenario.TransactionsPerAccount = *rows / *accounts
+
+	if *single {
+		transactions := generate(accountService, scenario)
+		elapsed := measure(func() error {
+			for _, transaction := range transactions {
+				if err := accountService.CreateTransaction(transaction); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		report("row by row", len(transactions), elapsed)
+		scenario.Seed++
+	}
+
+	transactions := generate(accountService, scenario)
+	elapsed := measure(func() error {
+		for start := 0; start < len(transactions); start += *batchSize {
+			end := start + *batchSize
+			if end > len(transactions) {
+				end = len(transactions)
+			}
+			if err := accountService.CreateTransactions(transactions[start:end]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	report(fmt.Sprintf("batches of %d", *batchSize), len(transactions), elapsed)
+}
+
+// generate creates the scenario accounts and returns their transactions,
+// ready to be posted.
+func generate(accountService *services.AccountService, scenario generator.Scenario) []models.Transaction {
+	dataset, err := generator.Generate(scenario)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var transactions []models.Transaction
+	for _, generated := range dataset.Accounts {
+		accountID, err := accountService.CreateAccount(generated.Account)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, transaction := range generated.Transactions {
+			transaction.AccountID = accountID
+			transactions = append(transactions, transaction)
+		}
+	}
+	return transactions
+}
+
+func measure(fn func() error) time.Duration {
+	start := time.Now()
+	if err := fn(); err != nil {
+		log.Fatal(err)
+	}
+	return time.Since(start)
+}
+
+func report(mode string, rows int, elapsed time.Duration) {
+	fmt.Printf("%-20s %8d rows in %10s  %10.0f rows/s\n", mode, rows, elapsed.Round(time.Millisecond), float64(rows)/elapsed.Seconds())
+}
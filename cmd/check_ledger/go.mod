@@ -0,0 +1,3 @@
+module storichallenge/cmd/check_ledger
+
+go 1.19
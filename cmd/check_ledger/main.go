@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"storichallenge_layer/config"
+	"storichallenge_layer/models"
+	"storichallenge_layer/services"
+)
+
+// Prints the trial balance of the ledger and checks its invariants, exiting
+// with status 1 when one does not hold. With -backfill it first journals the
+// transactions posted before the ledger existed.
+func main() {
+	backfill := flag.Bool("backfill", false, "journal the transactions without a journal entry first")
+	flag.Parse()
+
+	cfg, err := config.LoadDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	accountService, err := services.NewAccountService(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *backfill {
+		journaled, err := accountService.BackfillLedger(0)
+		if err != nil {
+			log.Fatalf("Failed to backfill the ledger: %v", err)
+		}
+		log.Printf("Journaled %d transactions.", journaled)
+	}
+
+	trial, err := accountService.GetTrialBalance()
+	if err != nil {
+		log.Fatalf("Failed to compute the trial balance: %v", err)
+	}
+	fmt.Printf("%-12s %10s %16s %16s %16s\n", "LEDGER", "ACCOUNT", "DEBITS", "CREDITS", "BALANCE")
+	for _, line := range trial.Lines {
+		account := ""
+		if line.LedgerAccount == models.LEDGER_CUSTOMER {
+			account = fmt.Sprint(line.AccountID)
+		}
+		fmt.Printf("%-12s %10s %16.2f %16.2f %16.2f\n", line.LedgerAccount, account,
+			float64(line.Debits)/100, float64(line.Credits)/100, float64(line.Balance())/100)
+	}
+	debits, credits := trial.Totals()
+	fmt.Printf("%-12s %10s %16.2f %16.2f %16.2f\n", "TOTAL", "", float64(debits)/100, float64(credits)/100, float64(debits+credits)/100)
+
+	check, err := accountService.CheckLedger()
+	if err != nil {
+		log.Fatalf("Failed to check the ledger: %v", err)
+	}
+	for _, entryID := range check.UnbalancedEntries {
+		log.Printf("Journal entry %d does not balance.", entryID)
+	}
+	if check.UnjournaledTransactions > 0 {
+		log.Printf("%d transactions have no journal entry; run with -backfill.", check.UnjournaledTransactions)
+	}
+	for _, mismatch := range check.Mismatches {
+		log.Printf("Account %d has a balance of %.2f but its ledger account %.2f.", mismatch.AccountID,
+			float64(mismatch.CurrentBalance)/100, float64(mismatch.LedgerBalance)/100)
+	}
+	if check.TransfersInTransit != 0 {
+		log.Printf("Transfers are off by %.2f.", float64(check.TransfersInTransit)/100)
+	}
+	if !check.OK() || !trial.Balanced() {
+		os.Exit(1)
+	}
+	log.Println("The ledger balances.")
+}
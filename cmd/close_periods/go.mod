@@ -0,0 +1,3 @@
+module storichallenge/cmd/close_periods
+
+go 1.19
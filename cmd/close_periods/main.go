@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"time"
+
+	"storichallenge_layer/config"
+	"storichallenge_layer/services"
+)
+
+// Closes a balance month, and every earlier one, on every account, once its
+// summaries were sent. Transactions dated in a closed month are then rejected
+// or posted as adjustment entries into the first open month, as
+// PERIOD_BACKDATING says. Schedule it at the start of every month.
+func main() {
+	now := time.Now().UTC()
+	month := flag.String("month", now.AddDate(0, 0, -now.Day()).Format("2006/01"), "close this month and every earlier one, as YYYY/MM (default: last month)")
+	flag.Parse()
+
+	cfg, err := config.LoadDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	accountService, err := services.NewAccountService(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	closed, err := accountService.ClosePeriods(*month)
+	if err != nil {
+		log.Fatalf("Failed to close %s: %v", *month, err)
+	}
+
+	log.Printf("Closed %s on %d accounts.", *month, closed)
+}
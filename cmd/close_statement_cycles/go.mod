@@ -0,0 +1,3 @@
+module storichallenge/cmd/close_statement_cycles
+
+go 1.19
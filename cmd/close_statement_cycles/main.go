@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"time"
+
+	"storichallenge_layer/config"
+	"storichallenge_layer/services"
+)
+
+// Closes the statement cycle of every credit account whose cut-off day is
+// the given date. Schedule it once a day; running it twice for the same date
+// does not duplicate statements.
+func main() {
+	date := flag.String("date", time.Now().UTC().Format("2006-01-02"), "cut-off date to close, as YYYY-MM-DD")
+	flag.Parse()
+
+	cutoff, err := time.Parse("2006-01-02", *date)
+	if err != nil {
+		log.Fatalf("Invalid -date %q: %v", *date, err)
+	}
+
+	cfg, err := config.LoadDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	accountService, err := services.NewAccountService(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Accounts that fail do not stop the others, so what was closed is
+	// reported either way.
+	statements, err := accountService.CloseStatementCycles(cutoff)
+	log.Printf("Closed %d statement cycles for %s.", len(statements), *date)
+	if err != nil {
+		log.Fatalf("Failed to close statement cycles: %v", err)
+	}
+}
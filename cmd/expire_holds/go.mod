@@ -0,0 +1,3 @@
+module storichallenge/cmd/expire_holds
+
+go 1.19
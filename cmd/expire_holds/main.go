@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"time"
+
+	"storichallenge_layer/config"
+	"storichallenge_layer/services"
+)
+
+// Expires every authorization hold neither captured nor released by its
+// expiry, giving the funds it took up back to the account. Schedule it at
+// least once a day; expired holds are skipped on later runs.
+func main() {
+	at := flag.String("at", time.Now().UTC().Format(time.RFC3339), "expire holds whose expiry is not after this moment, as RFC 3339")
+	flag.Parse()
+
+	expiry, err := time.Parse(time.RFC3339, *at)
+	if err != nil {
+		log.Fatalf("Invalid -at %q: %v", *at, err)
+	}
+
+	cfg, err := config.LoadDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	accountService, err := services.NewAccountService(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	expired, err := accountService.ExpireHolds(expiry)
+	if err != nil {
+		log.Fatalf("Failed to expire authorization holds: %v", err)
+	}
+
+	log.Printf("Expired %d authorization holds.", expired)
+}
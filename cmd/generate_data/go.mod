@@ -0,0 +1,3 @@
+module storichallenge/cmd/generate_data
+
+go 1.19
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"storichallenge_layer/config"
+	"storichallenge_layer/generator"
+	"storichallenge_layer/services"
+)
+
+// Local counterpart of lbd_generate_data. With -dry-run it only prints what
+// the scenario produces, so no database is needed.
+func main() {
+	scenarioPath := flag.String("scenario", "", "YAML or JSON scenario file (default: built-in scenario)")
+	seed := flag.Int64("seed", 0, "override the scenario seed")
+	accounts := flag.Int("accounts", 0, "override the number of accounts")
+	transactions := flag.Int("transactions", 0, "override transactions_per_account")
+	dryRun := flag.Bool("dry-run", false, "print the generated data instead of saving it")
+	flag.Parse()
+
+	scenario := generator.DefaultScenario()
+	if *scenarioPath != "" {
+		var err error
+		scenario, err = generator.LoadScenario(*scenarioPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *seed != 0 {
+		scenario.Seed = *seed
+	}
+	if *accounts != 0 {
+		scenario.Accounts = *accounts
+	}
+	if *transactions != 0 {
+		scenario.TransactionsPerAccount = *transactions
+	}
+
+	dataset, err := generator.Generate(scenario)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *dryRun {
+		for _, generated := range dataset.Accounts {
+			customer := generated.Customer
+			fmt.Fprintf(os.Stdout, "%s %s %s %s <%s> profile=%s transactions=%d\n",
+				generated.Account.AccountNumber, customer.CURP, customer.Name, customer.LastName, customer.Email, generated.Profile, len(generated.Transactions))
+		}
+		return
+	}
+
+	cfg, err := config.LoadDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	accountService, err := services.NewAccountService(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = dataset.Persist(accountService)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("Created %d accounts and %d transactions with seed %d.", len(dataset.Accounts), dataset.TransactionCount(), scenario.Seed)
+}
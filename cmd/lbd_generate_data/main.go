@@ -2,125 +2,92 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
-	"math"
-	"math/rand"
+	"net/http"
+	"strconv"
 	"time"
 
-	"storichallenge_layer/models"
+	"storichallenge_layer/config"
+	"storichallenge_layer/generator"
 	"storichallenge_layer/services"
+	"storichallenge_layer/validation"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 )
 
+// cfg is loaded once at cold start and shared by every invocation.
+var cfg config.Config
+
 func HandleRequest(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	// Seed the random number generator
-	rand.Seed(time.Now().UnixNano())
+	scenario := generator.DefaultScenario()
+	// Without an explicit seed every invocation produces fresh data; the seed
+	// used is logged and returned so the run can be reproduced.
+	scenario.Seed = time.Now().UnixNano()
+
+	var errs validation.ValidationError
+	if seedParam := request.QueryStringParameters["seed"]; seedParam != "" {
+		seed, err := strconv.ParseInt(seedParam, 10, 64)
+		if errs.Check(err == nil, "seed", validation.CodeInteger, "seed", seedParam) {
+			scenario.Seed = seed
+		}
+	}
+	if accountsParam := request.QueryStringParameters["accounts"]; accountsParam != "" {
+		accounts, err := strconv.Atoi(accountsParam)
+		if errs.Check(err == nil, "accounts", validation.CodeInteger, "accounts", accountsParam) {
+			scenario.Accounts = accounts
+		}
+	}
+	if err := errs.Err(); err != nil {
+		return validation.APIGatewayResponse(err, request.Headers), nil
+	}
 
-	// Initialize the account service
-	accountService, err := services.NewAccountService()
+	dataset, err := generator.Generate(scenario)
+	if _, ok := validation.AsValidationError(err); ok {
+		return validation.APIGatewayResponse(err, request.Headers), nil
+	}
 	if err != nil {
-		log.Fatal(err)
+		log.Println(err)
 		return events.APIGatewayProxyResponse{
-			StatusCode: 500,
+			StatusCode: http.StatusInternalServerError,
 			Body:       err.Error(),
 		}, nil
 	}
 
-	accounts, err := getSampleAccounts()
-
+	// Initialize the account service
+	accountService, err := services.NewAccountService(cfg)
 	if err != nil {
-		log.Fatal(err)
+		log.Println(err)
 		return events.APIGatewayProxyResponse{
-			StatusCode: 500,
+			StatusCode: http.StatusInternalServerError,
 			Body:       err.Error(),
 		}, nil
 	}
 
-	// Create each account in the database
-	for i := range accounts {
-		accountID, err := accountService.CreateAccount(accounts[i])
-		if err != nil {
-			log.Fatalf("Failed to save account in DB: %v", err)
-		}
-		accounts[i].ID = accountID
-	}
-
-	startOfYear := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
-	endOfYear := time.Date(2024, time.December, 31, 23, 59, 59, 0, time.UTC)
-
-	// Create random transactions for each account in database
-	for i := 0; i < 1000; i++ {
-		transactionAmount := int64(randomFloat2Decimals(-1000, 1000) * 100)
-		transactionDate := randomDateTime(startOfYear, endOfYear)
-		anyAccount := accounts[rand.Intn(len(accounts))]
+	log.Printf("Generating %d accounts and %d transactions with seed %d", len(dataset.Accounts), dataset.TransactionCount(), scenario.Seed)
 
-		transaction, err := models.NewTransaction(transactionAmount, transactionDate, anyAccount.ID)
-
-		if err != nil {
-			log.Fatalf("Failed to create transaction: %v", err)
-			return events.APIGatewayProxyResponse{
-				StatusCode: 500,
-				Body:       err.Error(),
-			}, nil
-		}
-
-		err = accountService.CreateTransaction(transaction)
-
-		if err != nil {
-			log.Fatalf("Failed to save transaction in DB: %v", err)
-			return events.APIGatewayProxyResponse{
-				StatusCode: 500,
-				Body:       err.Error(),
-			}, nil
-		}
+	err = dataset.Persist(accountService)
+	if err != nil {
+		log.Println(err)
+		return events.APIGatewayProxyResponse{
+			StatusCode: http.StatusInternalServerError,
+			Body:       err.Error(),
+		}, nil
 	}
 
 	// Return successfull response
 	return events.APIGatewayProxyResponse{
 		StatusCode: 200,
-		Body:       "Accounts and transactions successfully created.",
+		Body:       fmt.Sprintf("Accounts and transactions successfully created with seed %d.", scenario.Seed),
 	}, nil
 }
 
-func getSampleAccounts() ([]models.Account, error) {
-	account1, err := models.NewAccount("Max", "Verstappen", 28, "ignaciomatiasaramburudeveloper@gmail.com")
-	if err != nil {
-		return nil, err
-	}
-	account2, err := models.NewAccount("Charles", "Leclerc", 27, "ignaciomatiasaramburudeveloper@gmail.com")
-	if err != nil {
-		return nil, err
-	}
-	account3, err := models.NewAccount("Valtteri", "Bottas", 26, "ignaciomatiasaramburudeveloper@gmail.com")
+func main() {
+	var err error
+	cfg, err = config.LoadDB()
 	if err != nil {
-		return nil, err
-	}
-
-	// Create some sample accounts
-	accounts := []models.Account{account1, account2, account3}
-
-	return accounts, nil
-}
-
-// randomFloat2Decimals generates a random float64 between min and max, rounded to 2 decimals
-func randomFloat2Decimals(min, max float64) float64 {
-	var result float64
-	for result == 0 {
-		result = min + rand.Float64()*(max-min)
-		result = math.Round(result*100) / 100
+		log.Fatal(err)
 	}
-	return result
-}
-
-// randomDateTime generates a random date between min and max
-func randomDateTime(min, max time.Time) time.Time {
-	diff := max.Unix() - min.Unix()
-	secondsOffset := rand.Int63n(diff)
-	return min.Add(time.Duration(secondsOffset) * time.Second)
-}
-
-func main() {
 	lambda.Start(HandleRequest)
 }
@@ -4,17 +4,23 @@ import (
 	"context"
 	"log"
 	"net/http"
+	"storichallenge_layer/config"
 	"storichallenge_layer/services"
+	"storichallenge_layer/utils"
+	"storichallenge_layer/validation"
 	"strings"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 )
 
+// cfg is loaded once at cold start and shared by every invocation.
+var cfg config.Config
+
 func HandleRequest(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 
 	// Initialize the account service
-	accountService, err := services.NewAccountService()
+	accountService, err := services.NewAccountService(cfg)
 	if err != nil {
 		log.Fatal(err)
 		return events.APIGatewayProxyResponse{
@@ -24,34 +30,20 @@ func HandleRequest(ctx context.Context, request events.APIGatewayProxyRequest) (
 	}
 
 	// Initialize email builder
-	emailBuilder := services.NewEmailBuilder(accountService)
-
-	accountNumber := request.QueryStringParameters["accountNumber"]
+	emailBuilder := services.NewEmailBuilder(accountService, cfg.SMTP)
 
-	if accountNumber == "" {
-		log.Println("Account number is missing from query parameters")
-		return events.APIGatewayProxyResponse{
-			StatusCode: http.StatusBadRequest,
-			Body:       "Account number is required",
-		}, nil
+	params, err := parseSummaryRequest(request.QueryStringParameters)
+	if err != nil {
+		log.Printf("Invalid query parameters: %v", err)
+		return validation.APIGatewayResponse(err, request.Headers), nil
 	}
 
-	monthsParam := request.QueryStringParameters["months"]
-	var months []string
-	if monthsParam == "" {
-		// Default months if no months are provided
-		log.Println("Month is missing from query parameters")
-		return events.APIGatewayProxyResponse{
-			StatusCode: http.StatusBadRequest,
-			Body:       "Month is required",
-		}, nil
+	if params.CURP != "" {
+		err = emailBuilder.SendCustomerSummaryEmail(params.CURP, params.Months)
 	} else {
-		// Split months parameter by commas (e.g., "2024/01,2024/02,2024/03")
-		months = strings.Split(monthsParam, ",")
+		err = emailBuilder.SendAccountSummaryEmail(params.AccountNumber, params.Months)
 	}
 
-	err = emailBuilder.SendAccountSummaryEmail(accountNumber, months)
-
 	if err != nil {
 		log.Printf("Failed to send account summary email: %v", err)
 		return events.APIGatewayProxyResponse{
@@ -67,6 +59,43 @@ func HandleRequest(ctx context.Context, request events.APIGatewayProxyRequest) (
 	}, nil
 }
 
+// summaryRequest holds the query parameters of the endpoint, e.g.
+// ?accountNumber=999001000000000012&months=2024/01,2024/02. Passing curp
+// instead of accountNumber sends one summary of every account of the customer.
+type summaryRequest struct {
+	AccountNumber string
+	CURP          string
+	Months        []string
+}
+
+func parseSummaryRequest(params map[string]string) (summaryRequest, error) {
+	var errs validation.ValidationError
+	parsed := summaryRequest{AccountNumber: params["accountNumber"], CURP: strings.ToUpper(params["curp"])}
+
+	switch {
+	case parsed.CURP != "":
+		errs.Check(validation.IsCURPFormatOK(parsed.CURP), "curp", validation.CodeCURPFormat, parsed.CURP)
+	case errs.Required("accountNumber", parsed.AccountNumber):
+		errs.Check(validation.IsCLABEFormatOK(parsed.AccountNumber), "accountNumber", validation.CodeAccountNumber, parsed.AccountNumber)
+	}
+	if errs.Required("months", params["months"]) {
+		// Months are separated by commas, e.g. "2024/01,2024/02,2024/03"
+		for i, month := range strings.Split(params["months"], ",") {
+			_, err := utils.ParseMonthTime(month)
+			if errs.Check(err == nil, validation.Index("months", i), validation.CodeMonthFormat, month) {
+				parsed.Months = append(parsed.Months, month)
+			}
+		}
+	}
+
+	return parsed, errs.Err()
+}
+
 func main() {
+	var err error
+	cfg, err = config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
 	lambda.Start(HandleRequest)
 }
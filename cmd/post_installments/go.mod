@@ -0,0 +1,3 @@
+module storichallenge/cmd/post_installments
+
+go 1.19
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"time"
+
+	"storichallenge_layer/config"
+	"storichallenge_layer/services"
+)
+
+// Charges every installment of the "meses sin intereses" plans due up to the
+// given date. Schedule it once a day, before close_statement_cycles; an
+// installment is never charged twice.
+func main() {
+	date := flag.String("date", time.Now().UTC().Format("2006-01-02"), "charge installments due up to this date, as YYYY-MM-DD")
+	flag.Parse()
+
+	dueDate, err := time.Parse("2006-01-02", *date)
+	if err != nil {
+		log.Fatalf("Invalid -date %q: %v", *date, err)
+	}
+
+	cfg, err := config.LoadDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	accountService, err := services.NewAccountService(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	installments, err := accountService.PostDueInstallments(dueDate)
+	if err != nil {
+		log.Fatalf("Failed to charge installments: %v", err)
+	}
+
+	log.Printf("Charged %d installments due up to %s.", len(installments), *date)
+}
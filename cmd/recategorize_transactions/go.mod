@@ -0,0 +1,3 @@
+module storichallenge/cmd/recategorize_transactions
+
+go 1.19
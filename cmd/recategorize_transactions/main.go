@@ -0,0 +1,33 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"storichallenge_layer/config"
+	"storichallenge_layer/services"
+)
+
+// Applies the current categorization rules to the transactions already
+// posted. Run it after changing the rules; categories set by hand are kept.
+func main() {
+	accountID := flag.Int64("account-id", 0, "only recategorize this account (default: all accounts)")
+	flag.Parse()
+
+	cfg, err := config.LoadDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	accountService, err := services.NewAccountService(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	changed, err := accountService.RecategorizeTransactions(*accountID)
+	if err != nil {
+		log.Fatalf("Failed to recategorize transactions: %v", err)
+	}
+
+	log.Printf("Recategorized %d transactions.", changed)
+}
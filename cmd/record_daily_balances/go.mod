@@ -0,0 +1,3 @@
+module storichallenge/cmd/record_daily_balances
+
+go 1.19
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"time"
+
+	"storichallenge_layer/config"
+	"storichallenge_layer/services"
+)
+
+// Records the end-of-day balance of every account, computed from its
+// transactions. Schedule it once a day, after midnight UTC, for the day
+// before; pass -from and -to to back-fill a range of days. Running it again
+// for a date records it again.
+func main() {
+	yesterday := time.Now().UTC().AddDate(0, 0, -1).Format("2006-01-02")
+	date := flag.String("date", yesterday, "date to record, as YYYY-MM-DD (default: yesterday)")
+	from := flag.String("from", "", "first date to back-fill, as YYYY-MM-DD")
+	to := flag.String("to", yesterday, "last date to back-fill, as YYYY-MM-DD")
+	flag.Parse()
+
+	first, last := *date, *date
+	if *from != "" {
+		first, last = *from, *to
+	}
+	firstDate, err := time.Parse("2006-01-02", first)
+	if err != nil {
+		log.Fatalf("Invalid date %q: %v", first, err)
+	}
+	lastDate, err := time.Parse("2006-01-02", last)
+	if err != nil {
+		log.Fatalf("Invalid date %q: %v", last, err)
+	}
+
+	cfg, err := config.LoadDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	accountService, err := services.NewAccountService(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Accounts that fail do not stop the others, so what was recorded is
+	// reported either way.
+	recorded, err := accountService.RecordDailyBalances(firstDate, lastDate)
+	log.Printf("Recorded %d daily balances from %s to %s.", recorded, first, last)
+	if err != nil {
+		log.Fatalf("Failed to record daily balances: %v", err)
+	}
+}
@@ -0,0 +1,3 @@
+module storichallenge/cmd/run_accruals
+
+go 1.19
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"time"
+
+	"storichallenge_layer/config"
+	"storichallenge_layer/services"
+)
+
+// Charges the daily interest and the fees of every open credit account.
+// Schedule it once a day, before close_statement_cycles; pass -from and -to
+// to back-fill a range of days. Running it again for a date posts nothing
+// already charged.
+func main() {
+	today := time.Now().UTC().Format("2006-01-02")
+	date := flag.String("date", today, "date to accrue, as YYYY-MM-DD")
+	from := flag.String("from", "", "first date to back-fill, as YYYY-MM-DD")
+	to := flag.String("to", today, "last date to back-fill, as YYYY-MM-DD")
+	flag.Parse()
+
+	first, last := *date, *date
+	if *from != "" {
+		first, last = *from, *to
+	}
+	firstDate, err := time.Parse("2006-01-02", first)
+	if err != nil {
+		log.Fatalf("Invalid date %q: %v", first, err)
+	}
+	lastDate, err := time.Parse("2006-01-02", last)
+	if err != nil {
+		log.Fatalf("Invalid date %q: %v", last, err)
+	}
+
+	cfg, err := config.LoadDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	accountService, err := services.NewAccountService(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	engine := services.NewAccrualEngine(accountService, cfg.Accrual)
+	// Accounts that fail do not stop the others, so what was posted is
+	// reported either way.
+	accruals, err := engine.RunRange(firstDate, lastDate)
+	log.Printf("Posted %d charges from %s to %s.", len(accruals), first, last)
+	if err != nil {
+		log.Fatalf("Failed to run accruals: %v", err)
+	}
+}
@@ -15,6 +15,37 @@
 /*!40101 SET @OLD_SQL_MODE=@@SQL_MODE, SQL_MODE='NO_AUTO_VALUE_ON_ZERO' */;
 /*!40111 SET @OLD_SQL_NOTES=@@SQL_NOTES, SQL_NOTES=0 */;
 
+--
+-- Table structure for table `customer`
+--
+
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8 */;
+CREATE TABLE `customer` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `name` varchar(100) NOT NULL,
+  `last_name` varchar(100) NOT NULL,
+  `second_last_name` varchar(100) NOT NULL DEFAULT '',
+  `date_of_birth` date NOT NULL,
+  `curp` char(18) NOT NULL,
+  `rfc` varchar(13) NOT NULL DEFAULT '',
+  `phone` varchar(16) NOT NULL DEFAULT '',
+  `email` varchar(255) NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `curp` (`curp`),
+  UNIQUE KEY `email` (`email`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `customer`
+--
+
+LOCK TABLES `customer` WRITE;
+/*!40000 ALTER TABLE `customer` DISABLE KEYS */;
+/*!40000 ALTER TABLE `customer` ENABLE KEYS */;
+UNLOCK TABLES;
+
 --
 -- Table structure for table `account`
 --
@@ -23,15 +54,21 @@
 /*!40101 SET character_set_client = utf8 */;
 CREATE TABLE `account` (
   `id` int(11) NOT NULL AUTO_INCREMENT,
+  `customer_id` int(11) NOT NULL,
   `account_number` varchar(20) NOT NULL,
-  `name` varchar(100) NOT NULL,
-  `last_name` varchar(100) NOT NULL,
-  `age` int(11) DEFAULT NULL CHECK (`age` >= 0),
-  `email` varchar(255) DEFAULT NULL,
+  `account_type` varchar(10) NOT NULL DEFAULT 'debit',
   `current_balance_amt` bigint(20) DEFAULT 0,
+  `credit_limit_amt` bigint(20) NOT NULL DEFAULT 0,
+  `cutoff_day` int(11) NOT NULL DEFAULT 0,
+  `payment_due_days` int(11) NOT NULL DEFAULT 0,
+  `status` varchar(10) NOT NULL DEFAULT 'active',
+  `status_reason` varchar(255) NOT NULL DEFAULT '',
+  `status_changed_at` datetime DEFAULT NULL,
+  `closed_through` varchar(7) NOT NULL DEFAULT '',
   PRIMARY KEY (`id`),
   UNIQUE KEY `account_number` (`account_number`),
-  UNIQUE KEY `email` (`email`)
+  KEY `customer_id` (`customer_id`),
+  CONSTRAINT `account_ibfk_1` FOREIGN KEY (`customer_id`) REFERENCES `customer` (`id`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 /*!40101 SET character_set_client = @saved_cs_client */;
 
@@ -54,6 +91,7 @@ CREATE TABLE `balance` (
   `account_id` int(11) NOT NULL,
   `month` varchar(7) NOT NULL,
   `amt` bigint(20) NOT NULL,
+  `opening_amt` bigint(20) NOT NULL DEFAULT 0,
   PRIMARY KEY (`account_id`,`month`),
   CONSTRAINT `balance_ibfk_1` FOREIGN KEY (`account_id`) REFERENCES `account` (`id`) ON DELETE CASCADE
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -80,8 +118,22 @@ CREATE TABLE `transaction` (
   `month` varchar(7) NOT NULL,
   `dt` datetime NOT NULL,
   `amt` bigint(20) NOT NULL,
+  `txn_type` varchar(20) NOT NULL DEFAULT '',
+  `description` varchar(255) NOT NULL DEFAULT '',
+  `merchant` varchar(100) NOT NULL DEFAULT '',
+  `mcc` varchar(4) NOT NULL DEFAULT '',
+  `channel` varchar(10) NOT NULL DEFAULT '',
+  `location` varchar(100) NOT NULL DEFAULT '',
+  `category` varchar(40) NOT NULL DEFAULT '',
+  `category_override` varchar(40) NOT NULL DEFAULT '',
+  `transfer_id` int(11) DEFAULT NULL,
+  `original_transaction_id` int(11) DEFAULT NULL,
+  `value_dt` datetime DEFAULT NULL,
   PRIMARY KEY (`id`),
   KEY `account_id` (`account_id`,`month`),
+  KEY `account_id_dt` (`account_id`,`dt`,`id`),
+  KEY `transfer_id` (`transfer_id`),
+  KEY `original_transaction_id` (`original_transaction_id`),
   CONSTRAINT `transaction_ibfk_1` FOREIGN KEY (`account_id`, `month`) REFERENCES `balance` (`account_id`, `month`) ON DELETE CASCADE
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 /*!40101 SET character_set_client = @saved_cs_client */;
@@ -95,6 +147,338 @@ LOCK TABLES `transaction` WRITE;
 /*!40000 ALTER TABLE `transaction` ENABLE KEYS */;
 UNLOCK TABLES;
 
+--
+-- Table structure for table `monthly_stats`
+--
+
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8 */;
+CREATE TABLE `monthly_stats` (
+  `account_id` int(11) NOT NULL,
+  `month` varchar(7) NOT NULL,
+  `txn_count` bigint(20) NOT NULL DEFAULT 0,
+  `debit_count` bigint(20) NOT NULL DEFAULT 0,
+  `debit_sum` bigint(20) NOT NULL DEFAULT 0,
+  `credit_count` bigint(20) NOT NULL DEFAULT 0,
+  `credit_sum` bigint(20) NOT NULL DEFAULT 0,
+  `min_amt` bigint(20) NOT NULL DEFAULT 0,
+  `max_amt` bigint(20) NOT NULL DEFAULT 0,
+  PRIMARY KEY (`account_id`,`month`),
+  CONSTRAINT `monthly_stats_ibfk_1` FOREIGN KEY (`account_id`, `month`) REFERENCES `balance` (`account_id`, `month`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `monthly_stats`
+--
+
+LOCK TABLES `monthly_stats` WRITE;
+/*!40000 ALTER TABLE `monthly_stats` DISABLE KEYS */;
+/*!40000 ALTER TABLE `monthly_stats` ENABLE KEYS */;
+UNLOCK TABLES;
+
+--
+-- Table structure for table `account_status_history`
+--
+
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8 */;
+CREATE TABLE `account_status_history` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `account_id` int(11) NOT NULL,
+  `from_status` varchar(10) NOT NULL,
+  `to_status` varchar(10) NOT NULL,
+  `reason` varchar(255) NOT NULL,
+  `changed_at` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `account_id` (`account_id`,`changed_at`),
+  CONSTRAINT `account_status_history_ibfk_1` FOREIGN KEY (`account_id`) REFERENCES `account` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `account_status_history`
+--
+
+LOCK TABLES `account_status_history` WRITE;
+/*!40000 ALTER TABLE `account_status_history` DISABLE KEYS */;
+/*!40000 ALTER TABLE `account_status_history` ENABLE KEYS */;
+UNLOCK TABLES;
+
+--
+-- Table structure for table `statement`
+--
+
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8 */;
+CREATE TABLE `statement` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `account_id` int(11) NOT NULL,
+  `period_start` date NOT NULL,
+  `period_end` date NOT NULL,
+  `due_date` date NOT NULL,
+  `previous_balance_amt` bigint(20) NOT NULL,
+  `purchases_amt` bigint(20) NOT NULL,
+  `charges_amt` bigint(20) NOT NULL DEFAULT 0,
+  `payments_amt` bigint(20) NOT NULL,
+  `statement_balance_amt` bigint(20) NOT NULL,
+  `minimum_payment_amt` bigint(20) NOT NULL,
+  `credit_limit_amt` bigint(20) NOT NULL,
+  `available_credit_amt` bigint(20) NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `period_end` (`account_id`,`period_end`),
+  CONSTRAINT `statement_ibfk_1` FOREIGN KEY (`account_id`) REFERENCES `account` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `statement`
+--
+
+LOCK TABLES `statement` WRITE;
+/*!40000 ALTER TABLE `statement` DISABLE KEYS */;
+/*!40000 ALTER TABLE `statement` ENABLE KEYS */;
+UNLOCK TABLES;
+
+--
+-- Table structure for table `accrual`
+--
+
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8 */;
+CREATE TABLE `accrual` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `account_id` int(11) NOT NULL,
+  `accrual_date` date NOT NULL,
+  `kind` varchar(20) NOT NULL,
+  `amt` bigint(20) NOT NULL,
+  `transaction_id` int(11) NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `accrual_date` (`account_id`,`accrual_date`,`kind`),
+  CONSTRAINT `accrual_ibfk_1` FOREIGN KEY (`account_id`) REFERENCES `account` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `accrual`
+--
+
+LOCK TABLES `accrual` WRITE;
+/*!40000 ALTER TABLE `accrual` DISABLE KEYS */;
+/*!40000 ALTER TABLE `accrual` ENABLE KEYS */;
+UNLOCK TABLES;
+
+--
+-- Table structure for table `installment_plan`
+--
+
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8 */;
+CREATE TABLE `installment_plan` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `account_id` int(11) NOT NULL,
+  `purchase_dt` datetime NOT NULL,
+  `amt` bigint(20) NOT NULL,
+  `months` int(11) NOT NULL,
+  `status` varchar(10) NOT NULL DEFAULT 'active',
+  `closed_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `account_id_status` (`account_id`,`status`),
+  CONSTRAINT `installment_plan_ibfk_1` FOREIGN KEY (`account_id`) REFERENCES `account` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `installment_plan`
+--
+
+LOCK TABLES `installment_plan` WRITE;
+/*!40000 ALTER TABLE `installment_plan` DISABLE KEYS */;
+/*!40000 ALTER TABLE `installment_plan` ENABLE KEYS */;
+UNLOCK TABLES;
+
+--
+-- Table structure for table `installment`
+--
+
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8 */;
+CREATE TABLE `installment` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `plan_id` int(11) NOT NULL,
+  `account_id` int(11) NOT NULL,
+  `number` int(11) NOT NULL,
+  `due_dt` datetime NOT NULL,
+  `month` varchar(7) NOT NULL,
+  `amt` bigint(20) NOT NULL,
+  `status` varchar(10) NOT NULL DEFAULT 'pending',
+  `transaction_id` int(11) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `plan_id_number` (`plan_id`,`number`),
+  KEY `account_id_status` (`account_id`,`status`),
+  KEY `status_due_dt` (`status`,`due_dt`),
+  CONSTRAINT `installment_ibfk_1` FOREIGN KEY (`plan_id`) REFERENCES `installment_plan` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `installment`
+--
+
+LOCK TABLES `installment` WRITE;
+/*!40000 ALTER TABLE `installment` DISABLE KEYS */;
+/*!40000 ALTER TABLE `installment` ENABLE KEYS */;
+UNLOCK TABLES;
+
+--
+-- Table structure for table `transfer`
+--
+
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8 */;
+CREATE TABLE `transfer` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `client_reference` varchar(64) NOT NULL,
+  `from_account_id` int(11) NOT NULL,
+  `to_account_id` int(11) NOT NULL,
+  `amt` bigint(20) NOT NULL,
+  `description` varchar(255) NOT NULL DEFAULT '',
+  `dt` datetime NOT NULL,
+  `status` varchar(10) NOT NULL DEFAULT 'posted',
+  `reversed_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `client_reference` (`client_reference`),
+  KEY `from_account_id` (`from_account_id`),
+  KEY `to_account_id` (`to_account_id`),
+  CONSTRAINT `transfer_ibfk_1` FOREIGN KEY (`from_account_id`) REFERENCES `account` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `transfer_ibfk_2` FOREIGN KEY (`to_account_id`) REFERENCES `account` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `transfer`
+--
+
+LOCK TABLES `transfer` WRITE;
+/*!40000 ALTER TABLE `transfer` DISABLE KEYS */;
+/*!40000 ALTER TABLE `transfer` ENABLE KEYS */;
+UNLOCK TABLES;
+
+--
+-- Table structure for table `journal_entry`
+--
+
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8 */;
+CREATE TABLE `journal_entry` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `transaction_id` int(11) DEFAULT NULL,
+  `dt` datetime NOT NULL,
+  `description` varchar(255) NOT NULL DEFAULT '',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `transaction_id` (`transaction_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `journal_entry`
+--
+
+LOCK TABLES `journal_entry` WRITE;
+/*!40000 ALTER TABLE `journal_entry` DISABLE KEYS */;
+/*!40000 ALTER TABLE `journal_entry` ENABLE KEYS */;
+UNLOCK TABLES;
+
+--
+-- Table structure for table `posting`
+--
+
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8 */;
+CREATE TABLE `posting` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `entry_id` int(11) NOT NULL,
+  `ledger_account` varchar(20) NOT NULL,
+  `account_id` int(11) DEFAULT NULL,
+  `amt` bigint(20) NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `entry_id` (`entry_id`),
+  KEY `ledger_account_account_id` (`ledger_account`,`account_id`),
+  CONSTRAINT `posting_ibfk_1` FOREIGN KEY (`entry_id`) REFERENCES `journal_entry` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `posting`
+--
+
+LOCK TABLES `posting` WRITE;
+/*!40000 ALTER TABLE `posting` DISABLE KEYS */;
+/*!40000 ALTER TABLE `posting` ENABLE KEYS */;
+UNLOCK TABLES;
+
+--
+-- Table structure for table `authorization_hold`
+--
+
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8 */;
+CREATE TABLE `authorization_hold` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `account_id` int(11) NOT NULL,
+  `amt` bigint(20) NOT NULL,
+  `captured_amt` bigint(20) NOT NULL DEFAULT 0,
+  `txn_type` varchar(20) NOT NULL DEFAULT '',
+  `description` varchar(255) NOT NULL DEFAULT '',
+  `merchant` varchar(100) NOT NULL DEFAULT '',
+  `mcc` varchar(4) NOT NULL DEFAULT '',
+  `channel` varchar(10) NOT NULL DEFAULT '',
+  `location` varchar(100) NOT NULL DEFAULT '',
+  `authorized_at` datetime NOT NULL,
+  `expires_at` datetime NOT NULL,
+  `status` varchar(10) NOT NULL DEFAULT 'pending',
+  `resolved_at` datetime DEFAULT NULL,
+  `transaction_id` int(11) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `account_id_status` (`account_id`,`status`),
+  KEY `status_expires_at` (`status`,`expires_at`),
+  CONSTRAINT `authorization_hold_ibfk_1` FOREIGN KEY (`account_id`) REFERENCES `account` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `authorization_hold`
+--
+
+LOCK TABLES `authorization_hold` WRITE;
+/*!40000 ALTER TABLE `authorization_hold` DISABLE KEYS */;
+/*!40000 ALTER TABLE `authorization_hold` ENABLE KEYS */;
+UNLOCK TABLES;
+
+--
+-- Table structure for table `daily_balance`
+--
+
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8 */;
+CREATE TABLE `daily_balance` (
+  `account_id` int(11) NOT NULL,
+  `balance_date` date NOT NULL,
+  `amt` bigint(20) NOT NULL,
+  PRIMARY KEY (`account_id`,`balance_date`),
+  CONSTRAINT `daily_balance_ibfk_1` FOREIGN KEY (`account_id`) REFERENCES `account` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `daily_balance`
+--
+
+LOCK TABLES `daily_balance` WRITE;
+/*!40000 ALTER TABLE `daily_balance` DISABLE KEYS */;
+/*!40000 ALTER TABLE `daily_balance` ENABLE KEYS */;
+UNLOCK TABLES;
+
 --
 -- Dumping routines for database 'stori_db'
 --
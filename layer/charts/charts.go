@@ -0,0 +1,243 @@
+// Package charts draws simple charts with the standard library only, so that
+// emails and PDF statements can share them. Charts carry no text: callers
+// label them, e.g. with a legend in the email using the Palette colors.
+package charts
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+)
+
+const (
+	// MARGIN is the blank border, in pixels, around the plot area.
+	MARGIN = 10
+	// LINE_WIDTH is the thickness of lines, in pixels.
+	LINE_WIDTH = 3
+	// DONUT_HOLE is the radius of the hole of a donut as a fraction of its
+	// outer radius.
+	DONUT_HOLE = 0.55
+)
+
+var (
+	// Palette colors the series, bars and slices of a chart in order; it
+	// wraps around when there are more.
+	Palette = []color.RGBA{
+		{0x00, 0x7a, 0x78, 0xff},
+		{0xe4, 0x57, 0x2e, 0xff},
+		{0x29, 0x66, 0xb8, 0xff},
+		{0xf3, 0xa7, 0x12, 0xff},
+		{0x6a, 0x4c, 0x93, 0xff},
+		{0x8a, 0xb1, 0x7d, 0xff},
+		{0xc1, 0x66, 0x6b, 0xff},
+		{0x7f, 0x7f, 0x7f, 0xff},
+	}
+	background = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	axis       = color.RGBA{0xc8, 0xc8, 0xc8, 0xff}
+)
+
+// Color returns the i-th color of the Palette.
+func Color(i int) color.RGBA {
+	return Palette[i%len(Palette)]
+}
+
+// Hex returns the i-th color of the Palette as an HTML color, e.g. #007a78.
+func Hex(i int) string {
+	c := Color(i)
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// Line draws values from left to right joined by a line, with a horizontal
+// axis at zero when it falls within the chart.
+func Line(values []float64, width, height int) *image.RGBA {
+	img := canvas(width, height)
+	low, high := bounds(values)
+	scale := newScale(low, high, height)
+	drawZero(img, scale)
+
+	step := 0.0
+	if len(values) > 1 {
+		step = float64(width-2*MARGIN) / float64(len(values)-1)
+	}
+	x := func(i int) int {
+		if len(values) == 1 {
+			return width / 2
+		}
+		return MARGIN + int(math.Round(float64(i)*step))
+	}
+	for i := 1; i < len(values); i++ {
+		drawLine(img, x(i-1), scale.y(values[i-1]), x(i), scale.y(values[i]), Color(0))
+	}
+	for i, value := range values {
+		fillCircle(img, x(i), scale.y(value), LINE_WIDTH+1, Color(0))
+	}
+	return img
+}
+
+// Bars draws one group of side by side bars per element of groups, each bar
+// colored after its position in the group. Negative values go below the zero
+// axis.
+func Bars(groups [][]float64, width, height int) *image.RGBA {
+	img := canvas(width, height)
+	var all []float64
+	for _, group := range groups {
+		all = append(all, group...)
+	}
+	low, high := bounds(all)
+	scale := newScale(low, high, height)
+
+	if len(groups) > 0 {
+		slot := float64(width-2*MARGIN) / float64(len(groups))
+		for i, group := range groups {
+			if len(group) == 0 {
+				continue
+			}
+			// Bars take up 80% of their slot, leaving a gap between groups.
+			barWidth := slot * 0.8 / float64(len(group))
+			left := float64(MARGIN) + float64(i)*slot + slot*0.1
+			for j, value := range group {
+				x0 := int(math.Round(left + float64(j)*barWidth))
+				x1 := int(math.Round(left+float64(j+1)*barWidth)) - 1
+				y0, y1 := scale.y(0), scale.y(value)
+				if y0 > y1 {
+					y0, y1 = y1, y0
+				}
+				draw.Draw(img, image.Rect(x0, y0, max(x1, x0+1), y1+1), image.NewUniform(Color(j)), image.Point{}, draw.Src)
+			}
+		}
+	}
+	drawZero(img, scale)
+	return img
+}
+
+// Donut draws a ring split into one slice per value, clockwise from the top.
+// Values that are not positive get no slice.
+func Donut(values []float64, size int) *image.RGBA {
+	img := canvas(size, size)
+	total := 0.0
+	for _, value := range values {
+		if value > 0 {
+			total += value
+		}
+	}
+	if total == 0 {
+		return img
+	}
+
+	// ends holds the angle, from the top, at which each slice ends.
+	ends := make([]float64, len(values))
+	angle := 0.0
+	for i, value := range values {
+		if value > 0 {
+			angle += value / total * 2 * math.Pi
+		}
+		ends[i] = angle
+	}
+
+	center := float64(size) / 2
+	outer := center - MARGIN
+	inner := outer * DONUT_HOLE
+	for py := 0; py < size; py++ {
+		for px := 0; px < size; px++ {
+			dx, dy := float64(px)+0.5-center, float64(py)+0.5-center
+			distance := math.Hypot(dx, dy)
+			if distance < inner || distance > outer {
+				continue
+			}
+			angle := math.Atan2(dx, -dy)
+			if angle < 0 {
+				angle += 2 * math.Pi
+			}
+			for i, end := range ends {
+				if angle <= end && values[i] > 0 {
+					img.SetRGBA(px, py, Color(i))
+					break
+				}
+			}
+		}
+	}
+	return img
+}
+
+// PNG encodes a chart.
+func PNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("error while encoding chart: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func canvas(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	return img
+}
+
+// bounds returns the range of values, widened to include zero so that bars
+// and balances are measured from it.
+func bounds(values []float64) (float64, float64) {
+	low, high := 0.0, 0.0
+	for _, value := range values {
+		low = math.Min(low, value)
+		high = math.Max(high, value)
+	}
+	if low == high {
+		high = low + 1
+	}
+	return low, high
+}
+
+// scale maps values to the rows of the plot area.
+type scale struct {
+	low, high float64
+	top       int
+	bottom    int
+}
+
+func newScale(low, high float64, height int) scale {
+	return scale{low: low, high: high, top: MARGIN, bottom: height - MARGIN - 1}
+}
+
+func (s scale) y(value float64) int {
+	return s.bottom - int(math.Round((value-s.low)/(s.high-s.low)*float64(s.bottom-s.top)))
+}
+
+func drawZero(img *image.RGBA, s scale) {
+	y := s.y(0)
+	for x := img.Bounds().Min.X; x < img.Bounds().Max.X; x++ {
+		img.SetRGBA(x, y, axis)
+	}
+}
+
+// drawLine draws a segment LINE_WIDTH pixels thick by stamping a disc every
+// pixel along it.
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.RGBA) {
+	steps := max(abs(x1-x0), abs(y1-y0), 1)
+	for i := 0; i <= steps; i++ {
+		x := x0 + int(math.Round(float64((x1-x0)*i)/float64(steps)))
+		y := y0 + int(math.Round(float64((y1-y0)*i)/float64(steps)))
+		fillCircle(img, x, y, LINE_WIDTH/2, c)
+	}
+}
+
+func fillCircle(img *image.RGBA, cx, cy, radius int, c color.RGBA) {
+	for y := cy - radius; y <= cy+radius; y++ {
+		for x := cx - radius; x <= cx+radius; x++ {
+			if (x-cx)*(x-cx)+(y-cy)*(y-cy) <= radius*radius && image.Pt(x, y).In(img.Bounds()) {
+				img.SetRGBA(x, y, c)
+			}
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
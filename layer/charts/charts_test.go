@@ -0,0 +1,132 @@
+package charts
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// countColor returns how many pixels of img are c.
+func countColor(img *image.RGBA, c color.RGBA) int {
+	n := 0
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if img.RGBAAt(x, y) == c {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func checkSize(tb testing.TB, img *image.RGBA, width, height int) {
+	tb.Helper()
+	if img.Bounds() != image.Rect(0, 0, width, height) {
+		tb.Errorf("chart bounds %v, want %dx%d", img.Bounds(), width, height)
+	}
+}
+
+func TestLine(t *testing.T) {
+	for _, values := range [][]float64{nil, {}} {
+		img := Line(values, 120, 60)
+		checkSize(t, img, 120, 60)
+		if n := countColor(img, Color(0)); n != 0 {
+			t.Errorf("Line(%v) drew %d pixels, want none", values, n)
+		}
+		// The zero axis sits at the bottom of the default range.
+		if img.RGBAAt(60, 60-MARGIN-1) != axis {
+			t.Errorf("Line(%v) has no zero axis", values)
+		}
+	}
+
+	// A single month is a dot in the middle.
+	img := Line([]float64{500}, 120, 60)
+	if img.RGBAAt(60, MARGIN) != Color(0) {
+		t.Error("single value not drawn in the middle at the top")
+	}
+	if img.RGBAAt(MARGIN, MARGIN) != background {
+		t.Error("single value drawn as a line")
+	}
+
+	// A line from a debt to a balance crosses the zero axis in the middle.
+	img = Line([]float64{-100, 100}, 120, 61)
+	if img.RGBAAt(MARGIN, 61-MARGIN-1) != Color(0) || img.RGBAAt(120-MARGIN, MARGIN) != Color(0) {
+		t.Error("line does not go from the bottom left to the top right")
+	}
+	if img.RGBAAt(1, 30) != axis {
+		t.Error("zero axis not in the middle")
+	}
+}
+
+func TestBars(t *testing.T) {
+	img := Bars(nil, 120, 60)
+	checkSize(t, img, 120, 60)
+	if countColor(img, Color(0))+countColor(img, Color(1)) != 0 {
+		t.Error("Bars without groups drew bars")
+	}
+
+	// One month spending 100 and receiving nothing, then one receiving 50.
+	img = Bars([][]float64{{100, 0}, {0, 50}}, 120, 60)
+	spent, received := countColor(img, Color(0)), countColor(img, Color(1))
+	if spent == 0 || received == 0 || received >= spent {
+		t.Errorf("bars of %d and %d pixels, want the second about half the first", spent, received)
+	}
+
+	// Negative values go below the axis.
+	img = Bars([][]float64{{-100}}, 120, 60)
+	if img.RGBAAt(60, 60-MARGIN-1) != Color(0) || img.RGBAAt(60, MARGIN-1) != background {
+		t.Error("negative bar not drawn below the zero axis")
+	}
+}
+
+func TestDonut(t *testing.T) {
+	for _, values := range [][]float64{nil, {0}, {-10, 0}} {
+		img := Donut(values, 100)
+		checkSize(t, img, 100, 100)
+		if n := countColor(img, background); n != 100*100 {
+			t.Errorf("Donut(%v) drew %d pixels, want none", values, 100*100-n)
+		}
+	}
+
+	// A single category is the whole ring.
+	img := Donut([]float64{30}, 100)
+	top, right, bottom, left := img.RGBAAt(50, MARGIN+2), img.RGBAAt(100-MARGIN-2, 50), img.RGBAAt(50, 100-MARGIN-2), img.RGBAAt(MARGIN+2, 50)
+	if top != Color(0) || right != Color(0) || bottom != Color(0) || left != Color(0) {
+		t.Error("single slice is not a full ring")
+	}
+	if img.RGBAAt(50, 50) != background {
+		t.Error("donut has no hole")
+	}
+
+	// Slices go clockwise from the top and skip values that are not positive.
+	img = Donut([]float64{10, -5, 10}, 100)
+	if img.RGBAAt(100-MARGIN-2, 50) != Color(0) || img.RGBAAt(MARGIN+2, 50) != Color(2) || countColor(img, Color(1)) != 0 {
+		t.Error("two equal slices do not split the ring into right and left halves")
+	}
+}
+
+func TestPNG(t *testing.T) {
+	encoded, err := PNG(Line([]float64{1, 2, 3}, 80, 40))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := png.Decode(bytes.NewReader(encoded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Bounds() != image.Rect(0, 0, 80, 40) {
+		t.Errorf("decoded bounds %v, want 80x40", decoded.Bounds())
+	}
+}
+
+func TestColor(t *testing.T) {
+	if Color(len(Palette)) != Color(0) {
+		t.Error("Palette does not wrap around")
+	}
+	if Hex(0) != "#007a78" || Hex(3) != "#f3a712" {
+		t.Errorf("Hex(0), Hex(3) = %s, %s, want #007a78, #f3a712", Hex(0), Hex(3))
+	}
+}
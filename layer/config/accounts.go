@@ -0,0 +1,33 @@
+package config
+
+import (
+	"fmt"
+
+	"storichallenge_layer/models"
+	"storichallenge_layer/validation"
+)
+
+// AccountsConfig holds the CLABE prefix of the accounts this deployment
+// issues.
+type AccountsConfig struct {
+	BankCode   string `yaml:"bank_code" json:"bank_code"`
+	BranchCode string `yaml:"branch_code" json:"branch_code"`
+}
+
+func (cfg AccountsConfig) validate() []string {
+	var problems []string
+	if !validation.IsCLABECodeFormatOK(cfg.BankCode) {
+		problems = append(problems, fmt.Sprintf("ACCOUNT_BANK_CODE must be 3 digits, instead given: %s", cfg.BankCode))
+	}
+	if !validation.IsCLABECodeFormatOK(cfg.BranchCode) {
+		problems = append(problems, fmt.Sprintf("ACCOUNT_BRANCH_CODE must be 3 digits, instead given: %s", cfg.BranchCode))
+	}
+	return problems
+}
+
+func defaultAccountsConfig() AccountsConfig {
+	return AccountsConfig{
+		BankCode:   models.DEFAULT_BANK_CODE,
+		BranchCode: models.DEFAULT_BRANCH_CODE,
+	}
+}
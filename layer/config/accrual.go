@@ -0,0 +1,45 @@
+package config
+
+import "fmt"
+
+const (
+	DEFAULT_ACCRUAL_APR      = 0.60
+	DEFAULT_ACCRUAL_LATE_FEE = 350_00
+	// DEFAULT_ACCRUAL_IVA_RATE is the Mexican VAT, charged on card fees and
+	// interest.
+	DEFAULT_ACCRUAL_IVA_RATE = 0.16
+)
+
+// AccrualConfig drives the interest and fee accrual engine for credit
+// accounts. Fees are in cents; rates are fractions, e.g. 0.6 for a 60% APR.
+type AccrualConfig struct {
+	APR        float64 `yaml:"apr" json:"apr"`
+	MonthlyFee int     `yaml:"monthly_fee" json:"monthly_fee"`
+	LateFee    int     `yaml:"late_fee" json:"late_fee"`
+	IVARate    float64 `yaml:"iva_rate" json:"iva_rate"`
+}
+
+func (cfg AccrualConfig) validate() []string {
+	var problems []string
+	if cfg.APR < 0 || cfg.APR > 10 {
+		problems = append(problems, fmt.Sprintf("ACCRUAL_APR must be a fraction between 0 and 10, instead given: %v", cfg.APR))
+	}
+	if cfg.MonthlyFee < 0 {
+		problems = append(problems, "ACCRUAL_MONTHLY_FEE must not be negative")
+	}
+	if cfg.LateFee < 0 {
+		problems = append(problems, "ACCRUAL_LATE_FEE must not be negative")
+	}
+	if cfg.IVARate < 0 || cfg.IVARate > 1 {
+		problems = append(problems, fmt.Sprintf("ACCRUAL_IVA_RATE must be a fraction between 0 and 1, instead given: %v", cfg.IVARate))
+	}
+	return problems
+}
+
+func defaultAccrualConfig() AccrualConfig {
+	return AccrualConfig{
+		APR:     DEFAULT_ACCRUAL_APR,
+		LateFee: DEFAULT_ACCRUAL_LATE_FEE,
+		IVARate: DEFAULT_ACCRUAL_IVA_RATE,
+	}
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// CategoriesConfig holds the rules that categorize transactions. Rules set
+// in the config file replace the built-in ones.
+type CategoriesConfig struct {
+	Rules []CategoryRule `yaml:"rules" json:"rules"`
+}
+
+// CategoryRule assigns Category to the transactions matching every condition
+// it sets; a rule without conditions matches every transaction. Rules are
+// tried by decreasing Priority, in file order on ties.
+type CategoryRule struct {
+	Category string `yaml:"category" json:"category"`
+	Priority int    `yaml:"priority" json:"priority"`
+	// MCC lists merchant category codes, e.g. "5411", or inclusive ranges of
+	// them, e.g. "5811-5814".
+	MCC []string `yaml:"mcc" json:"mcc"`
+	// Merchant and Description are case-insensitive regular expressions.
+	Merchant    string   `yaml:"merchant" json:"merchant"`
+	Description string   `yaml:"description" json:"description"`
+	Types       []string `yaml:"types" json:"types"`
+	// Kind is debit or credit.
+	Kind string `yaml:"kind" json:"kind"`
+	// MinAmount and MaxAmount bound the absolute amount, in currency units.
+	MinAmount *float64 `yaml:"min_amount" json:"min_amount"`
+	MaxAmount *float64 `yaml:"max_amount" json:"max_amount"`
+}
+
+var mccRange = regexp.MustCompile(`^[0-9]{4}(-[0-9]{4})?$`)
+
+func (cfg CategoriesConfig) validate() []string {
+	var problems []string
+	for i, rule := range cfg.Rules {
+		add := func(format string, args ...any) {
+			problems = append(problems, fmt.Sprintf("category rule %d (%s): ", i+1, rule.Category)+fmt.Sprintf(format, args...))
+		}
+		if strings.TrimSpace(rule.Category) == "" {
+			add("category must be provided")
+		}
+		for _, mcc := range rule.MCC {
+			if !mccRange.MatchString(mcc) {
+				add("mcc must be a 4 digit code or a range such as 5811-5814, instead given: %s", mcc)
+			} else if from, to, found := strings.Cut(mcc, "-"); found && from > to {
+				add("mcc range must not be reversed, instead given: %s", mcc)
+			}
+		}
+		for _, pattern := range []string{rule.Merchant, rule.Description} {
+			if _, err := regexp.Compile(pattern); err != nil {
+				add("invalid pattern %q: %v", pattern, err)
+			}
+		}
+		if rule.Kind != "" && rule.Kind != "debit" && rule.Kind != "credit" {
+			add("kind must be debit or credit, instead given: %s", rule.Kind)
+		}
+		if rule.MinAmount != nil && rule.MaxAmount != nil && *rule.MinAmount > *rule.MaxAmount {
+			add("min_amount must not be greater than max_amount")
+		}
+	}
+	return problems
+}
+
+func defaultCategoriesConfig() CategoriesConfig {
+	return CategoriesConfig{Rules: []CategoryRule{
+		{Category: "fees", Priority: 100, Types: []string{"fee", "interest"}},
+		{Category: "income", Priority: 90, Kind: "credit", Description: `salary|payroll|nomina|allowance|client`},
+		{Category: "groceries", Priority: 50, MCC: []string{"5411", "5422", "5441", "5451", "5499"}},
+		{Category: "restaurants", Priority: 50, MCC: []string{"5811-5814"}},
+		{Category: "transport", Priority: 50, MCC: []string{"4011-4131", "4784", "5541", "5542"}},
+		{Category: "entertainment", Priority: 50, MCC: []string{"4899", "7832", "7841", "7911-7999"}},
+		{Category: "utilities", Priority: 50, MCC: []string{"4812-4816", "4900"}},
+		{Category: "shopping", Priority: 40, MCC: []string{"5300-5399", "5611-5699", "5732-5735", "5941-5949"}},
+		{Category: "health", Priority: 40, MCC: []string{"5912", "8011-8099"}},
+		{Category: "housing", Priority: 30, Description: `rent|renta|coworking`},
+		{Category: "transfers", Priority: 10, Types: []string{"transfer"}},
+	}}
+}
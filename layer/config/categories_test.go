@@ -0,0 +1,83 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCategoriesValidate(t *testing.T) {
+	one, two := 1.0, 2.0
+	tests := []struct {
+		name string
+		rule CategoryRule
+		want []string
+	}{
+		{"valid", CategoryRule{Category: "coffee", MCC: []string{"5814", "5811-5814"}, Merchant: `starbucks|cielito`,
+			Kind: "debit", MinAmount: &one, MaxAmount: &two}, nil},
+		{"no conditions", CategoryRule{Category: "other"}, nil},
+		{"blank category", CategoryRule{Category: " "}, []string{"category must be provided"}},
+		{"mcc format", CategoryRule{Category: "food", MCC: []string{"581", "5811-58"}},
+			[]string{"instead given: 581", "instead given: 5811-58"}},
+		{"reversed range", CategoryRule{Category: "food", MCC: []string{"5814-5811"}}, []string{"mcc range must not be reversed"}},
+		{"patterns", CategoryRule{Category: "food", Merchant: "(", Description: "[a-"},
+			[]string{`invalid pattern "("`, `invalid pattern "[a-"`}},
+		{"kind", CategoryRule{Category: "food", Kind: "both"}, []string{"kind must be debit or credit"}},
+		{"amounts", CategoryRule{Category: "food", MinAmount: &two, MaxAmount: &one}, []string{"min_amount must not be greater than max_amount"}},
+	}
+	for _, test := range tests {
+		problems := CategoriesConfig{Rules: []CategoryRule{{Category: "fees"}, test.rule}}.validate()
+		if len(problems) != len(test.want) {
+			t.Errorf("%s: problems %q, want %d", test.name, problems, len(test.want))
+			continue
+		}
+		for i, problem := range problems {
+			// Problems name the rule by position and category.
+			if !strings.HasPrefix(problem, "category rule 2 (") || !strings.Contains(problem, test.want[i]) {
+				t.Errorf("%s: problem %q, want rule 2 and %q", test.name, problem, test.want[i])
+			}
+		}
+	}
+
+	if problems := defaultCategoriesConfig().validate(); len(problems) != 0 {
+		t.Errorf("built-in rules have problems %q", problems)
+	}
+}
+
+func TestLoadCategoriesReplaceDefaults(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("CONFIG_FILE", writeFile(t, t.TempDir(), "config.yaml", `
+db:
+  host: db
+  user: stori
+  name: stori
+categories:
+  rules:
+    - category: coffee
+      priority: 60
+      mcc: ["5811-5814"]
+      merchant: starbucks
+      min_amount: 20.5
+    - category: other
+`))
+	cfg, err := LoadDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := cfg.Categories.Rules
+	if len(rules) != 2 || rules[0].Category != "coffee" || rules[1].Category != "other" {
+		t.Fatalf("rules %+v, want coffee and other only", rules)
+	}
+	if rules[0].Priority != 60 || rules[0].MCC[0] != "5811-5814" || rules[0].Merchant != "starbucks" ||
+		rules[0].MinAmount == nil || *rules[0].MinAmount != 20.5 || rules[0].MaxAmount != nil {
+		t.Errorf("coffee rule %+v, want every setting of the file", rules[0])
+	}
+
+	t.Setenv("CONFIG_FILE", writeFile(t, t.TempDir(), "config.yaml", `
+categories:
+  rules:
+    - category: food
+      mcc: ["5814-5811"]
+`))
+	_, err = LoadDB()
+	checkProblems(t, err, "category rule 1 (food): mcc range must not be reversed")
+}
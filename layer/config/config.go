@@ -0,0 +1,378 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"storichallenge_layer/storage"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	DEFAULT_MYSQL_PORT    = "3306"
+	DEFAULT_POSTGRES_PORT = "5432"
+	DEFAULT_SMTP_PORT     = "587"
+)
+
+// Config holds every setting the layer needs. It is built by Load from, in
+// increasing order of precedence: defaults, an optional YAML/JSON file pointed
+// to by CONFIG_FILE, environment variables and the configured secret provider.
+type Config struct {
+	DB         DBConfig         `yaml:"db" json:"db"`
+	SMTP       SMTPConfig       `yaml:"smtp" json:"smtp"`
+	Secrets    SecretsConfig    `yaml:"secrets" json:"secrets"`
+	Accounts   AccountsConfig   `yaml:"accounts" json:"accounts"`
+	Accrual    AccrualConfig    `yaml:"accrual" json:"accrual"`
+	Categories CategoriesConfig `yaml:"categories" json:"categories"`
+	Periods    PeriodsConfig    `yaml:"periods" json:"periods"`
+}
+
+func defaultConfig() Config {
+	return Config{
+		DB: DBConfig{
+			Driver:          DEFAULT_DB_DRIVER,
+			MaxOpenConns:    DEFAULT_DB_MAX_OPEN_CONNS,
+			MaxIdleConns:    DEFAULT_DB_MAX_IDLE_CONNS,
+			ConnMaxLifetime: DEFAULT_DB_CONN_MAX_LIFETIME,
+			ConnMaxIdleTime: DEFAULT_DB_CONN_MAX_IDLE_TIME,
+			ConnectRetries:  DEFAULT_DB_CONNECT_RETRIES,
+			RetryBackoff:    DEFAULT_DB_RETRY_BACKOFF,
+		},
+		SMTP:       SMTPConfig{Port: DEFAULT_SMTP_PORT},
+		Accounts:   defaultAccountsConfig(),
+		Accrual:    defaultAccrualConfig(),
+		Categories: defaultCategoriesConfig(),
+		Periods:    defaultPeriodsConfig(),
+		Secrets: SecretsConfig{
+			Provider: SECRETS_PROVIDER_ENV,
+		},
+	}
+}
+
+// Load builds the configuration and validates it, so that a misconfigured
+// lambda fails at startup instead of on its first query or email.
+func Load() (Config, error) {
+	cfg, err := load()
+	if err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// LoadDB is Load for tools that only talk to the database, such as
+// maintenance commands, and therefore do not need SMTP settings.
+func LoadDB() (Config, error) {
+	cfg, err := load()
+	if err != nil {
+		return Config{}, err
+	}
+	problems := append(cfg.DB.validate(), cfg.Accounts.validate()...)
+	problems = append(problems, cfg.Accrual.validate()...)
+	problems = append(problems, cfg.Categories.validate()...)
+	problems = append(problems, cfg.Periods.validate()...)
+	if err := problemsToError(problems); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+func load() (Config, error) {
+	cfg := defaultConfig()
+
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := loadFile(path, &cfg); err != nil {
+			return Config{}, err
+		}
+	}
+
+	if err := applyEnv(&cfg); err != nil {
+		return Config{}, err
+	}
+
+	if cfg.DB.Port == "" {
+		switch cfg.DB.Driver {
+		case storage.DRIVER_POSTGRES, "postgresql":
+			cfg.DB.Port = DEFAULT_POSTGRES_PORT
+		case storage.DRIVER_MYSQL, "mariadb":
+			cfg.DB.Port = DEFAULT_MYSQL_PORT
+		}
+	}
+
+	provider, err := NewSecretProvider(cfg.Secrets)
+	if err != nil {
+		return Config{}, err
+	}
+	if err := resolveSecrets(provider, &cfg); err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error while reading config file %s: %v", path, err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, cfg)
+	case ".json":
+		err = json.Unmarshal(data, cfg)
+	default:
+		return fmt.Errorf("config file %s must have a .yaml, .yml or .json extension", path)
+	}
+	if err != nil {
+		return fmt.Errorf("error while parsing config file %s: %v", path, err)
+	}
+
+	return nil
+}
+
+func applyEnv(cfg *Config) error {
+	setFromEnv(&cfg.DB.Driver, "DB_DRIVER")
+	setFromEnv(&cfg.DB.Path, "DB_PATH")
+	setFromEnv(&cfg.DB.User, "DB_USER")
+	setFromEnv(&cfg.DB.Password, "DB_PASSWORD")
+	setFromEnv(&cfg.DB.Host, "DB_HOST")
+	setFromEnv(&cfg.DB.Port, "DB_PORT")
+	setFromEnv(&cfg.DB.Name, "DB_NAME")
+	setFromEnv(&cfg.DB.TLS, "DB_TLS")
+	setFromEnv(&cfg.DB.TLSCAFile, "DB_TLS_CA_FILE")
+
+	setFromEnv(&cfg.SMTP.Host, "SMTP_HOST")
+	setFromEnv(&cfg.SMTP.Port, "SMTP_PORT")
+	setFromEnv(&cfg.SMTP.Username, "SMTP_USERNAME")
+	setFromEnv(&cfg.SMTP.Password, "SMTP_PASSWORD")
+
+	setFromEnv(&cfg.Accounts.BankCode, "ACCOUNT_BANK_CODE")
+	setFromEnv(&cfg.Accounts.BranchCode, "ACCOUNT_BRANCH_CODE")
+
+	setFromEnv(&cfg.Periods.Backdating, "PERIOD_BACKDATING")
+
+	setFromEnv(&cfg.Secrets.Provider, "SECRETS_PROVIDER")
+	setFromEnv(&cfg.Secrets.Dir, "SECRETS_DIR")
+	setFromEnv(&cfg.Secrets.AWSSecretID, "SECRETS_AWS_SECRET_ID")
+	setFromEnv(&cfg.Secrets.AWSRegion, "SECRETS_AWS_REGION")
+	setFromEnv(&cfg.Secrets.AWSEndpoint, "SECRETS_AWS_ENDPOINT")
+
+	var problems []string
+	for key, field := range map[string]*int{
+		"DB_MAX_OPEN_CONNS":   &cfg.DB.MaxOpenConns,
+		"DB_MAX_IDLE_CONNS":   &cfg.DB.MaxIdleConns,
+		"DB_CONNECT_RETRIES":  &cfg.DB.ConnectRetries,
+		"ACCRUAL_MONTHLY_FEE": &cfg.Accrual.MonthlyFee,
+		"ACCRUAL_LATE_FEE":    &cfg.Accrual.LateFee,
+	} {
+		if err := setIntFromEnv(field, key); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	for key, field := range map[string]*Duration{
+		"DB_CONN_MAX_LIFETIME":  &cfg.DB.ConnMaxLifetime,
+		"DB_CONN_MAX_IDLE_TIME": &cfg.DB.ConnMaxIdleTime,
+		"DB_RETRY_BACKOFF":      &cfg.DB.RetryBackoff,
+	} {
+		if err := setDurationFromEnv(field, key); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	for key, field := range map[string]*float64{
+		"ACCRUAL_APR":      &cfg.Accrual.APR,
+		"ACCRUAL_IVA_RATE": &cfg.Accrual.IVARate,
+	} {
+		if err := setFloatFromEnv(field, key); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("invalid configuration: %s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// setFromEnv only overrides the current value when the variable is set, so
+// values coming from the config file survive an environment that lacks them.
+func setFromEnv(field *string, key string) {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		*field = value
+	}
+}
+
+func setIntFromEnv(field *int, key string) error {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("%s must be an integer, instead given: %s", key, value)
+	}
+	*field = parsed
+	return nil
+}
+
+func setFloatFromEnv(field *float64, key string) error {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return nil
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("%s must be a number, instead given: %s", key, value)
+	}
+	*field = parsed
+	return nil
+}
+
+func setDurationFromEnv(field *Duration, key string) error {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return nil
+	}
+	if err := field.UnmarshalText([]byte(value)); err != nil {
+		return fmt.Errorf("%s must be a duration such as 30s or 5m, instead given: %s", key, value)
+	}
+	return nil
+}
+
+func resolveSecrets(provider SecretProvider, cfg *Config) error {
+	secrets := map[string]*string{
+		"DB_PASSWORD":   &cfg.DB.Password,
+		"SMTP_PASSWORD": &cfg.SMTP.Password,
+	}
+	for key, field := range secrets {
+		value, err := provider.GetSecret(key)
+		if err != nil {
+			return fmt.Errorf("error while resolving secret %s: %v", key, err)
+		}
+		if value != "" {
+			*field = value
+		}
+	}
+	return nil
+}
+
+// Validate reports every missing or malformed setting at once.
+func (cfg Config) Validate() error {
+	problems := cfg.DB.validate()
+	problems = append(problems, cfg.SMTP.validate()...)
+	problems = append(problems, cfg.Accounts.validate()...)
+	problems = append(problems, cfg.Accrual.validate()...)
+	problems = append(problems, cfg.Categories.validate()...)
+	problems = append(problems, cfg.Periods.validate()...)
+	return problemsToError(problems)
+}
+
+// Validate reports every missing or malformed database setting at once.
+func (cfg DBConfig) Validate() error {
+	return problemsToError(cfg.validate())
+}
+
+func problemsToError(problems []string) error {
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+type setting struct {
+	value string
+	key   string
+}
+
+func checkRequired(required []setting) []string {
+	var problems []string
+	for _, field := range required {
+		if field.value == "" {
+			problems = append(problems, fmt.Sprintf("%s must be provided", field.key))
+		}
+	}
+	return problems
+}
+
+func checkPort(field setting) []string {
+	if field.value == "" {
+		return nil
+	}
+	if port, err := strconv.Atoi(field.value); err != nil || port < 1 || port > 65535 {
+		return []string{fmt.Sprintf("%s must be a port number between 1 and 65535, instead given: %s", field.key, field.value)}
+	}
+	return nil
+}
+
+func (cfg DBConfig) validate() []string {
+	var problems []string
+
+	dialect, ok := storage.NewDialect(cfg.Driver)
+	switch {
+	case !ok:
+		problems = append(problems, fmt.Sprintf("DB_DRIVER must be one of mysql, mariadb, postgres or sqlite, instead given: %s", cfg.Driver))
+	case dialect.Name() == storage.DRIVER_SQLITE:
+		problems = append(problems, checkRequired([]setting{{cfg.Path, "DB_PATH"}})...)
+	default:
+		problems = append(problems, checkRequired([]setting{
+			{cfg.User, "DB_USER"},
+			{cfg.Host, "DB_HOST"},
+			{cfg.Port, "DB_PORT"},
+			{cfg.Name, "DB_NAME"},
+		})...)
+		problems = append(problems, checkPort(setting{cfg.Port, "DB_PORT"})...)
+	}
+
+	nonNegative := []struct {
+		value int64
+		key   string
+	}{
+		{int64(cfg.MaxOpenConns), "DB_MAX_OPEN_CONNS"},
+		{int64(cfg.MaxIdleConns), "DB_MAX_IDLE_CONNS"},
+		{int64(cfg.ConnectRetries), "DB_CONNECT_RETRIES"},
+		{int64(cfg.ConnMaxLifetime), "DB_CONN_MAX_LIFETIME"},
+		{int64(cfg.ConnMaxIdleTime), "DB_CONN_MAX_IDLE_TIME"},
+		{int64(cfg.RetryBackoff), "DB_RETRY_BACKOFF"},
+	}
+	for _, field := range nonNegative {
+		if field.value < 0 {
+			problems = append(problems, fmt.Sprintf("%s must not be negative", field.key))
+		}
+	}
+
+	switch cfg.TLS {
+	case "", "true", "false", "skip-verify", "preferred":
+	default:
+		problems = append(problems, fmt.Sprintf("DB_TLS must be one of true, false, skip-verify or preferred, instead given: %s", cfg.TLS))
+	}
+	// A CA only makes sense when the server is verified; with any other mode
+	// it would be silently ignored, or verification silently turned on.
+	// PostgreSQL cannot verify the server and fall back to plain text, which
+	// is what "preferred" does with a CA on MySQL.
+	if cfg.TLSCAFile != "" && ok && dialect.Name() == storage.DRIVER_POSTGRES && cfg.TLS != "true" {
+		problems = append(problems, fmt.Sprintf("DB_TLS_CA_FILE requires DB_TLS to be true on PostgreSQL, instead given: %q", cfg.TLS))
+	} else if cfg.TLSCAFile != "" && cfg.TLS != "true" && cfg.TLS != "preferred" {
+		problems = append(problems, fmt.Sprintf("DB_TLS_CA_FILE requires DB_TLS to be true or preferred, instead given: %q", cfg.TLS))
+	}
+
+	return problems
+}
+
+func (cfg SMTPConfig) validate() []string {
+	problems := checkRequired([]setting{
+		{cfg.Host, "SMTP_HOST"},
+		{cfg.Port, "SMTP_PORT"},
+		{cfg.Username, "SMTP_USERNAME"},
+		{cfg.Password, "SMTP_PASSWORD"},
+	})
+	return append(problems, checkPort(setting{cfg.Port, "SMTP_PORT"})...)
+}
@@ -0,0 +1,200 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// envKeys are every variable Load reads, cleared by clearEnv so that the
+// environment running the tests does not leak into them.
+var envKeys = []string{
+	"CONFIG_FILE",
+	"DB_DRIVER", "DB_PATH", "DB_USER", "DB_PASSWORD", "DB_HOST", "DB_PORT", "DB_NAME", "DB_TLS", "DB_TLS_CA_FILE",
+	"DB_MAX_OPEN_CONNS", "DB_MAX_IDLE_CONNS", "DB_CONNECT_RETRIES", "DB_CONN_MAX_LIFETIME", "DB_CONN_MAX_IDLE_TIME", "DB_RETRY_BACKOFF",
+	"SMTP_HOST", "SMTP_PORT", "SMTP_USERNAME", "SMTP_PASSWORD",
+	"ACCOUNT_BANK_CODE", "ACCOUNT_BRANCH_CODE", "PERIOD_BACKDATING",
+	"ACCRUAL_MONTHLY_FEE", "ACCRUAL_LATE_FEE", "ACCRUAL_APR", "ACCRUAL_IVA_RATE",
+	"SECRETS_PROVIDER", "SECRETS_DIR", "SECRETS_AWS_SECRET_ID", "SECRETS_AWS_REGION", "SECRETS_AWS_ENDPOINT",
+}
+
+func clearEnv(tb testing.TB) {
+	tb.Helper()
+	for _, key := range envKeys {
+		tb.Setenv(key, "")
+	}
+}
+
+func writeFile(tb testing.TB, dir string, name string, content string) string {
+	tb.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		tb.Fatal(err)
+	}
+	return path
+}
+
+func checkProblems(tb testing.TB, err error, want ...string) {
+	tb.Helper()
+	if len(want) == 0 {
+		if err != nil {
+			tb.Errorf("error = %v, want none", err)
+		}
+		return
+	}
+	if err == nil {
+		tb.Errorf("no error, want one mentioning %q", want)
+		return
+	}
+	for _, problem := range want {
+		if !strings.Contains(err.Error(), problem) {
+			tb.Errorf("error = %v, want it to mention %q", err, problem)
+		}
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	clearEnv(t)
+	dir := t.TempDir()
+	secrets := t.TempDir()
+	writeFile(t, secrets, "DB_PASSWORD", "secret-password\n")
+	t.Setenv("CONFIG_FILE", writeFile(t, dir, "config.yaml", `
+db:
+  host: file-host
+  user: file-user
+  password: file-password
+  name: stori
+  max_open_conns: 9
+  conn_max_lifetime: 10m
+smtp:
+  host: smtp.example.com
+  username: mailer
+  password: file-smtp-password
+accounts:
+  bank_code: "123"
+secrets:
+  provider: file
+  dir: `+secrets+`
+`))
+	t.Setenv("DB_HOST", "env-host")
+	t.Setenv("DB_PASSWORD", "env-password")
+	t.Setenv("DB_MAX_IDLE_CONNS", "4")
+	t.Setenv("ACCOUNT_BANK_CODE", "456")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"default driver", cfg.DB.Driver, DEFAULT_DB_DRIVER},
+		{"default port of the driver", cfg.DB.Port, DEFAULT_MYSQL_PORT},
+		{"default retries", cfg.DB.ConnectRetries, DEFAULT_DB_CONNECT_RETRIES},
+		{"file over default", cfg.DB.MaxOpenConns, 9},
+		{"file duration", cfg.DB.ConnMaxLifetime, Duration(10 * time.Minute)},
+		{"file user", cfg.DB.User, "file-user"},
+		{"env over file", cfg.DB.Host, "env-host"},
+		{"env over default", cfg.DB.MaxIdleConns, 4},
+		{"env over file code", cfg.Accounts.BankCode, "456"},
+		{"secret over env", cfg.DB.Password, "secret-password"},
+		{"file without secret", cfg.SMTP.Password, "file-smtp-password"},
+		{"default smtp port", cfg.SMTP.Port, DEFAULT_SMTP_PORT},
+	}
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, test.got, test.want)
+		}
+	}
+}
+
+func TestLoadJSONAndPostgresPort(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("CONFIG_FILE", writeFile(t, t.TempDir(), "config.json",
+		`{"db": {"driver": "postgres", "host": "db", "user": "stori", "name": "stori"}}`))
+
+	cfg, err := LoadDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DB.Port != DEFAULT_POSTGRES_PORT {
+		t.Errorf("port = %s, want %s", cfg.DB.Port, DEFAULT_POSTGRES_PORT)
+	}
+	// Load also needs the SMTP settings.
+	_, err = Load()
+	checkProblems(t, err, "SMTP_HOST must be provided", "SMTP_PASSWORD must be provided")
+}
+
+func TestLoadErrors(t *testing.T) {
+	toml := writeFile(t, t.TempDir(), "config.toml", "")
+	tests := []struct {
+		name string
+		env  map[string]string
+		want []string
+	}{
+		{"missing file", map[string]string{"CONFIG_FILE": "missing.yaml"}, []string{"error while reading config file missing.yaml"}},
+		{"unknown file type", map[string]string{"CONFIG_FILE": toml}, []string{"must have a .yaml, .yml or .json extension"}},
+		{"bad numbers", map[string]string{"DB_MAX_OPEN_CONNS": "many", "DB_RETRY_BACKOFF": "soon", "ACCRUAL_APR": "high"},
+			[]string{"DB_MAX_OPEN_CONNS must be an integer", "DB_RETRY_BACKOFF must be a duration", "ACCRUAL_APR must be a number"}},
+		{"unknown provider", map[string]string{"SECRETS_PROVIDER": "vault"}, []string{`unknown secrets provider "vault"`}},
+		{"file provider without dir", map[string]string{"SECRETS_PROVIDER": "file"}, []string{"SECRETS_DIR must be provided"}},
+		{"aws provider without secret", map[string]string{"SECRETS_PROVIDER": "aws"}, []string{"SECRETS_AWS_SECRET_ID must be provided"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clearEnv(t)
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+			_, err := LoadDB()
+			checkProblems(t, err, test.want...)
+		})
+	}
+}
+
+func validConfig() Config {
+	cfg := defaultConfig()
+	cfg.DB.Host = "db"
+	cfg.DB.Port = DEFAULT_MYSQL_PORT
+	cfg.DB.User = "stori"
+	cfg.DB.Name = "stori"
+	cfg.SMTP = SMTPConfig{Host: "smtp.example.com", Port: DEFAULT_SMTP_PORT, Username: "mailer", Password: "secret"}
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   []string
+	}{
+		{"valid", func(*Config) {}, nil},
+		{"sqlite needs a path only", func(cfg *Config) { cfg.DB = DBConfig{Driver: "sqlite"} }, []string{"DB_PATH must be provided"}},
+		{"sqlite", func(cfg *Config) { cfg.DB = DBConfig{Driver: "sqlite3", Path: "stori.db"} }, nil},
+		{"unknown driver", func(cfg *Config) { cfg.DB.Driver = "oracle" }, []string{"DB_DRIVER must be one of"}},
+		{"missing settings", func(cfg *Config) { cfg.DB.Host, cfg.SMTP.Username = "", "" },
+			[]string{"DB_HOST must be provided", "SMTP_USERNAME must be provided"}},
+		{"ports", func(cfg *Config) { cfg.DB.Port, cfg.SMTP.Port = "0", "smtp" },
+			[]string{"DB_PORT must be a port number", "SMTP_PORT must be a port number"}},
+		{"negative pool", func(cfg *Config) { cfg.DB.MaxOpenConns, cfg.DB.RetryBackoff = -1, -1 },
+			[]string{"DB_MAX_OPEN_CONNS must not be negative", "DB_RETRY_BACKOFF must not be negative"}},
+		{"tls mode", func(cfg *Config) { cfg.DB.TLS = "yes" }, []string{"DB_TLS must be one of"}},
+		{"ca without verification", func(cfg *Config) { cfg.DB.TLS, cfg.DB.TLSCAFile = "skip-verify", "ca.pem" },
+			[]string{"DB_TLS_CA_FILE requires DB_TLS"}},
+		{"postgres ca without verification", func(cfg *Config) {
+			cfg.DB.Driver, cfg.DB.Port, cfg.DB.TLS, cfg.DB.TLSCAFile = "postgres", DEFAULT_POSTGRES_PORT, "preferred", "ca.pem"
+		}, []string{"DB_TLS_CA_FILE requires DB_TLS to be true on PostgreSQL"}},
+		{"bank code", func(cfg *Config) { cfg.Accounts.BankCode = "12" }, []string{"ACCOUNT_BANK_CODE must be 3 digits"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := validConfig()
+			test.modify(&cfg)
+			checkProblems(t, cfg.Validate(), test.want...)
+		})
+	}
+}
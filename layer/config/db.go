@@ -1,33 +1,263 @@
 package config
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
 	"log"
+	"net"
+	"net/url"
 	"os"
+	"sync"
+	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"storichallenge_layer/storage"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
+
+const (
+	DEFAULT_DB_DRIVER             = storage.DRIVER_MYSQL
+	DEFAULT_DB_MAX_OPEN_CONNS     = 5
+	DEFAULT_DB_MAX_IDLE_CONNS     = 2
+	DEFAULT_DB_CONN_MAX_LIFETIME  = Duration(5 * time.Minute)
+	DEFAULT_DB_CONN_MAX_IDLE_TIME = Duration(time.Minute)
+	DEFAULT_DB_CONNECT_RETRIES    = 3
+	DEFAULT_DB_RETRY_BACKOFF      = Duration(200 * time.Millisecond)
 )
 
-func ConnectToDB() (*sql.DB, error) {
-	db_user := os.Getenv("DB_USER")
-	db_password := os.Getenv("DB_PASSWORD")
-	db_host := os.Getenv("DB_HOST")
-	db_port := os.Getenv("DB_PORT")
-	db_name := os.Getenv("DB_NAME")
-	dsn := db_user + ":" + db_password + "@tcp(" + db_host + ":" + db_port + ")/" + db_name
-	db, err := sql.Open("mysql", dsn)
+type DBConfig struct {
+	// Driver selects the storage backend: mysql (also used for MariaDB),
+	// postgres or sqlite. SQLite only needs Path, the database file.
+	Driver   string `yaml:"driver" json:"driver"`
+	Path     string `yaml:"path" json:"path"`
+	User     string `yaml:"user" json:"user"`
+	Password string `yaml:"password" json:"password"`
+	Host     string `yaml:"host" json:"host"`
+	Port     string `yaml:"port" json:"port"`
+	Name     string `yaml:"name" json:"name"`
+
+	MaxOpenConns    int      `yaml:"max_open_conns" json:"max_open_conns"`
+	MaxIdleConns    int      `yaml:"max_idle_conns" json:"max_idle_conns"`
+	ConnMaxLifetime Duration `yaml:"conn_max_lifetime" json:"conn_max_lifetime"`
+	ConnMaxIdleTime Duration `yaml:"conn_max_idle_time" json:"conn_max_idle_time"`
+
+	// TLS is one of the go-sql-driver modes: "true", "false", "skip-verify"
+	// or "preferred", mapped to the equivalent sslmode on PostgreSQL. Setting
+	// TLSCAFile verifies the server against that CA; it requires "true", or
+	// "preferred" on MySQL only, since PostgreSQL cannot both verify the
+	// server and fall back to plain text.
+	TLS       string `yaml:"tls" json:"tls"`
+	TLSCAFile string `yaml:"tls_ca_file" json:"tls_ca_file"`
+
+	// ConnectRetries and RetryBackoff retry the first ping and then every
+	// statement and transaction that fails with a transient error, see
+	// IsTransientDBError.
+	ConnectRetries int      `yaml:"connect_retries" json:"connect_retries"`
+	RetryBackoff   Duration `yaml:"retry_backoff" json:"retry_backoff"`
+}
+
+var (
+	sharedDBMu sync.Mutex
+	sharedDB   *storage.DB
+)
+
+// GetDB returns the process-wide connection pool, opening it on first use.
+// Lambda keeps the process alive between warm invocations, so every request
+// after the cold start reuses the same pool instead of dialing again. A failed
+// attempt is not cached and the next call tries again.
+func GetDB(cfg DBConfig) (*storage.DB, error) {
+	sharedDBMu.Lock()
+	defer sharedDBMu.Unlock()
+
+	if sharedDB != nil {
+		return sharedDB, nil
+	}
+
+	db, err := ConnectToDB(cfg)
 	if err != nil {
-		log.Fatal(err)
 		return nil, err
 	}
+	sharedDB = db
+	return sharedDB, nil
+}
+
+// ConnectToDB opens a new pool tuned with cfg and pings it, retrying with
+// exponential backoff while the failure looks transient. The pool keeps
+// retrying the statements and transactions that fail that way. SQLite
+// databases get their schema created on first use.
+func ConnectToDB(cfg DBConfig) (*storage.DB, error) {
+	dialect, ok := storage.NewDialect(cfg.Driver)
+	if !ok {
+		return nil, fmt.Errorf("unknown database driver %q", cfg.Driver)
+	}
 
-	err = db.Ping()
+	sqlDB, err := cfg.open(dialect)
 	if err != nil {
-		log.Fatal(err)
 		return nil, err
 	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetime))
+	sqlDB.SetConnMaxIdleTime(time.Duration(cfg.ConnMaxIdleTime))
 
-	fmt.Println("Successfully connected to the database!")
+	retry := cfg.retryPolicy()
+	if err := retry.Do(sqlDB.Ping); err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("error while connecting to the database: %v", err)
+	}
+
+	db := storage.NewDB(sqlDB, dialect)
+	db.Retry = retry
+	if err := storage.EnsureSchema(db); err != nil {
+		sqlDB.Close()
+		return nil, err
+	}
+
+	log.Printf("Successfully connected to the %s database!", dialect.Name())
 	return db, nil
 }
+
+func (cfg DBConfig) retryPolicy() storage.RetryPolicy {
+	return storage.RetryPolicy{
+		Retries:   cfg.ConnectRetries,
+		Backoff:   time.Duration(cfg.RetryBackoff),
+		Transient: IsTransientDBError,
+	}
+}
+
+func (cfg DBConfig) open(dialect storage.Dialect) (*sql.DB, error) {
+	switch dialect.Name() {
+	case storage.DRIVER_POSTGRES:
+		dsn, err := cfg.postgresDSN()
+		if err != nil {
+			return nil, err
+		}
+		return sql.Open(dialect.DriverName(), dsn)
+	case storage.DRIVER_SQLITE:
+		return sql.Open(dialect.DriverName(), cfg.sqliteDSN())
+	default:
+		mysqlCfg, err := cfg.mysqlConfig()
+		if err != nil {
+			return nil, err
+		}
+		connector, err := mysql.NewConnector(mysqlCfg)
+		if err != nil {
+			return nil, fmt.Errorf("error while configuring database connection: %v", err)
+		}
+		return sql.OpenDB(connector), nil
+	}
+}
+
+// IsTransientDBError reports whether err is worth retrying: network failures,
+// dropped connections and the server refusing connections because it is busy.
+func IsTransientDBError(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) {
+		return true
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Class() {
+		case "08", // connection exception
+			"53", // insufficient resources, e.g. too many connections
+			"57": // operator intervention, e.g. server shutting down
+			return true
+		}
+		return pqErr.Code == "40001" || pqErr.Code == "40P01" // serialization failure, deadlock
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case 1040, // ER_CON_COUNT_ERROR: too many connections
+			1203, // ER_TOO_MANY_USER_CONNECTIONS
+			1205, // ER_LOCK_WAIT_TIMEOUT
+			1213: // ER_LOCK_DEADLOCK
+			return true
+		}
+	}
+
+	return false
+}
+
+func (cfg DBConfig) mysqlConfig() (*mysql.Config, error) {
+	mysqlCfg := mysql.NewConfig()
+	mysqlCfg.User = cfg.User
+	mysqlCfg.Passwd = cfg.Password
+	mysqlCfg.Net = "tcp"
+	mysqlCfg.Addr = net.JoinHostPort(cfg.Host, cfg.Port)
+	mysqlCfg.DBName = cfg.Name
+	mysqlCfg.ParseTime = true
+	// Report matched rather than changed rows, so an UPDATE adding zero still
+	// counts as finding the row.
+	mysqlCfg.ClientFoundRows = true
+	mysqlCfg.TLSConfig = cfg.TLS
+
+	if cfg.TLSCAFile != "" {
+		pem, err := os.ReadFile(cfg.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("error while reading DB TLS CA file %s: %v", cfg.TLSCAFile, err)
+		}
+		rootCAs := x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("DB TLS CA file %s does not contain any PEM certificate", cfg.TLSCAFile)
+		}
+		mysqlCfg.TLS = &tls.Config{RootCAs: rootCAs, ServerName: cfg.Host}
+		// A custom tls.Config takes over TLSConfig, so "preferred" has to
+		// keep its fallback to plain text by hand.
+		mysqlCfg.AllowFallbackToPlaintext = cfg.TLS == "preferred"
+	}
+
+	return mysqlCfg, nil
+}
+
+func (cfg DBConfig) postgresDSN() (string, error) {
+	dsn := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(cfg.User, cfg.Password),
+		Host:   net.JoinHostPort(cfg.Host, cfg.Port),
+		Path:   "/" + cfg.Name,
+	}
+
+	params := url.Values{}
+	switch cfg.TLS {
+	case "", "false":
+		params.Set("sslmode", "disable")
+	case "true":
+		params.Set("sslmode", "verify-full")
+	case "skip-verify":
+		params.Set("sslmode", "require")
+	case "preferred":
+		params.Set("sslmode", "prefer")
+	}
+	if cfg.TLSCAFile != "" {
+		// With sslmode=prefer the CA would be silently ignored.
+		if cfg.TLS != "true" {
+			return "", fmt.Errorf("DB_TLS_CA_FILE requires DB_TLS to be true on PostgreSQL, instead given: %q", cfg.TLS)
+		}
+		if _, err := os.Stat(cfg.TLSCAFile); err != nil {
+			return "", fmt.Errorf("error while reading DB TLS CA file %s: %v", cfg.TLSCAFile, err)
+		}
+		params.Set("sslrootcert", cfg.TLSCAFile)
+	}
+	dsn.RawQuery = params.Encode()
+
+	return dsn.String(), nil
+}
+
+// sqliteDSN enables foreign keys, which SQLite leaves off by default, and a
+// busy timeout so concurrent writers wait instead of failing.
+func (cfg DBConfig) sqliteDSN() string {
+	return "file:" + cfg.Path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+}
@@ -0,0 +1,233 @@
+package config
+
+import (
+	"database/sql/driver"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+func TestMySQLConfig(t *testing.T) {
+	cfg := DBConfig{User: "stori", Password: "p@ss:word", Host: "db.example.com", Port: "3306", Name: "stori", TLS: "true"}
+	mysqlCfg, err := cfg.mysqlConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mysqlCfg.Addr != "db.example.com:3306" || mysqlCfg.Net != "tcp" || mysqlCfg.DBName != "stori" {
+		t.Errorf("connects to %s %s/%s, want tcp db.example.com:3306/stori", mysqlCfg.Net, mysqlCfg.Addr, mysqlCfg.DBName)
+	}
+	if mysqlCfg.User != "stori" || mysqlCfg.Passwd != "p@ss:word" {
+		t.Errorf("credentials %s:%s, want stori:p@ss:word", mysqlCfg.User, mysqlCfg.Passwd)
+	}
+	if !mysqlCfg.ParseTime || !mysqlCfg.ClientFoundRows || mysqlCfg.TLSConfig != "true" {
+		t.Errorf("ParseTime %v, ClientFoundRows %v and TLSConfig %q, want true, true and true",
+			mysqlCfg.ParseTime, mysqlCfg.ClientFoundRows, mysqlCfg.TLSConfig)
+	}
+}
+
+func TestPostgresDSN(t *testing.T) {
+	cfg := DBConfig{User: "stori", Password: "p@ss/word", Host: "db.example.com", Port: "5432", Name: "stori"}
+	dsn, err := cfg.postgresDSN()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	password, _ := parsed.User.Password()
+	if parsed.User.Username() != "stori" || password != "p@ss/word" {
+		t.Errorf("credentials %s:%s, want stori:p@ss/word", parsed.User.Username(), password)
+	}
+	if parsed.Host != "db.example.com:5432" || parsed.Path != "/stori" {
+		t.Errorf("connects to %s%s, want db.example.com:5432/stori", parsed.Host, parsed.Path)
+	}
+	if sslmode := parsed.Query().Get("sslmode"); sslmode != "disable" {
+		t.Errorf("sslmode = %s, want disable", sslmode)
+	}
+}
+
+func TestSQLiteDSN(t *testing.T) {
+	dsn := DBConfig{Path: "/tmp/stori.db"}.sqliteDSN()
+	want := "file:/tmp/stori.db?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	if dsn != want {
+		t.Errorf("sqliteDSN = %s, want %s", dsn, want)
+	}
+}
+
+// writeCAFile writes the certificate of a throwaway TLS server as a PEM file.
+func writeCAFile(tb testing.TB) string {
+	tb.Helper()
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	server.Close()
+	block := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	return writeFile(tb, tb.TempDir(), "ca.pem", string(block))
+}
+
+func TestMySQLConfigCA(t *testing.T) {
+	for _, mode := range []string{"true", "preferred"} {
+		cfg := DBConfig{Host: "db.example.com", Port: "3306", TLS: mode, TLSCAFile: writeCAFile(t)}
+		mysqlCfg, err := cfg.mysqlConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mysqlCfg.TLS == nil || mysqlCfg.TLS.RootCAs == nil || mysqlCfg.TLS.ServerName != "db.example.com" {
+			t.Errorf("%s: TLS config %+v, want the CA and the server name", mode, mysqlCfg.TLS)
+		}
+		if mysqlCfg.AllowFallbackToPlaintext != (mode == "preferred") {
+			t.Errorf("%s: AllowFallbackToPlaintext = %v", mode, mysqlCfg.AllowFallbackToPlaintext)
+		}
+	}
+
+	for name, path := range map[string]string{
+		"missing": filepath.Join(t.TempDir(), "missing.pem"),
+		"not pem": writeFile(t, t.TempDir(), "ca.pem", "not a certificate"),
+	} {
+		if _, err := (DBConfig{TLS: "true", TLSCAFile: path}).mysqlConfig(); err == nil {
+			t.Errorf("%s CA file accepted", name)
+		}
+	}
+}
+
+func TestPostgresDSNTLS(t *testing.T) {
+	caFile := writeCAFile(t)
+	tests := []struct {
+		tls, caFile string
+		sslmode     string
+		rootcert    string
+		err         string
+	}{
+		{tls: "", sslmode: "disable"},
+		{tls: "false", sslmode: "disable"},
+		{tls: "true", sslmode: "verify-full"},
+		{tls: "skip-verify", sslmode: "require"},
+		{tls: "preferred", sslmode: "prefer"},
+		{tls: "true", caFile: caFile, sslmode: "verify-full", rootcert: caFile},
+		{tls: "preferred", caFile: caFile, err: "requires DB_TLS to be true"},
+		{tls: "skip-verify", caFile: caFile, err: "requires DB_TLS to be true"},
+		{tls: "true", caFile: caFile + ".missing", err: "error while reading DB TLS CA file"},
+	}
+	for _, test := range tests {
+		dsn, err := DBConfig{Host: "db", Port: "5432", TLS: test.tls, TLSCAFile: test.caFile}.postgresDSN()
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("TLS %q with CA %q: error %v, want %q", test.tls, test.caFile, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("TLS %q with CA %q: %v", test.tls, test.caFile, err)
+			continue
+		}
+		parsed, err := url.Parse(dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		query := parsed.Query()
+		if query.Get("sslmode") != test.sslmode || query.Get("sslrootcert") != test.rootcert {
+			t.Errorf("TLS %q with CA %q: sslmode %q and sslrootcert %q, want %q and %q", test.tls, test.caFile,
+				query.Get("sslmode"), query.Get("sslrootcert"), test.sslmode, test.rootcert)
+		}
+	}
+}
+
+func TestConnectToDB(t *testing.T) {
+	cfg := DBConfig{Driver: "sqlite", Path: filepath.Join(t.TempDir(), "stori.db"),
+		MaxOpenConns: 3, ConnectRetries: 2, RetryBackoff: Duration(time.Millisecond)}
+	db, err := ConnectToDB(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if max := db.Stats().MaxOpenConnections; max != 3 {
+		t.Errorf("MaxOpenConnections = %d, want 3", max)
+	}
+	if db.Retry.Retries != 2 || db.Retry.Backoff != time.Millisecond || db.Retry.Transient == nil {
+		t.Errorf("retry policy %+v, want 2 retries from 1ms on transient errors", db.Retry)
+	}
+	if _, err := db.Exec("SELECT 1 FROM account"); err != nil {
+		t.Errorf("schema not created: %v", err)
+	}
+
+	if _, err := ConnectToDB(DBConfig{Driver: "oracle"}); err == nil {
+		t.Error("unknown driver accepted")
+	}
+}
+
+func TestConnectToDBRetriesPing(t *testing.T) {
+	// A port nobody listens on refuses every connection.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	listener.Close()
+
+	cfg := DBConfig{Driver: "mysql", Host: host, Port: port, User: "stori", Name: "stori",
+		ConnectRetries: 2, RetryBackoff: Duration(20 * time.Millisecond)}
+	start := time.Now()
+	if _, err := ConnectToDB(cfg); err == nil || !strings.Contains(err.Error(), "error while connecting to the database") {
+		t.Fatalf("error = %v, want a connection error", err)
+	}
+	// Two retries wait 20ms and then 40ms.
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("gave up after %s, want at least 60ms of backoff", elapsed)
+	}
+}
+
+func TestGetDB(t *testing.T) {
+	defer func() { sharedDB = nil }()
+
+	if _, err := GetDB(DBConfig{Driver: "oracle"}); err == nil {
+		t.Fatal("unknown driver accepted")
+	}
+	if sharedDB != nil {
+		t.Fatal("failed connection cached")
+	}
+
+	cfg := DBConfig{Driver: "sqlite", Path: filepath.Join(t.TempDir(), "stori.db")}
+	first, err := GetDB(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	second, err := GetDB(DBConfig{Driver: "oracle"})
+	if err != nil || second != first {
+		t.Errorf("second call returned %p, %v, want the pool %p", second, err, first)
+	}
+}
+
+func TestIsTransientDBError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{driver.ErrBadConn, true},
+		{fmt.Errorf("error while pinging: %w", mysql.ErrInvalidConn), true},
+		{&net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{&pq.Error{Code: "08006"}, true},  // connection failure
+		{&pq.Error{Code: "53300"}, true},  // too many connections
+		{&pq.Error{Code: "40P01"}, true},  // deadlock
+		{&pq.Error{Code: "23505"}, false}, // unique violation
+		{&mysql.MySQLError{Number: 1213}, true},
+		{&mysql.MySQLError{Number: 1062}, false}, // duplicate entry
+		{errors.New("syntax error"), false},
+	}
+	for _, test := range tests {
+		if got := IsTransientDBError(test.err); got != test.want {
+			t.Errorf("IsTransientDBError(%v) = %v, want %v", test.err, got, test.want)
+		}
+	}
+}
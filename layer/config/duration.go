@@ -0,0 +1,20 @@
+package config
+
+import "time"
+
+// Duration is a time.Duration that can be read from config files and env
+// variables written in Go duration format, e.g. "5m" or "250ms".
+type Duration time.Duration
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
@@ -1,10 +1,8 @@
 package config
 
-import "os"
-
-var (
-	SMTP_HOST     = os.Getenv("SMTP_HOST")
-	SMTP_PORT     = os.Getenv("SMTP_PORT")
-	SMTP_USERNAME = os.Getenv("SMTP_USERNAME")
-	SMTP_PASSWORD = os.Getenv("SMTP_PASSWORD")
-)
+type SMTPConfig struct {
+	Host     string `yaml:"host" json:"host"`
+	Port     string `yaml:"port" json:"port"`
+	Username string `yaml:"username" json:"username"`
+	Password string `yaml:"password" json:"password"`
+}
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"fmt"
+
+	"storichallenge_layer/models"
+)
+
+// PeriodsConfig decides what happens to transactions dated in a closed
+// balance month: "reject" refuses them and "adjust" posts them into the first
+// open month as adjustment entries.
+type PeriodsConfig struct {
+	Backdating string `yaml:"backdating" json:"backdating"`
+}
+
+func (cfg PeriodsConfig) validate() []string {
+	if !models.BackdatePolicy(cfg.Backdating).IsValid() {
+		return []string{fmt.Sprintf("PERIOD_BACKDATING must be %s or %s, instead given: %s", models.BACKDATE_REJECT, models.BACKDATE_ADJUST, cfg.Backdating)}
+	}
+	return nil
+}
+
+func defaultPeriodsConfig() PeriodsConfig {
+	return PeriodsConfig{Backdating: string(models.BACKDATE_REJECT)}
+}
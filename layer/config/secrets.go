@@ -0,0 +1,125 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+const (
+	SECRETS_PROVIDER_ENV  = "env"
+	SECRETS_PROVIDER_FILE = "file"
+	SECRETS_PROVIDER_AWS  = "aws"
+)
+
+// SecretsConfig selects where sensitive values such as passwords are read from.
+type SecretsConfig struct {
+	Provider    string `yaml:"provider" json:"provider"`
+	Dir         string `yaml:"dir" json:"dir"`
+	AWSSecretID string `yaml:"aws_secret_id" json:"aws_secret_id"`
+	AWSRegion   string `yaml:"aws_region" json:"aws_region"`
+	// AWSEndpoint overrides the Secrets Manager endpoint, e.g. to point at a
+	// LocalStack container during local development.
+	AWSEndpoint string `yaml:"aws_endpoint" json:"aws_endpoint"`
+}
+
+// SecretProvider returns the value stored under key, or an empty string when
+// the provider has no value for it.
+type SecretProvider interface {
+	GetSecret(key string) (string, error)
+}
+
+func NewSecretProvider(cfg SecretsConfig) (SecretProvider, error) {
+	switch cfg.Provider {
+	case "", SECRETS_PROVIDER_ENV:
+		return EnvSecretProvider{}, nil
+	case SECRETS_PROVIDER_FILE:
+		if cfg.Dir == "" {
+			return nil, errors.New("SECRETS_DIR must be provided when using the file secrets provider")
+		}
+		return FileSecretProvider{Dir: cfg.Dir}, nil
+	case SECRETS_PROVIDER_AWS:
+		if cfg.AWSSecretID == "" {
+			return nil, errors.New("SECRETS_AWS_SECRET_ID must be provided when using the aws secrets provider")
+		}
+		return NewAWSSecretProvider(cfg)
+	default:
+		return nil, fmt.Errorf("unknown secrets provider %q, expected one of: env, file, aws", cfg.Provider)
+	}
+}
+
+// EnvSecretProvider reads secrets straight from environment variables.
+type EnvSecretProvider struct{}
+
+func (EnvSecretProvider) GetSecret(key string) (string, error) {
+	return os.Getenv(key), nil
+}
+
+// FileSecretProvider reads each secret from a file named after its key inside
+// Dir, the layout used by Docker and Kubernetes secret mounts.
+type FileSecretProvider struct {
+	Dir string
+}
+
+func (p FileSecretProvider) GetSecret(key string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(p.Dir, key))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// AWSSecretProvider reads a single Secrets Manager secret holding a JSON
+// object of key/value pairs. The secret is fetched once and cached.
+type AWSSecretProvider struct {
+	client   *secretsmanager.Client
+	secretID string
+	values   map[string]string
+}
+
+func NewAWSSecretProvider(cfg SecretsConfig) (*AWSSecretProvider, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if cfg.AWSRegion != "" {
+		opts = append(opts, awsconfig.WithRegion(cfg.AWSRegion))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error while loading AWS configuration: %v", err)
+	}
+
+	client := secretsmanager.NewFromConfig(awsCfg, func(o *secretsmanager.Options) {
+		if cfg.AWSEndpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.AWSEndpoint)
+		}
+	})
+
+	return &AWSSecretProvider{client: client, secretID: cfg.AWSSecretID}, nil
+}
+
+func (p *AWSSecretProvider) GetSecret(key string) (string, error) {
+	if p.values == nil {
+		output, err := p.client.GetSecretValue(context.Background(), &secretsmanager.GetSecretValueInput{
+			SecretId: aws.String(p.secretID),
+		})
+		if err != nil {
+			return "", fmt.Errorf("error while fetching secret %s: %v", p.secretID, err)
+		}
+		values := map[string]string{}
+		if err := json.Unmarshal([]byte(aws.ToString(output.SecretString)), &values); err != nil {
+			return "", fmt.Errorf("secret %s must hold a JSON object of strings: %v", p.secretID, err)
+		}
+		p.values = values
+	}
+	return p.values[key], nil
+}
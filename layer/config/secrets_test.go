@@ -0,0 +1,78 @@
+package config
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEnvSecretProvider(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "env-password")
+	provider, err := NewSecretProvider(SecretsConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSecret(t, provider, "DB_PASSWORD", "env-password")
+}
+
+func TestFileSecretProvider(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "DB_PASSWORD", "  file-password\n")
+	provider, err := NewSecretProvider(SecretsConfig{Provider: SECRETS_PROVIDER_FILE, Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSecret(t, provider, "DB_PASSWORD", "file-password")
+	checkSecret(t, provider, "SMTP_PASSWORD", "")
+}
+
+func TestAWSSecretProvider(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if target := r.Header.Get("X-Amz-Target"); target != "secretsmanager.GetSecretValue" {
+			t.Errorf("called %s, want GetSecretValue", target)
+		}
+		var input struct{ SecretId string }
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.SecretId != "stori/prod" {
+			t.Errorf("asked for secret %q (%v), want stori/prod", input.SecretId, err)
+		}
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		json.NewEncoder(w).Encode(map[string]string{
+			"Name":         "stori/prod",
+			"SecretString": `{"DB_PASSWORD": "aws-password"}`,
+		})
+	}))
+	defer server.Close()
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	t.Setenv("AWS_CONFIG_FILE", "/nonexistent")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/nonexistent")
+
+	provider, err := NewSecretProvider(SecretsConfig{
+		Provider:    SECRETS_PROVIDER_AWS,
+		AWSSecretID: "stori/prod",
+		AWSRegion:   "us-east-1",
+		AWSEndpoint: server.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSecret(t, provider, "DB_PASSWORD", "aws-password")
+	checkSecret(t, provider, "SMTP_PASSWORD", "")
+	if requests != 1 {
+		t.Errorf("fetched the secret %d times, want once", requests)
+	}
+}
+
+func checkSecret(tb testing.TB, provider SecretProvider, key string, want string) {
+	tb.Helper()
+	got, err := provider.GetSecret(key)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if got != want {
+		tb.Errorf("secret %s = %q, want %q", key, got, want)
+	}
+}
@@ -0,0 +1,93 @@
+package generator
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+const (
+	DISTRIBUTION_FIXED     = "fixed"
+	DISTRIBUTION_UNIFORM   = "uniform"
+	DISTRIBUTION_NORMAL    = "normal"
+	DISTRIBUTION_LOGNORMAL = "lognormal"
+)
+
+// Distribution describes how amounts, in currency units, are drawn. Min and
+// Max bound every kind except fixed; a zero Max means no upper bound.
+type Distribution struct {
+	Kind   string  `yaml:"kind" json:"kind"`
+	Value  float64 `yaml:"value" json:"value"`
+	Min    float64 `yaml:"min" json:"min"`
+	Max    float64 `yaml:"max" json:"max"`
+	Mean   float64 `yaml:"mean" json:"mean"`
+	StdDev float64 `yaml:"std_dev" json:"std_dev"`
+}
+
+func (d Distribution) validate() error {
+	switch d.Kind {
+	case DISTRIBUTION_FIXED:
+		if d.Value <= 0 {
+			return fmt.Errorf("fixed distribution value must be positive, instead given: %v", d.Value)
+		}
+	case DISTRIBUTION_UNIFORM:
+		if d.Min < 0 || d.Max <= d.Min {
+			return fmt.Errorf("uniform distribution needs 0 <= min < max, instead given: min %v, max %v", d.Min, d.Max)
+		}
+	case DISTRIBUTION_NORMAL, DISTRIBUTION_LOGNORMAL:
+		if d.Mean <= 0 || d.StdDev < 0 {
+			return fmt.Errorf("%s distribution needs a positive mean and a non-negative std_dev", d.Kind)
+		}
+		if d.Max != 0 && d.Max < d.Min {
+			return fmt.Errorf("%s distribution max must not be lower than min", d.Kind)
+		}
+	default:
+		return fmt.Errorf("unknown distribution kind %q, expected one of: fixed, uniform, normal, lognormal", d.Kind)
+	}
+	return nil
+}
+
+// sampleCents draws a positive amount in cents.
+func (d Distribution) sampleCents(rng *rand.Rand) int64 {
+	var value float64
+	switch d.Kind {
+	case DISTRIBUTION_FIXED:
+		value = d.Value
+	case DISTRIBUTION_UNIFORM:
+		value = d.Min + rng.Float64()*(d.Max-d.Min)
+	case DISTRIBUTION_NORMAL:
+		value = d.Mean + rng.NormFloat64()*d.StdDev
+	case DISTRIBUTION_LOGNORMAL:
+		// Parameters of the underlying normal so the result has the requested
+		// mean and standard deviation.
+		variance := math.Log(1 + (d.StdDev*d.StdDev)/(d.Mean*d.Mean))
+		mu := math.Log(d.Mean) - variance/2
+		value = math.Exp(mu + rng.NormFloat64()*math.Sqrt(variance))
+	}
+
+	if d.Kind != DISTRIBUTION_FIXED {
+		value = math.Max(value, d.Min)
+		if d.Max != 0 {
+			value = math.Min(value, d.Max)
+		}
+	}
+
+	cents := int64(math.Round(value * 100))
+	if cents < 1 {
+		cents = 1
+	}
+	return cents
+}
+
+// Range is an inclusive integer range.
+type Range struct {
+	Min int `yaml:"min" json:"min"`
+	Max int `yaml:"max" json:"max"`
+}
+
+func (r Range) sample(rng *rand.Rand) int {
+	if r.Max <= r.Min {
+		return r.Min
+	}
+	return r.Min + rng.Intn(r.Max-r.Min+1)
+}
@@ -0,0 +1,258 @@
+package generator
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"storichallenge_layer/models"
+)
+
+// Dataset is the output of Generate. Transactions are sorted by date and have
+// no AccountID until the dataset is persisted.
+type Dataset struct {
+	Accounts []GeneratedAccount
+}
+
+type GeneratedAccount struct {
+	Customer     models.Customer
+	Account      models.Account
+	Profile      string
+	Transactions []models.Transaction
+}
+
+// Store is the part of services.AccountService needed to persist a dataset.
+type Store interface {
+	CreateCustomer(customer models.Customer) (int64, error)
+	CreateAccount(account models.Account) (int64, error)
+	CreateTransactions(transactions []models.Transaction) error
+}
+
+// Generate builds the accounts and transactions described by the scenario.
+// It only draws from a generator seeded with scenario.Seed, so the output is
+// reproducible.
+func Generate(scenario Scenario) (Dataset, error) {
+	if err := scenario.Validate(); err != nil {
+		return Dataset{}, err
+	}
+
+	rng := rand.New(rand.NewSource(scenario.Seed))
+	dataset := Dataset{Accounts: make([]GeneratedAccount, 0, scenario.Accounts)}
+
+	for i := 0; i < scenario.Accounts; i++ {
+		profile := pickProfile(rng, scenario.Profiles)
+
+		customer, account, err := generateAccount(rng, scenario, profile, i)
+		if err != nil {
+			return Dataset{}, err
+		}
+
+		var transactions []models.Transaction
+		if scenario.TransactionsPerAccount > 0 {
+			transactions, err = generateFixedCount(rng, scenario, profile)
+		} else {
+			transactions, err = generateMonthly(rng, scenario, profile)
+		}
+		if err != nil {
+			return Dataset{}, err
+		}
+		sort.SliceStable(transactions, func(a, b int) bool {
+			return transactions[a].DateTime.Before(transactions[b].DateTime)
+		})
+
+		dataset.Accounts = append(dataset.Accounts, GeneratedAccount{
+			Customer:     customer,
+			Account:      account,
+			Profile:      profile.Name,
+			Transactions: transactions,
+		})
+	}
+
+	return dataset, nil
+}
+
+// Persist creates every customer, their account and then its transactions as
+// one batch through store, filling in the generated IDs.
+func (d *Dataset) Persist(store Store) error {
+	for i := range d.Accounts {
+		generated := &d.Accounts[i]
+
+		customerID, err := store.CreateCustomer(generated.Customer)
+		if err != nil {
+			return fmt.Errorf("failed to save customer in DB: %v", err)
+		}
+		generated.Customer.ID = customerID
+		generated.Account.CustomerID = customerID
+
+		accountID, err := store.CreateAccount(generated.Account)
+		if err != nil {
+			return fmt.Errorf("failed to save account in DB: %v", err)
+		}
+		generated.Account.ID = accountID
+
+		for j := range generated.Transactions {
+			generated.Transactions[j].AccountID = accountID
+		}
+		if err := store.CreateTransactions(generated.Transactions); err != nil {
+			return fmt.Errorf("failed to save transactions in DB: %v", err)
+		}
+	}
+	return nil
+}
+
+func (d Dataset) TransactionCount() int {
+	count := 0
+	for _, account := range d.Accounts {
+		count += len(account.Transactions)
+	}
+	return count
+}
+
+func pickProfile(rng *rand.Rand, profiles []Profile) Profile {
+	total := 0
+	for _, profile := range profiles {
+		total += profile.Weight
+	}
+	pick := rng.Intn(total)
+	for _, profile := range profiles {
+		if pick < profile.Weight {
+			return profile
+		}
+		pick -= profile.Weight
+	}
+	return profiles[len(profiles)-1]
+}
+
+func generateAccount(rng *rand.Rand, scenario Scenario, profile Profile, index int) (models.Customer, models.Account, error) {
+	name := firstNames[rng.Intn(len(firstNames))]
+	lastName := lastNames[rng.Intn(len(lastNames))]
+	secondLastName := lastNames[rng.Intn(len(lastNames))]
+	birthDate := dateOfBirth(rng, scenario.End.Time, profile.Age.sample(rng))
+	curp := generateCURP(rng, name, lastName, secondLastName, birthDate)
+	rfc := generateRFC(rng, name, lastName, secondLastName, birthDate)
+	phone := generatePhone(rng)
+	// The index keeps emails unique when two customers share a name.
+	email := fmt.Sprintf("%s.%s.%d@%s", strings.ToLower(name), strings.ToLower(lastName), index+1, scenario.EmailDomain)
+
+	customer, err := models.NewCustomer(name, lastName, secondLastName, birthDate, curp, rfc, phone, email)
+	if err != nil {
+		return models.Customer{}, models.Account{}, err
+	}
+	account := models.NewAccount(0)
+
+	bankCode, branchCode := scenario.BankCode, scenario.BranchCode
+	if bankCode == "" {
+		bankCode = models.DEFAULT_BANK_CODE
+	}
+	if branchCode == "" {
+		branchCode = models.DEFAULT_BRANCH_CODE
+	}
+	account.AccountNumber, err = models.NewAccountNumber(bankCode, branchCode, rng.Int63n(models.MAX_ACCOUNT_SERIAL))
+	if err != nil {
+		return models.Customer{}, models.Account{}, err
+	}
+
+	return customer, account, nil
+}
+
+func generateMonthly(rng *rand.Rand, scenario Scenario, profile Profile) ([]models.Transaction, error) {
+	start := scenario.Start.Time
+	end := scenario.End.AddDate(0, 0, 1) // End is inclusive
+
+	var transactions []models.Transaction
+	for month := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC); month.Before(end); month = month.AddDate(0, 1, 0) {
+		nextMonth := month.AddDate(0, 1, 0)
+
+		for _, rule := range profile.Recurring {
+			day := rule.DayOfMonth
+			if lastDay := nextMonth.AddDate(0, 0, -1).Day(); day > lastDay {
+				day = lastDay
+			}
+			dateTime := month.AddDate(0, 0, day-1).Add(randomTimeOfDay(rng))
+			if dateTime.Before(start) || !dateTime.Before(end) {
+				continue
+			}
+			transaction, err := newTransaction(rng, rule.Kind, rule.Amount, dateTime, rule.transactionDetails(rule.Name))
+			if err != nil {
+				return nil, err
+			}
+			transactions = append(transactions, transaction)
+		}
+
+		from, to := maxTime(month, start), minTime(nextMonth, end)
+		for _, rule := range profile.Random {
+			for n := rule.PerMonth.sample(rng); n > 0; n-- {
+				transaction, err := newTransaction(rng, rule.Kind, rule.Amount, randomDateTime(rng, from, to), rule.transactionDetails(rule.Name))
+				if err != nil {
+					return nil, err
+				}
+				transactions = append(transactions, transaction)
+			}
+		}
+	}
+
+	return transactions, nil
+}
+
+func generateFixedCount(rng *rand.Rand, scenario Scenario, profile Profile) ([]models.Transaction, error) {
+	start := scenario.Start.Time
+	end := scenario.End.AddDate(0, 0, 1)
+	total := randomWeight(profile)
+
+	transactions := make([]models.Transaction, 0, scenario.TransactionsPerAccount)
+	for i := 0; i < scenario.TransactionsPerAccount; i++ {
+		pick := rng.Intn(total)
+		var rule RandomRule
+		for _, rule = range profile.Random {
+			if pick < rule.Weight {
+				break
+			}
+			pick -= rule.Weight
+		}
+		transaction, err := newTransaction(rng, rule.Kind, rule.Amount, randomDateTime(rng, start, end), rule.transactionDetails(rule.Name))
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, transaction)
+	}
+	return transactions, nil
+}
+
+func newTransaction(rng *rand.Rand, kind string, amount Distribution, dateTime time.Time, details models.TransactionDetails) (models.Transaction, error) {
+	cents := amount.sampleCents(rng)
+	if kind == KIND_DEBIT || (kind == KIND_EITHER && rng.Intn(2) == 0) {
+		cents = -cents
+	}
+	return models.NewTransactionWithDetails(cents, dateTime, 0, details)
+}
+
+// randomDateTime returns a moment in [from, to), truncated to the second as
+// that is what the database keeps.
+func randomDateTime(rng *rand.Rand, from, to time.Time) time.Time {
+	seconds := int64(to.Sub(from) / time.Second)
+	if seconds <= 0 {
+		return from
+	}
+	return from.Add(time.Duration(rng.Int63n(seconds)) * time.Second)
+}
+
+// randomTimeOfDay spreads recurring postings between 06:00 and 22:00.
+func randomTimeOfDay(rng *rand.Rand) time.Duration {
+	return 6*time.Hour + time.Duration(rng.Int63n(int64(16*time.Hour/time.Second)))*time.Second
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
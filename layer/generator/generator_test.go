@@ -0,0 +1,73 @@
+package generator
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"storichallenge_layer/validation"
+)
+
+func TestGenerateIsReproducible(t *testing.T) {
+	scenario := DefaultScenario()
+	scenario.Seed = 42
+	first, err := Generate(scenario)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Generate(scenario)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.Accounts) != scenario.Accounts || first.TransactionCount() == 0 {
+		t.Fatalf("generated %d accounts and %d transactions, want %d accounts with transactions",
+			len(first.Accounts), first.TransactionCount(), scenario.Accounts)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Error("the same seed generated different datasets")
+	}
+
+	scenario.Seed = 43
+	other, err := Generate(scenario)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reflect.DeepEqual(first, other) {
+		t.Error("different seeds generated the same dataset")
+	}
+}
+
+func TestScenarioValidate(t *testing.T) {
+	if err := DefaultScenario().Validate(); err != nil {
+		t.Fatalf("built-in scenario is invalid: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*Scenario)
+		field  string
+		code   string
+	}{
+		{"no accounts", func(s *Scenario) { s.Accounts = 0 }, "accounts", validation.CodeRange},
+		{"too many accounts", func(s *Scenario) { s.Accounts = MAX_ACCOUNTS + 1 }, "accounts", validation.CodeRange},
+		{"no end", func(s *Scenario) { s.End = Date{} }, "end", validation.CodeRequired},
+		{"end before start", func(s *Scenario) { s.End = Date{s.Start.Add(-24 * time.Hour)} }, "end", validation.CodeInvalid},
+		{"no profiles", func(s *Scenario) { s.Profiles = nil }, "profiles", validation.CodeRequired},
+		{"day of month", func(s *Scenario) { s.Profiles[0].Recurring[0].DayOfMonth = 32 }, "profiles[0].recurring[0].day_of_month", validation.CodeRange},
+		{"amount", func(s *Scenario) { s.Profiles[0].Random[0].Amount.Kind = "pareto" }, "profiles[0].random[0].amount", validation.CodeInvalid},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scenario := DefaultScenario()
+			test.modify(&scenario)
+			_, err := Generate(scenario)
+			validationErr, ok := validation.AsValidationError(err)
+			if !ok || len(validationErr.Errors) != 1 {
+				t.Fatalf("Generate error %v, want a single field error", err)
+			}
+			if got := validationErr.Errors[0]; got.Field != test.field || got.Code != test.code {
+				t.Errorf("got %s error on %s, want %s on %s", got.Code, got.Field, test.code, test.field)
+			}
+		})
+	}
+}
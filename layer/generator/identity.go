@@ -0,0 +1,63 @@
+package generator
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"storichallenge_layer/validation"
+)
+
+// curpStates are the birth state codes used in positions 12 and 13 of a CURP.
+var curpStates = []string{
+	"AS", "BC", "BS", "CC", "CL", "CM", "CS", "CH", "DF", "DG", "GT", "GR", "HG",
+	"JC", "MC", "MN", "MS", "NT", "NL", "OC", "PL", "QT", "QR", "SP", "SL", "SR",
+	"TC", "TS", "TL", "VZ", "YN", "ZS",
+}
+
+const homoclaveAlphabet = "0123456789ABCDEFGHIJKLMNPQRSTUVWXYZ"
+
+// dateOfBirth picks a birth date so the customer is age years old at the
+// scenario end date.
+func dateOfBirth(rng *rand.Rand, end time.Time, age int) time.Time {
+	latest := time.Date(end.Year()-age, end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+	return latest.AddDate(0, 0, -rng.Intn(365))
+}
+
+// generateCURP builds a CURP that is consistent with the customer data and
+// has a valid check digit. Sex and birth state are random.
+func generateCURP(rng *rand.Rand, name string, lastName string, secondLastName string, dateOfBirth time.Time) string {
+	sex := "HM"[rng.Intn(2)]
+	// The differentiator is a digit for people born before 2000 and a letter
+	// afterwards.
+	differentiator := byte('0' + rng.Intn(10))
+	if dateOfBirth.Year() >= 2000 {
+		differentiator = byte('A' + rng.Intn(26))
+	}
+	first17 := fmt.Sprintf("%s%s%c%s%s%c",
+		validation.NamePrefix(name, lastName, secondLastName),
+		dateOfBirth.Format("060102"),
+		sex,
+		curpStates[rng.Intn(len(curpStates))],
+		validation.InnerConsonants(name, lastName, secondLastName),
+		differentiator,
+	)
+	return fmt.Sprintf("%s%d", first17, validation.CURPCheckDigit(first17))
+}
+
+// generateRFC builds a person's RFC with a random homoclave and a valid check
+// digit.
+func generateRFC(rng *rand.Rand, name string, lastName string, secondLastName string, dateOfBirth time.Time) string {
+	first12 := fmt.Sprintf("%s%s%c%c",
+		validation.RFCNamePrefix(name, lastName, secondLastName),
+		dateOfBirth.Format("060102"),
+		homoclaveAlphabet[rng.Intn(len(homoclaveAlphabet))],
+		homoclaveAlphabet[rng.Intn(len(homoclaveAlphabet))],
+	)
+	return first12 + string(validation.RFCCheckDigit(first12))
+}
+
+// generatePhone returns a Mexican mobile number in E.164 format.
+func generatePhone(rng *rand.Rand) string {
+	return fmt.Sprintf("+52%d%09d", 1+rng.Intn(9), rng.Intn(1000000000))
+}
@@ -0,0 +1,15 @@
+package generator
+
+var firstNames = []string{
+	"Sofia", "Mateo", "Valentina", "Santiago", "Regina", "Sebastian", "Camila",
+	"Leonardo", "Ximena", "Diego", "Mariana", "Emiliano", "Daniela", "Miguel",
+	"Fernanda", "Alejandro", "Renata", "Carlos", "Andrea", "Jose", "Paula",
+	"Luis", "Natalia", "Jorge", "Lucia", "Ricardo", "Gabriela", "Fernando",
+}
+
+var lastNames = []string{
+	"Hernandez", "Garcia", "Martinez", "Lopez", "Gonzalez", "Perez", "Rodriguez",
+	"Sanchez", "Ramirez", "Cruz", "Flores", "Gomez", "Morales", "Vazquez",
+	"Reyes", "Jimenez", "Torres", "Diaz", "Gutierrez", "Ruiz", "Mendoza",
+	"Aguilar", "Ortiz", "Moreno", "Castillo", "Romero", "Alvarez", "Chavez",
+}
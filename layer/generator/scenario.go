@@ -0,0 +1,260 @@
+package generator
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"storichallenge_layer/models"
+	"storichallenge_layer/validation"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	KIND_DEBIT  = "debit"
+	KIND_CREDIT = "credit"
+	// KIND_EITHER picks debit or credit with equal odds for each transaction.
+	KIND_EITHER = "either"
+
+	DATE_FORMAT = "2006-01-02"
+
+	// MAX_ACCOUNTS bounds the accounts of a scenario, which are generated in
+	// memory and persisted in a single run.
+	MAX_ACCOUNTS = 1000
+)
+
+//go:embed scenarios/default.yaml
+var defaultScenario []byte
+
+// Scenario describes a synthetic data set. The same scenario and seed always
+// produce the same accounts and transactions.
+type Scenario struct {
+	Seed        int64  `yaml:"seed" json:"seed"`
+	Start       Date   `yaml:"start" json:"start"`
+	End         Date   `yaml:"end" json:"end"`
+	Accounts    int    `yaml:"accounts" json:"accounts"`
+	EmailDomain string `yaml:"email_domain" json:"email_domain"`
+	// BankCode and BranchCode prefix the generated CLABE account numbers and
+	// default to models.DEFAULT_BANK_CODE and models.DEFAULT_BRANCH_CODE.
+	BankCode   string    `yaml:"bank_code" json:"bank_code"`
+	BranchCode string    `yaml:"branch_code" json:"branch_code"`
+	Profiles   []Profile `yaml:"profiles" json:"profiles"`
+	// TransactionsPerAccount, when set, replaces the per-month counts of the
+	// random rules: each account gets exactly that many random transactions,
+	// spread over the date range and split between rules by weight.
+	TransactionsPerAccount int `yaml:"transactions_per_account" json:"transactions_per_account"`
+}
+
+// Profile is a kind of customer, e.g. a salaried renter or a student.
+// Accounts pick a profile at random, proportionally to Weight.
+type Profile struct {
+	Name      string          `yaml:"name" json:"name"`
+	Weight    int             `yaml:"weight" json:"weight"`
+	Age       Range           `yaml:"age" json:"age"`
+	Recurring []RecurringRule `yaml:"recurring" json:"recurring"`
+	Random    []RandomRule    `yaml:"random" json:"random"`
+}
+
+// RecurringRule posts one transaction every month on DayOfMonth, such as a
+// salary, the rent or a subscription. Days past the end of a short month
+// fall on its last day.
+type RecurringRule struct {
+	Name        string       `yaml:"name" json:"name"`
+	Kind        string       `yaml:"kind" json:"kind"`
+	DayOfMonth  int          `yaml:"day_of_month" json:"day_of_month"`
+	Amount      Distribution `yaml:"amount" json:"amount"`
+	RuleDetails `yaml:",inline"`
+}
+
+// RandomRule posts PerMonth transactions at random moments of each month,
+// such as groceries or restaurants.
+type RandomRule struct {
+	Name        string       `yaml:"name" json:"name"`
+	Kind        string       `yaml:"kind" json:"kind"`
+	PerMonth    Range        `yaml:"per_month" json:"per_month"`
+	Weight      int          `yaml:"weight" json:"weight"`
+	Amount      Distribution `yaml:"amount" json:"amount"`
+	RuleDetails `yaml:",inline"`
+}
+
+// RuleDetails describes the transactions posted by a rule, see
+// models.TransactionDetails. Every field is optional: Type is purchase for
+// debits and payment for credits, and Description the rule name.
+type RuleDetails struct {
+	Type        string `yaml:"type" json:"type"`
+	Description string `yaml:"description" json:"description"`
+	Merchant    string `yaml:"merchant" json:"merchant"`
+	MCC         string `yaml:"mcc" json:"mcc"`
+	Channel     string `yaml:"channel" json:"channel"`
+	Location    string `yaml:"location" json:"location"`
+}
+
+// transactionDetails are the details of the transactions of the rule named
+// name.
+func (d RuleDetails) transactionDetails(name string) models.TransactionDetails {
+	description := d.Description
+	if description == "" {
+		description = strings.ReplaceAll(name, "_", " ")
+	}
+	return models.TransactionDetails{
+		Type:        models.TransactionType(d.Type),
+		Description: description,
+		Merchant:    d.Merchant,
+		MCC:         d.MCC,
+		Channel:     models.TransactionChannel(d.Channel),
+		Location:    d.Location,
+	}
+}
+
+// validate builds a transaction of each sign kind allows with the details.
+func (d RuleDetails) validate(name string, kind string) error {
+	var signs []int64
+	switch kind {
+	case KIND_DEBIT:
+		signs = []int64{-1}
+	case KIND_CREDIT:
+		signs = []int64{1}
+	default:
+		signs = []int64{-1, 1}
+	}
+	for _, sign := range signs {
+		if _, err := models.NewTransactionWithDetails(sign, time.Time{}, 0, d.transactionDetails(name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Date is a calendar day written as YYYY-MM-DD in scenario files.
+type Date struct {
+	time.Time
+}
+
+func (d *Date) UnmarshalText(text []byte) error {
+	parsed, err := time.Parse(DATE_FORMAT, string(text))
+	if err != nil {
+		return fmt.Errorf("date must be given as YYYY-MM-DD, instead given: %s", text)
+	}
+	d.Time = parsed
+	return nil
+}
+
+func (d Date) MarshalText() ([]byte, error) {
+	return []byte(d.Format(DATE_FORMAT)), nil
+}
+
+// UnmarshalJSON and MarshalJSON shadow the RFC 3339 ones promoted from
+// time.Time.
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	return d.UnmarshalText([]byte(text))
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Format(DATE_FORMAT))
+}
+
+// DefaultScenario returns the built-in scenario in scenarios/default.yaml.
+func DefaultScenario() Scenario {
+	var scenario Scenario
+	if err := yaml.Unmarshal(defaultScenario, &scenario); err != nil {
+		panic(fmt.Sprintf("invalid built-in scenario: %v", err))
+	}
+	return scenario
+}
+
+// LoadScenario reads a YAML or JSON scenario file.
+func LoadScenario(path string) (Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Scenario{}, fmt.Errorf("error while reading scenario file %s: %v", path, err)
+	}
+
+	var scenario Scenario
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &scenario)
+	case ".json":
+		err = json.Unmarshal(data, &scenario)
+	default:
+		return Scenario{}, fmt.Errorf("scenario file %s must have a .yaml, .yml or .json extension", path)
+	}
+	if err != nil {
+		return Scenario{}, fmt.Errorf("error while parsing scenario file %s: %v", path, err)
+	}
+
+	if err := scenario.Validate(); err != nil {
+		return Scenario{}, fmt.Errorf("invalid scenario file %s: %w", path, err)
+	}
+	return scenario, nil
+}
+
+// Validate checks the scenario, reporting every problem as a field of a
+// validation.ValidationError so that API callers get them in their language.
+func (s Scenario) Validate() error {
+	var errs validation.ValidationError
+
+	startOk := errs.Check(!s.Start.IsZero(), "start", validation.CodeRequired, "start")
+	endOk := errs.Check(!s.End.IsZero(), "end", validation.CodeRequired, "end")
+	if startOk && endOk {
+		errs.Check(s.End.After(s.Start.Time), "end", validation.CodeInvalid, "end date must be after start date")
+	}
+	errs.Check(s.Accounts >= 1 && s.Accounts <= MAX_ACCOUNTS, "accounts", validation.CodeRange, "accounts", 1, MAX_ACCOUNTS, s.Accounts)
+	errs.Check(s.EmailDomain != "", "email_domain", validation.CodeRequired, "email_domain")
+	errs.Check(s.TransactionsPerAccount >= 0, "transactions_per_account", validation.CodeInvalid, "transactions_per_account must not be negative")
+	errs.Check(len(s.Profiles) > 0, "profiles", validation.CodeRequired, "profiles")
+
+	for i, profile := range s.Profiles {
+		field := validation.Index("profiles", i)
+		errs.Check(profile.Weight >= 1, validation.Path(field, "weight"), validation.CodeInvalid,
+			fmt.Sprintf("profile %s: weight must be at least 1", profile.Name))
+		errs.Check(profile.Age.Min >= 18 && profile.Age.Max >= profile.Age.Min, validation.Path(field, "age"), validation.CodeInvalid,
+			fmt.Sprintf("profile %s: age range must start at 18 or more", profile.Name))
+		for j, rule := range profile.Recurring {
+			ruleField := validation.Path(field, validation.Index("recurring", j))
+			errs.Check(validKind(rule.Kind), validation.Path(ruleField, "kind"), validation.CodeInvalid,
+				fmt.Sprintf("profile %s, rule %s: kind must be debit, credit or either", profile.Name, rule.Name))
+			errs.Check(rule.DayOfMonth >= 1 && rule.DayOfMonth <= 31, validation.Path(ruleField, "day_of_month"), validation.CodeRange,
+				"day_of_month", 1, 31, rule.DayOfMonth)
+			errs.Merge(validation.Path(ruleField, "amount"), rule.Amount.validate())
+			errs.Merge(ruleField, rule.RuleDetails.validate(rule.Name, rule.Kind))
+		}
+		for j, rule := range profile.Random {
+			ruleField := validation.Path(field, validation.Index("random", j))
+			errs.Check(validKind(rule.Kind), validation.Path(ruleField, "kind"), validation.CodeInvalid,
+				fmt.Sprintf("profile %s, rule %s: kind must be debit, credit or either", profile.Name, rule.Name))
+			errs.Check(rule.PerMonth.Min >= 0 && rule.PerMonth.Max >= rule.PerMonth.Min, validation.Path(ruleField, "per_month"), validation.CodeInvalid,
+				fmt.Sprintf("profile %s, rule %s: per_month must be a non-negative range", profile.Name, rule.Name))
+			errs.Check(rule.Weight >= 0, validation.Path(ruleField, "weight"), validation.CodeInvalid,
+				fmt.Sprintf("profile %s, rule %s: weight must not be negative", profile.Name, rule.Name))
+			errs.Merge(validation.Path(ruleField, "amount"), rule.Amount.validate())
+			errs.Merge(ruleField, rule.RuleDetails.validate(rule.Name, rule.Kind))
+		}
+		if s.TransactionsPerAccount > 0 {
+			errs.Check(randomWeight(profile) > 0, validation.Path(field, "random"), validation.CodeInvalid,
+				fmt.Sprintf("profile %s: transactions_per_account needs at least one random rule with a positive weight", profile.Name))
+		}
+	}
+
+	return errs.Err()
+}
+
+func validKind(kind string) bool {
+	return kind == KIND_DEBIT || kind == KIND_CREDIT || kind == KIND_EITHER
+}
+
+func randomWeight(profile Profile) int {
+	total := 0
+	for _, rule := range profile.Random {
+		total += rule.Weight
+	}
+	return total
+}
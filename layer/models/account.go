@@ -1,43 +1,144 @@
 package models
 
 import (
-	"fmt"
+	"errors"
 	"storichallenge_layer/validation"
+	"time"
 )
 
+type AccountType string
+
+const (
+	ACCOUNT_TYPE_DEBIT AccountType = "debit"
+	// ACCOUNT_TYPE_CREDIT is a credit card: purchases are debits that make the
+	// balance negative, up to the credit limit, and payments are credits.
+	ACCOUNT_TYPE_CREDIT AccountType = "credit"
+)
+
+const (
+	MAX_CUTOFF_DAY           = 28
+	DEFAULT_PAYMENT_DUE_DAYS = 20
+	MAX_PAYMENT_DUE_DAYS     = 60
+)
+
+var ErrCreditLimitExceeded = errors.New("transaction exceeds the available credit of the account")
+
 type Account struct {
 	ID                   int64
+	CustomerID           int64
 	AccountNumber        string
-	Name                 string
-	LastName             string
-	Age                  int
-	Email                string
+	Type                 AccountType
 	CurrentBalanceAmount int64
-	Balances             []Balance
+	// Credit accounts only. CreditLimit is in cents; the statement cycle
+	// closes on CutoffDay of every month and is due PaymentDueDays later.
+	CreditLimit    int64
+	CutoffDay      int
+	PaymentDueDays int
+	// PendingInstallments is the sum of the installments of active plans not
+	// charged yet, in cents. They take up credit though they are not in the
+	// balance.
+	PendingInstallments int64
+	// HeldAmount is the sum of the pending authorization holds not expired
+	// yet, in cents. Like pending installments, it takes up funds though it
+	// is not in the balance.
+	HeldAmount int64
+	// ClosedThrough is the last closed balance month; nothing can be posted
+	// into it or any earlier month. It is empty until a month is closed.
+	ClosedThrough string
+	Status        AccountStatus
+	StatusReason  string
+	// StatusChangedAt is zero until the status first changes.
+	StatusChangedAt time.Time
+	// Customer is filled in when the account is read from the database.
+	Customer Customer
+	Balances []Balance
 }
 
-func NewAccount(name string, lastName string, age int, email string) (Account, error) {
-	if name == "" {
-		return Account{}, fmt.Errorf(validation.ErrFieldRequired, "account customer name")
+// NewAccount opens an active debit account for a customer. The account number
+// is issued when the account is created.
+func NewAccount(customerID int64) Account {
+	return Account{
+		CustomerID:           customerID,
+		AccountNumber:        "",
+		Type:                 ACCOUNT_TYPE_DEBIT,
+		CurrentBalanceAmount: 0,
+		Status:               ACCOUNT_STATUS_ACTIVE,
 	}
-	if lastName == "" {
-		return Account{}, fmt.Errorf(validation.ErrFieldRequired, "account customer lastName")
+}
+
+// NewCreditAccount opens a credit card for a customer. cutoffDay is capped at
+// 28 so that every month has a cut-off date; paymentDueDays of 0 means
+// DEFAULT_PAYMENT_DUE_DAYS.
+func NewCreditAccount(customerID int64, creditLimit int64, cutoffDay int, paymentDueDays int) (Account, error) {
+	if paymentDueDays == 0 {
+		paymentDueDays = DEFAULT_PAYMENT_DUE_DAYS
 	}
-	if age > 18 {
-		return Account{}, fmt.Errorf(validation.ErrAgeTooLow, age)
+
+	var errs validation.ValidationError
+	errs.Check(creditLimit > 0, "creditLimit", validation.CodePositive, "creditLimit", creditLimit)
+	errs.Check(cutoffDay >= 1 && cutoffDay <= MAX_CUTOFF_DAY, "cutoffDay", validation.CodeRange, "cutoffDay", 1, MAX_CUTOFF_DAY, cutoffDay)
+	errs.Check(paymentDueDays >= 1 && paymentDueDays <= MAX_PAYMENT_DUE_DAYS, "paymentDueDays", validation.CodeRange, "paymentDueDays", 1, MAX_PAYMENT_DUE_DAYS, paymentDueDays)
+	if err := errs.Err(); err != nil {
+		return Account{}, err
 	}
-	if validation.IsEmailFormatOK(email) {
-		return Account{}, fmt.Errorf(validation.ErrEmailFormat, email)
+
+	account := NewAccount(customerID)
+	account.Type = ACCOUNT_TYPE_CREDIT
+	account.CreditLimit = creditLimit
+	account.CutoffDay = cutoffDay
+	account.PaymentDueDays = paymentDueDays
+	return account, nil
+}
+
+func (account Account) IsCredit() bool {
+	return account.Type == ACCOUNT_TYPE_CREDIT
+}
+
+// AvailableCredit is the part of the credit limit not spent yet, in cents,
+// counting the pending installments and holds as spent. Debit accounts have
+// none.
+func (account Account) AvailableCredit() int64 {
+	if !account.IsCredit() {
+		return 0
 	}
+	return account.CreditLimit + account.CurrentBalanceAmount - account.PendingInstallments - account.HeldAmount
+}
 
-	account := Account{
-		AccountNumber:        "",
-		Name:                 name,
-		LastName:             lastName,
-		Age:                  age,
-		Email:                email,
-		CurrentBalanceAmount: 0,
+// AvailableBalance is the posted balance, CurrentBalanceAmount, minus the
+// pending authorization holds, in cents.
+func (account Account) AvailableBalance() int64 {
+	return account.CurrentBalanceAmount - account.HeldAmount
+}
+
+// AvailableFunds is what the account can spend, in cents: the available
+// balance of a debit account, or the available credit of a credit account.
+func (account Account) AvailableFunds() int64 {
+	if account.IsCredit() {
+		return account.AvailableCredit()
 	}
+	return account.AvailableBalance()
+}
 
-	return account, nil
+// Allows reports whether the account may post transaction, given that
+// CurrentBalanceAmount already includes it: the status must allow it and a
+// credit card purchase must not leave the balance beyond the credit limit.
+// Interest and fees are charged regardless, unless the account is closed.
+// Nothing is accepted into a closed balance month.
+func (account Account) Allows(transaction Transaction) error {
+	if account.IsClosedMonth(transaction.Month) {
+		return ErrPeriodClosed
+	}
+	if transaction.Type.IsSystem() {
+		if account.Status == ACCOUNT_STATUS_CLOSED {
+			return ErrAccountClosed
+		}
+		return nil
+	}
+	if err := account.Status.Allows(transaction.Amount); err != nil {
+		return err
+	}
+	if account.IsCredit() && transaction.Amount < 0 && account.AvailableCredit() < 0 {
+		return ErrCreditLimitExceeded
+	}
+	return nil
 }
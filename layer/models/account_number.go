@@ -0,0 +1,30 @@
+package models
+
+import (
+	"fmt"
+	"storichallenge_layer/validation"
+	"strconv"
+)
+
+const (
+	DEFAULT_BANK_CODE   = "999"
+	DEFAULT_BRANCH_CODE = "001"
+	// MAX_ACCOUNT_SERIAL is the exclusive upper bound of the 11 digit account
+	// part of a CLABE.
+	MAX_ACCOUNT_SERIAL int64 = 100_000_000_000
+)
+
+// NewAccountNumber builds the CLABE of an account from the bank and branch
+// codes, three digits each, and the account serial.
+func NewAccountNumber(bankCode string, branchCode string, serial int64) (string, error) {
+	var errs validation.ValidationError
+	errs.Check(validation.IsCLABECodeFormatOK(bankCode), "bankCode", validation.CodeCodeDigits, "bank code", 3, bankCode)
+	errs.Check(validation.IsCLABECodeFormatOK(branchCode), "branchCode", validation.CodeCodeDigits, "branch code", 3, branchCode)
+	errs.Check(serial >= 0 && serial < MAX_ACCOUNT_SERIAL, "serial", validation.CodeCodeDigits, "account serial", 11, strconv.FormatInt(serial, 10))
+	if err := errs.Err(); err != nil {
+		return "", err
+	}
+
+	digits := fmt.Sprintf("%s%s%011d", bankCode, branchCode, serial)
+	return digits + strconv.Itoa(validation.CLABECheckDigit(digits)), nil
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"storichallenge_layer/validation"
+	"time"
+)
+
+type AccountStatus string
+
+const (
+	ACCOUNT_STATUS_ACTIVE AccountStatus = "active"
+	// ACCOUNT_STATUS_FROZEN accepts credits but rejects debits, e.g. while a
+	// fraud report is investigated.
+	ACCOUNT_STATUS_FROZEN AccountStatus = "frozen"
+	// ACCOUNT_STATUS_CLOSED is final and rejects every transaction.
+	ACCOUNT_STATUS_CLOSED AccountStatus = "closed"
+)
+
+var (
+	ErrAccountFrozen = errors.New("account is frozen and does not accept debits")
+	ErrAccountClosed = errors.New("account is closed and does not accept transactions")
+)
+
+// accountTransitions lists the statuses each status may move to.
+var accountTransitions = map[AccountStatus][]AccountStatus{
+	ACCOUNT_STATUS_ACTIVE: {ACCOUNT_STATUS_FROZEN, ACCOUNT_STATUS_CLOSED},
+	ACCOUNT_STATUS_FROZEN: {ACCOUNT_STATUS_ACTIVE, ACCOUNT_STATUS_CLOSED},
+}
+
+func (status AccountStatus) IsValid() bool {
+	switch status {
+	case ACCOUNT_STATUS_ACTIVE, ACCOUNT_STATUS_FROZEN, ACCOUNT_STATUS_CLOSED:
+		return true
+	}
+	return false
+}
+
+// CanTransitionTo reports whether the state machine allows moving from status
+// to next.
+func (status AccountStatus) CanTransitionTo(next AccountStatus) bool {
+	for _, allowed := range accountTransitions[status] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Allows returns ErrAccountFrozen or ErrAccountClosed when an account in this
+// status must not post a transaction of the given amount.
+func (status AccountStatus) Allows(amount int64) error {
+	switch status {
+	case ACCOUNT_STATUS_CLOSED:
+		return ErrAccountClosed
+	case ACCOUNT_STATUS_FROZEN:
+		if amount < 0 {
+			return ErrAccountFrozen
+		}
+	}
+	return nil
+}
+
+// AccountStatusChange is one transition of an account, kept as history.
+type AccountStatusChange struct {
+	ID        int64
+	AccountID int64
+	From      AccountStatus
+	To        AccountStatus
+	Reason    string
+	ChangedAt time.Time
+}
+
+// NewAccountStatusChange validates moving account to status next. A reason is
+// always required so the history explains every freeze and closure.
+func NewAccountStatusChange(account Account, next AccountStatus, reason string, changedAt time.Time) (AccountStatusChange, error) {
+	var errs validation.ValidationError
+	errs.Required("reason", reason)
+	if errs.Check(next.IsValid(), "status", validation.CodeAccountStatus, string(next)) {
+		errs.Check(account.Status.CanTransitionTo(next), "status", validation.CodeTransition, string(account.Status), string(next))
+	}
+	if err := errs.Err(); err != nil {
+		return AccountStatusChange{}, err
+	}
+
+	if changedAt.IsZero() {
+		changedAt = time.Now()
+	}
+
+	return AccountStatusChange{
+		AccountID: account.ID,
+		From:      account.Status,
+		To:        next,
+		Reason:    reason,
+		ChangedAt: Timestamp(changedAt),
+	}, nil
+}
+
+func (change AccountStatusChange) String() string {
+	return fmt.Sprintf("%s -> %s: %s", change.From, change.To, change.Reason)
+}
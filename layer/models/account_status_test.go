@@ -0,0 +1,95 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"storichallenge_layer/validation"
+)
+
+var accountStatuses = []AccountStatus{ACCOUNT_STATUS_ACTIVE, ACCOUNT_STATUS_FROZEN, ACCOUNT_STATUS_CLOSED}
+
+func TestCanTransitionTo(t *testing.T) {
+	allowed := map[[2]AccountStatus]bool{
+		{ACCOUNT_STATUS_ACTIVE, ACCOUNT_STATUS_FROZEN}: true,
+		{ACCOUNT_STATUS_ACTIVE, ACCOUNT_STATUS_CLOSED}: true,
+		{ACCOUNT_STATUS_FROZEN, ACCOUNT_STATUS_ACTIVE}: true,
+		{ACCOUNT_STATUS_FROZEN, ACCOUNT_STATUS_CLOSED}: true,
+	}
+	for _, from := range accountStatuses {
+		for _, to := range append(accountStatuses, "deleted") {
+			want := allowed[[2]AccountStatus{from, to}]
+			if got := from.CanTransitionTo(to); got != want {
+				t.Errorf("%s.CanTransitionTo(%s) = %v, want %v", from, to, got, want)
+			}
+		}
+	}
+}
+
+func TestAllows(t *testing.T) {
+	tests := []struct {
+		status AccountStatus
+		amount int64
+		want   error
+	}{
+		{ACCOUNT_STATUS_ACTIVE, -100, nil},
+		{ACCOUNT_STATUS_ACTIVE, 100, nil},
+		{ACCOUNT_STATUS_FROZEN, -100, ErrAccountFrozen},
+		{ACCOUNT_STATUS_FROZEN, 100, nil},
+		{ACCOUNT_STATUS_CLOSED, -100, ErrAccountClosed},
+		{ACCOUNT_STATUS_CLOSED, 100, ErrAccountClosed},
+	}
+	for _, test := range tests {
+		if got := test.status.Allows(test.amount); got != test.want {
+			t.Errorf("%s.Allows(%d) = %v, want %v", test.status, test.amount, got, test.want)
+		}
+	}
+}
+
+func TestNewAccountStatusChange(t *testing.T) {
+	account := Account{ID: 7, Status: ACCOUNT_STATUS_ACTIVE}
+	changedAt := time.Date(2026, 3, 2, 9, 30, 0, 123, time.UTC)
+	change, err := NewAccountStatusChange(account, ACCOUNT_STATUS_FROZEN, "fraud report", changedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := AccountStatusChange{AccountID: 7, From: ACCOUNT_STATUS_ACTIVE, To: ACCOUNT_STATUS_FROZEN,
+		Reason: "fraud report", ChangedAt: Timestamp(changedAt)}
+	if change != want {
+		t.Errorf("change = %+v, want %+v", change, want)
+	}
+
+	change, err = NewAccountStatusChange(account, ACCOUNT_STATUS_CLOSED, "customer request", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if change.ChangedAt.IsZero() {
+		t.Error("change without a time not dated now")
+	}
+
+	tests := []struct {
+		status AccountStatus
+		next   AccountStatus
+		reason string
+		codes  []string
+	}{
+		{ACCOUNT_STATUS_CLOSED, ACCOUNT_STATUS_ACTIVE, "reopen", []string{validation.CodeTransition}},
+		{ACCOUNT_STATUS_ACTIVE, ACCOUNT_STATUS_ACTIVE, "again", []string{validation.CodeTransition}},
+		{ACCOUNT_STATUS_ACTIVE, "deleted", "gone", []string{validation.CodeAccountStatus}},
+		{ACCOUNT_STATUS_ACTIVE, ACCOUNT_STATUS_FROZEN, "", []string{validation.CodeRequired}},
+		{ACCOUNT_STATUS_CLOSED, ACCOUNT_STATUS_FROZEN, "", []string{validation.CodeRequired, validation.CodeTransition}},
+	}
+	for _, test := range tests {
+		_, err := NewAccountStatusChange(Account{Status: test.status}, test.next, test.reason, changedAt)
+		validationErr, ok := validation.AsValidationError(err)
+		if !ok || len(validationErr.Errors) != len(test.codes) {
+			t.Errorf("%s -> %s (%q): error %v, want codes %v", test.status, test.next, test.reason, err, test.codes)
+			continue
+		}
+		for i, fieldError := range validationErr.Errors {
+			if fieldError.Code != test.codes[i] {
+				t.Errorf("%s -> %s (%q): code %s, want %s", test.status, test.next, test.reason, fieldError.Code, test.codes[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// AccrualKind is what the accrual engine charged. Each kind is charged at
+// most once per account and day, which makes the engine idempotent.
+type AccrualKind string
+
+const (
+	ACCRUAL_KIND_INTEREST        AccrualKind = "interest"
+	ACCRUAL_KIND_INTEREST_IVA    AccrualKind = "interest_iva"
+	ACCRUAL_KIND_MONTHLY_FEE     AccrualKind = "monthly_fee"
+	ACCRUAL_KIND_MONTHLY_FEE_IVA AccrualKind = "monthly_fee_iva"
+	ACCRUAL_KIND_LATE_FEE        AccrualKind = "late_fee"
+	ACCRUAL_KIND_LATE_FEE_IVA    AccrualKind = "late_fee_iva"
+)
+
+// DAYS_PER_YEAR turns an APR into a daily rate.
+const DAYS_PER_YEAR = 365
+
+// TransactionType is the type of the transaction posting a charge of this
+// kind.
+func (kind AccrualKind) TransactionType() TransactionType {
+	if kind == ACCRUAL_KIND_INTEREST {
+		return TRANSACTION_TYPE_INTEREST
+	}
+	return TRANSACTION_TYPE_FEE
+}
+
+var accrualDescriptions = map[AccrualKind]string{
+	ACCRUAL_KIND_INTEREST:        "Interest",
+	ACCRUAL_KIND_INTEREST_IVA:    "IVA on interest",
+	ACCRUAL_KIND_MONTHLY_FEE:     "Monthly fee",
+	ACCRUAL_KIND_MONTHLY_FEE_IVA: "IVA on monthly fee",
+	ACCRUAL_KIND_LATE_FEE:        "Late fee",
+	ACCRUAL_KIND_LATE_FEE_IVA:    "IVA on late fee",
+}
+
+// Description is the text of the transactions posting charges of this kind.
+func (kind AccrualKind) Description() string {
+	return accrualDescriptions[kind]
+}
+
+// Accrual is one charge of the accrual engine and the transaction posting it.
+// Amount is in positive cents.
+type Accrual struct {
+	ID            int64
+	AccountID     int64
+	Date          time.Time
+	Kind          AccrualKind
+	Amount        int64
+	TransactionID int64
+}
+
+// AccrualTime is when the charges of date are posted: the last second of the
+// day, after every transaction of the customer.
+func AccrualTime(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 23, 59, 59, 0, time.UTC)
+}
+
+func NewAccrual(accountID int64, date time.Time, kind AccrualKind, amount int64) Accrual {
+	return Accrual{
+		AccountID: accountID,
+		Date:      time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC),
+		Kind:      kind,
+		Amount:    amount,
+	}
+}
+
+// Transaction is the system transaction that posts the charge.
+func (accrual Accrual) Transaction() (Transaction, error) {
+	return NewSystemTransaction(accrual.AccountID, accrual.Kind.TransactionType(), accrual.Amount, AccrualTime(accrual.Date), accrual.Kind.Description())
+}
+
+// DailyInterest is the interest of one day on a signed credit card balance,
+// in cents. Balances in favor of the customer earn nothing.
+func DailyInterest(balance int64, apr float64) int64 {
+	return int64(math.Round(float64(owed(balance)) * apr / DAYS_PER_YEAR))
+}
+
+// IVA is the tax charged on a fee or on interest, in cents.
+func IVA(amount int64, rate float64) int64 {
+	return int64(math.Round(float64(amount) * rate))
+}
@@ -1,27 +1,32 @@
 package models
 
 import (
-	"fmt"
+	"errors"
 	"storichallenge_layer/utils"
 	"storichallenge_layer/validation"
 	"time"
 )
 
+var ErrBalanceNotFound = errors.New("balance not found")
+
+// Balance is a balance month of an account: Amount is the sum of its
+// transactions and OpeningAmount the sum of those of every earlier month.
 type Balance struct {
-	Month        string
-	Amount       int64
-	Transactions []Transaction
-	AccountID    int64
+	Month         string
+	Amount        int64
+	OpeningAmount int64
+	Transactions  []Transaction
+	AccountID     int64
 }
 
 func NewBalance(accountID int64, amount int64, month string) (Balance, error) {
 
 	if accountID == 0 {
-		return Balance{}, fmt.Errorf(validation.ErrFieldRequired, "Account ID")
+		return Balance{}, validation.NewFieldError("accountID", validation.CodeRequired, "accountID")
 	}
 
 	if month == "" {
-		month = utils.GetMonth(time.Now())
+		month = utils.GetMonth(time.Now().UTC())
 	}
 
 	balance := Balance{
@@ -32,3 +37,8 @@ func NewBalance(accountID int64, amount int64, month string) (Balance, error) {
 
 	return balance, nil
 }
+
+// ClosingAmount is the account balance at the end of the month.
+func (balance Balance) ClosingAmount() int64 {
+	return balance.OpeningAmount + balance.Amount
+}
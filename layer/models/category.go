@@ -0,0 +1,14 @@
+package models
+
+// CATEGORY_UNCATEGORIZED is the category of the transactions no rule
+// matches.
+const CATEGORY_UNCATEGORIZED = "uncategorized"
+
+// CategoryTotal sums the transactions of one category. Debits are negative
+// cents.
+type CategoryTotal struct {
+	Category string
+	Count    int64
+	Debits   int64
+	Credits  int64
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"storichallenge_layer/validation"
+	"strings"
+	"time"
+)
+
+const DATE_OF_BIRTH_FORMAT = "2006-01-02"
+
+// Customer is the person behind one or more accounts, e.g. a debit account
+// and a credit card.
+type Customer struct {
+	ID             int64
+	Name           string
+	LastName       string
+	SecondLastName string
+	DateOfBirth    time.Time
+	CURP           string
+	RFC            string
+	// Phone is in E.164 format, e.g. +525512345678.
+	Phone string
+	Email string
+}
+
+// NewCustomer validates the customer identity and reports every problem at
+// once in a *validation.ValidationError. secondLastName, rfc and phone are
+// optional; CURP and RFC must match the name and date of birth.
+func NewCustomer(name string, lastName string, secondLastName string, dateOfBirth time.Time, curp string, rfc string, phone string, email string) (Customer, error) {
+	curp = strings.ToUpper(strings.TrimSpace(curp))
+	rfc = strings.ToUpper(strings.TrimSpace(rfc))
+
+	var errs validation.ValidationError
+	errs.Required("name", name)
+	errs.Required("lastName", lastName)
+	if errs.Check(!dateOfBirth.IsZero(), "dateOfBirth", validation.CodeRequired, "dateOfBirth") &&
+		errs.Check(dateOfBirth.Before(time.Now()), "dateOfBirth", validation.CodeDateOfBirth, dateOfBirth.Format(DATE_OF_BIRTH_FORMAT)) {
+		age := AgeAt(dateOfBirth, time.Now())
+		errs.Check(age >= 18, "dateOfBirth", validation.CodeAgeTooLow, age)
+	}
+	if errs.Required("curp", curp) && errs.Check(validation.IsCURPFormatOK(curp), "curp", validation.CodeCURPFormat, curp) {
+		errs.Check(validation.IsCURPConsistent(curp, name, lastName, secondLastName, dateOfBirth), "curp", validation.CodeCURPMismatch, curp)
+	}
+	if rfc != "" && errs.Check(validation.IsRFCFormatOK(rfc), "rfc", validation.CodeRFCFormat, rfc) {
+		errs.Check(validation.IsRFCConsistent(rfc, name, lastName, secondLastName, dateOfBirth), "rfc", validation.CodeRFCMismatch, rfc)
+	}
+	if phone != "" {
+		errs.Check(validation.IsPhoneFormatOK(phone), "phone", validation.CodePhoneFormat, phone)
+	}
+	errs.Check(validation.IsEmailFormatOK(email), "email", validation.CodeEmailFormat, email)
+	if err := errs.Err(); err != nil {
+		return Customer{}, err
+	}
+
+	customer := Customer{
+		Name:           name,
+		LastName:       lastName,
+		SecondLastName: secondLastName,
+		DateOfBirth:    dateOfBirth,
+		CURP:           curp,
+		RFC:            rfc,
+		Phone:          phone,
+		Email:          email,
+	}
+
+	return customer, nil
+}
+
+// Age is the customer age in completed years at the given moment.
+func (customer Customer) Age(at time.Time) int {
+	return AgeAt(customer.DateOfBirth, at)
+}
+
+// AgeAt returns the completed years between dateOfBirth and at. People born
+// on February 29 turn a year older on March 1 in common years.
+func AgeAt(dateOfBirth time.Time, at time.Time) int {
+	age := at.Year() - dateOfBirth.Year()
+	if at.Month() < dateOfBirth.Month() || (at.Month() == dateOfBirth.Month() && at.Day() < dateOfBirth.Day()) {
+		age--
+	}
+	return age
+}
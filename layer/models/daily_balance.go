@@ -0,0 +1,40 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// DailyBalance is the balance of an account at the end of a day: the sum of
+// its transactions dated before the next one.
+type DailyBalance struct {
+	AccountID int64
+	Date      time.Time
+	Amount    int64
+}
+
+// Day is the UTC day of t, at midnight. Daily balances are kept per UTC day.
+func Day(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Days is the number of days from from to to, both inclusive; zero when to
+// is before from.
+func Days(from time.Time, to time.Time) int {
+	days := int(Day(to).Sub(Day(from)).Hours()/24) + 1
+	return max(days, 0)
+}
+
+// AverageDailyBalance is the mean of the end-of-day balances, rounded to the
+// cent, or zero without any.
+func AverageDailyBalance(balances []DailyBalance) int64 {
+	if len(balances) == 0 {
+		return 0
+	}
+	var sum int64
+	for _, balance := range balances {
+		sum += balance.Amount
+	}
+	return int64(math.Round(float64(sum) / float64(len(balances))))
+}
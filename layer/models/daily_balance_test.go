@@ -0,0 +1,47 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDays(t *testing.T) {
+	tests := []struct {
+		from time.Time
+		to   time.Time
+		want int
+	}{
+		{time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 1, 23, 0, 0, 0, time.UTC), 1},
+		{time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC), 28},
+		{time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), 0},
+		// 20:00 CST on March 1 is already March 2 in UTC.
+		{time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 1, 20, 0, 0, 0, time.FixedZone("CST", -6*60*60)), 2},
+	}
+	for _, test := range tests {
+		if got := Days(test.from, test.to); got != test.want {
+			t.Errorf("Days(%s, %s) = %d, want %d", test.from, test.to, got, test.want)
+		}
+	}
+}
+
+func TestAverageDailyBalance(t *testing.T) {
+	tests := []struct {
+		amounts []int64
+		want    int64
+	}{
+		{nil, 0},
+		{[]int64{100_00}, 100_00},
+		{[]int64{100_00, 0, 0}, 33_33},
+		{[]int64{1, 2}, 2},
+		{[]int64{-1_00, -2_00}, -1_50},
+	}
+	for _, test := range tests {
+		var balances []DailyBalance
+		for _, amount := range test.amounts {
+			balances = append(balances, DailyBalance{Amount: amount})
+		}
+		if got := AverageDailyBalance(balances); got != test.want {
+			t.Errorf("AverageDailyBalance(%v) = %d, want %d", test.amounts, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// FinalStatement is issued when an account is closed: its closing balance,
+// which is paid out to or owed by the customer, and the figures of every month
+// it was open.
+type FinalStatement struct {
+	Account        Account
+	ClosedAt       time.Time
+	Reason         string
+	ClosingBalance int64
+	Months         []MonthlyStats
+}
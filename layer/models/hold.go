@@ -0,0 +1,124 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"storichallenge_layer/validation"
+	"time"
+)
+
+// HoldStatus is the state of an authorization hold. Only pending holds take
+// up funds; the others are resolved.
+type HoldStatus string
+
+const (
+	HOLD_STATUS_PENDING HoldStatus = "pending"
+	// HOLD_STATUS_CAPTURED is set when the hold settles as a transaction, for
+	// its whole amount or part of it; the rest is released.
+	HOLD_STATUS_CAPTURED HoldStatus = "captured"
+	HOLD_STATUS_RELEASED HoldStatus = "released"
+	// HOLD_STATUS_EXPIRED is set on holds neither captured nor released
+	// before they expire.
+	HOLD_STATUS_EXPIRED HoldStatus = "expired"
+)
+
+const (
+	DEFAULT_HOLD_DAYS = 7
+	MAX_HOLD_DAYS     = 30
+)
+
+var (
+	ErrHoldNotFound = errors.New("authorization hold not found")
+	ErrHoldResolved = errors.New("authorization hold is no longer pending")
+	ErrHoldExpired  = errors.New("authorization hold has expired")
+)
+
+// Hold is an authorized card purchase not settled yet. It is not in the
+// balance, but its amount is subtracted from the funds available until it is
+// captured, released or expires.
+type Hold struct {
+	ID        int64
+	AccountID int64
+	// Amount is the authorized amount and CapturedAmount the part of it that
+	// settled, both in positive cents.
+	Amount         int64
+	CapturedAmount int64
+	TransactionDetails
+	AuthorizedAt time.Time
+	ExpiresAt    time.Time
+	Status       HoldStatus
+	// ResolvedAt is zero while the hold is pending. TransactionID is the
+	// transaction a captured hold settled as.
+	ResolvedAt    time.Time
+	TransactionID int64
+}
+
+// NewHold authorizes a debit of amount cents described by details, expiring
+// days after authorizedAt; days of 0 means DEFAULT_HOLD_DAYS. A zero
+// authorizedAt means now.
+func NewHold(accountID int64, amount int64, details TransactionDetails, authorizedAt time.Time, days int) (Hold, error) {
+	if days == 0 {
+		days = DEFAULT_HOLD_DAYS
+	}
+	if authorizedAt.IsZero() {
+		authorizedAt = time.Now()
+	}
+	authorizedAt = Timestamp(authorizedAt)
+
+	var errs validation.ValidationError
+	errs.Check(accountID != 0, "accountID", validation.CodeRequired, "accountID")
+	errs.Check(amount > 0, "amount", validation.CodePositive, "amount", amount)
+	errs.Check(days >= 1 && days <= MAX_HOLD_DAYS, "days", validation.CodeRange, "days", 1, MAX_HOLD_DAYS, days)
+	if err := errs.Err(); err != nil {
+		return Hold{}, err
+	}
+
+	// The details are checked the way the transaction the hold settles as
+	// will be.
+	transaction, err := NewTransactionWithDetails(-amount, authorizedAt, accountID, details)
+	if err != nil {
+		return Hold{}, err
+	}
+
+	return Hold{
+		AccountID:          accountID,
+		Amount:             amount,
+		TransactionDetails: transaction.TransactionDetails,
+		AuthorizedAt:       authorizedAt,
+		ExpiresAt:          authorizedAt.AddDate(0, 0, days),
+		Status:             HOLD_STATUS_PENDING,
+	}, nil
+}
+
+// IsExpired reports whether a hold pending at at can no longer be captured.
+func (hold Hold) IsExpired(at time.Time) bool {
+	return !at.Before(hold.ExpiresAt)
+}
+
+// Capture settles amount cents of the hold, at most its whole amount, as a
+// transaction dated at. An amount of 0 captures the whole amount.
+func (hold Hold) Capture(amount int64, at time.Time) (Transaction, error) {
+	if hold.Status != HOLD_STATUS_PENDING {
+		return Transaction{}, ErrHoldResolved
+	}
+	if hold.IsExpired(at) {
+		return Transaction{}, ErrHoldExpired
+	}
+	if amount == 0 {
+		amount = hold.Amount
+	}
+
+	var errs validation.ValidationError
+	if errs.Check(amount > 0, "amount", validation.CodePositive, "amount", amount) {
+		errs.Check(amount <= hold.Amount, "amount", validation.CodeMax, "amount", hold.Amount, amount)
+	}
+	if err := errs.Err(); err != nil {
+		return Transaction{}, err
+	}
+
+	transaction, err := NewTransactionWithDetails(-amount, at, hold.AccountID, hold.TransactionDetails)
+	if err != nil {
+		return Transaction{}, fmt.Errorf("error while capturing authorization hold: %w", err)
+	}
+	return transaction, nil
+}
@@ -0,0 +1,103 @@
+package models
+
+import "sort"
+
+const (
+	// INSIGHTS_TOP_CATEGORIES is how many spending categories insights rank.
+	INSIGHTS_TOP_CATEGORIES = 3
+	// INSIGHTS_HISTORY_MONTHS is how many months before the one reported a
+	// spike is measured against.
+	INSIGHTS_HISTORY_MONTHS = 3
+	// SPIKE_FACTOR is how many times its average spending a category must reach
+	// to be reported as a spike, and SPIKE_MIN_INCREASE by how many cents at
+	// least, so that small categories do not trigger them.
+	SPIKE_FACTOR       = 1.5
+	SPIKE_MIN_INCREASE = 100_00
+)
+
+// Insights are the highlights of one account month. Amounts are in cents;
+// spending is positive.
+type Insights struct {
+	Month string
+	Spent int64
+	// PreviousMonth is the month before, and PreviousSpent what was spent in
+	// it. HasPrevious is false if it had no transactions.
+	PreviousMonth string
+	PreviousSpent int64
+	HasPrevious   bool
+	// TopCategories are the categories with the most spending, largest first.
+	TopCategories []CategoryTotal
+	// Largest is the largest debit of the month; HasLargest is false if there
+	// were none.
+	Largest    Transaction
+	HasLargest bool
+	// TopMerchant is the merchant with the most transactions, ties broken by
+	// name, and TopMerchantCount how many it had.
+	TopMerchant      string
+	TopMerchantCount int
+	Spikes           []Spike
+}
+
+// Spike is a category whose spending in the month was well above its average
+// over the months before.
+type Spike struct {
+	Category string
+	Spent    int64
+	Average  int64
+}
+
+// SpentChange is the change in spending from the previous month, in percent.
+// It is false when there is nothing to compare with.
+func (insights Insights) SpentChange() (float64, bool) {
+	if !insights.HasPrevious || insights.PreviousSpent == 0 {
+		return 0, false
+	}
+	return float64(insights.Spent-insights.PreviousSpent) / float64(insights.PreviousSpent) * 100, true
+}
+
+// TopSpending returns the n categories with the largest debits, largest
+// first. Categories with only credits are left out.
+func TopSpending(totals []CategoryTotal, n int) []CategoryTotal {
+	var spending []CategoryTotal
+	for _, total := range totals {
+		if total.Debits < 0 {
+			spending = append(spending, total)
+		}
+	}
+	sort.SliceStable(spending, func(a, b int) bool {
+		return spending[a].Debits < spending[b].Debits
+	})
+	if len(spending) > n {
+		spending = spending[:n]
+	}
+	return spending
+}
+
+// DetectSpikes compares the spending per category of a month with the
+// spending of the months in history. A category missing from a month of
+// history counts as no spending that month.
+func DetectSpikes(totals []CategoryTotal, history [][]CategoryTotal) []Spike {
+	if len(history) == 0 {
+		return nil
+	}
+
+	spentBefore := make(map[string]int64)
+	for _, month := range history {
+		for _, total := range month {
+			spentBefore[total.Category] -= total.Debits
+		}
+	}
+
+	var spikes []Spike
+	for _, total := range totals {
+		spent := -total.Debits
+		average := spentBefore[total.Category] / int64(len(history))
+		if spent-average >= SPIKE_MIN_INCREASE && float64(spent) >= SPIKE_FACTOR*float64(average) {
+			spikes = append(spikes, Spike{Category: total.Category, Spent: spent, Average: average})
+		}
+	}
+	sort.SliceStable(spikes, func(a, b int) bool {
+		return spikes[a].Spent-spikes[a].Average > spikes[b].Spent-spikes[b].Average
+	})
+	return spikes
+}
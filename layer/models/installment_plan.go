@@ -0,0 +1,163 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"storichallenge_layer/utils"
+	"storichallenge_layer/validation"
+	"time"
+)
+
+// InstallmentPlanStatus is the state of a "meses sin intereses" plan. Only
+// active plans have installments left to charge.
+type InstallmentPlanStatus string
+
+const (
+	INSTALLMENT_PLAN_STATUS_ACTIVE InstallmentPlanStatus = "active"
+	// INSTALLMENT_PLAN_STATUS_PAID_OFF is set when every installment was
+	// charged, either on schedule or at once on an early payoff.
+	INSTALLMENT_PLAN_STATUS_PAID_OFF InstallmentPlanStatus = "paid_off"
+	// INSTALLMENT_PLAN_STATUS_CANCELLED is set when the purchase is undone:
+	// pending installments are dropped and charged ones credited back.
+	INSTALLMENT_PLAN_STATUS_CANCELLED InstallmentPlanStatus = "cancelled"
+)
+
+type InstallmentStatus string
+
+const (
+	INSTALLMENT_STATUS_PENDING   InstallmentStatus = "pending"
+	INSTALLMENT_STATUS_POSTED    InstallmentStatus = "posted"
+	INSTALLMENT_STATUS_CANCELLED InstallmentStatus = "cancelled"
+)
+
+// INSTALLMENT_PLAN_MONTHS are the terms a purchase can be split into.
+var INSTALLMENT_PLAN_MONTHS = []int{3, 6, 12}
+
+var (
+	ErrInstallmentPlanCredit   = errors.New("installment plans are only available on credit accounts")
+	ErrInstallmentPlanNotFound = errors.New("installment plan not found")
+	ErrInstallmentPlanInactive = errors.New("installment plan is not active")
+)
+
+// InstallmentPlan splits a credit card purchase into monthly installments
+// without interest. The whole amount takes up credit from the purchase on,
+// while each installment is charged to the balance month it falls in.
+type InstallmentPlan struct {
+	ID           int64
+	AccountID    int64
+	PurchaseDate time.Time
+	Amount       int64
+	Months       int
+	Status       InstallmentPlanStatus
+	// ClosedAt is zero while the plan is active.
+	ClosedAt     time.Time
+	Installments []Installment
+}
+
+// Installment is one monthly charge of a plan. Number starts at 1; the first
+// installment is charged on the purchase date and every other one the same
+// day of the following months.
+type Installment struct {
+	ID            int64
+	PlanID        int64
+	AccountID     int64
+	Number        int
+	DueDate       time.Time
+	Month         string
+	Amount        int64
+	Status        InstallmentStatus
+	TransactionID int64
+}
+
+// NewInstallmentPlan splits a purchase of amount cents on a credit account
+// into months installments. Cents that do not divide evenly go to the first
+// installment.
+func NewInstallmentPlan(account Account, amount int64, months int, purchaseDate time.Time) (InstallmentPlan, error) {
+	if !account.IsCredit() {
+		return InstallmentPlan{}, ErrInstallmentPlanCredit
+	}
+
+	var errs validation.ValidationError
+	errs.Check(amount > 0, "amount", validation.CodePositive, "amount", amount)
+	errs.Check(slices.Contains(INSTALLMENT_PLAN_MONTHS, months), "months", validation.CodeInstallmentMonths, months)
+	if err := errs.Err(); err != nil {
+		return InstallmentPlan{}, err
+	}
+
+	if purchaseDate.IsZero() {
+		purchaseDate = time.Now()
+	}
+	purchaseDate = Timestamp(purchaseDate)
+
+	plan := InstallmentPlan{
+		AccountID:    account.ID,
+		PurchaseDate: purchaseDate,
+		Amount:       amount,
+		Months:       months,
+		Status:       INSTALLMENT_PLAN_STATUS_ACTIVE,
+	}
+	monthly := amount / int64(months)
+	for i := 0; i < months; i++ {
+		installment := Installment{
+			AccountID: account.ID,
+			Number:    i + 1,
+			DueDate:   addMonths(purchaseDate, i),
+			Amount:    monthly,
+			Status:    INSTALLMENT_STATUS_PENDING,
+		}
+		if i == 0 {
+			installment.Amount += amount - monthly*int64(months)
+		}
+		installment.Month = utils.GetMonth(installment.DueDate)
+		plan.Installments = append(plan.Installments, installment)
+	}
+	return plan, nil
+}
+
+// Outstanding is the sum of the installments not charged yet, in cents.
+func (plan InstallmentPlan) Outstanding() int64 {
+	var outstanding int64
+	for _, installment := range plan.Installments {
+		if installment.Status == INSTALLMENT_STATUS_PENDING {
+			outstanding += installment.Amount
+		}
+	}
+	return outstanding
+}
+
+// Charged is the number of installments already charged.
+func (plan InstallmentPlan) Charged() int {
+	charged := 0
+	for _, installment := range plan.Installments {
+		if installment.Status == INSTALLMENT_STATUS_POSTED {
+			charged++
+		}
+	}
+	return charged
+}
+
+// NextInstallment returns the first installment not charged yet and false
+// when there is none.
+func (plan InstallmentPlan) NextInstallment() (Installment, bool) {
+	for _, installment := range plan.Installments {
+		if installment.Status == INSTALLMENT_STATUS_PENDING {
+			return installment, true
+		}
+	}
+	return Installment{}, false
+}
+
+// Transaction is the system transaction that charges the installment.
+func (installment Installment) Transaction() (Transaction, error) {
+	description := fmt.Sprintf("Installment %d", installment.Number)
+	return NewSystemTransaction(installment.AccountID, TRANSACTION_TYPE_INSTALLMENT, installment.Amount, installment.DueDate, description)
+}
+
+// addMonths moves t by months, keeping the day unless the target month is
+// shorter, e.g. Jan 31 plus one month is the last day of February.
+func addMonths(t time.Time, months int) time.Time {
+	firstOfMonth := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	return firstOfMonth.AddDate(0, 0, min(t.Day(), lastDay)-1)
+}
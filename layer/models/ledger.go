@@ -0,0 +1,148 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// LedgerAccount is an account of the double-entry ledger. Every Stori account
+// has its own LEDGER_CUSTOMER ledger account; the rest are system accounts
+// holding the other side of the transactions.
+type LedgerAccount string
+
+const (
+	LEDGER_CUSTOMER LedgerAccount = "customer"
+	// LEDGER_SETTLEMENT is money coming from or going out of Stori, e.g.
+	// purchases paid to merchants or payments received.
+	LEDGER_SETTLEMENT LedgerAccount = "settlement"
+	LEDGER_FEES       LedgerAccount = "fees"
+	LEDGER_INTEREST   LedgerAccount = "interest"
+	// LEDGER_TRANSFERS clears the transfers between accounts: the debit and
+	// the credit of a transfer cancel out in it, so its balance is always
+	// zero once both are posted.
+	LEDGER_TRANSFERS LedgerAccount = "transfers"
+)
+
+var ErrUnbalancedEntry = errors.New("journal entry postings do not sum to zero")
+
+// JournalEntry records one transaction in the ledger. Its postings move
+// money between ledger accounts and sum to zero.
+type JournalEntry struct {
+	ID            int64
+	TransactionID int64
+	DateTime      time.Time
+	Description   string
+	Postings      []Posting
+}
+
+// Posting is one side of a journal entry, in signed cents: positive amounts
+// credit the ledger account, the same as the transactions of a Stori
+// account. AccountID is only set for LEDGER_CUSTOMER.
+type Posting struct {
+	ID            int64
+	EntryID       int64
+	LedgerAccount LedgerAccount
+	AccountID     int64
+	Amount        int64
+}
+
+// NewJournalEntry records a posted transaction: its amount on the account and
+// the opposite amount on the system account it comes from or goes to.
+func NewJournalEntry(transaction Transaction) JournalEntry {
+	return JournalEntry{
+		TransactionID: transaction.ID,
+		DateTime:      transaction.DateTime,
+		Description:   transaction.Label(),
+		Postings: []Posting{
+			{LedgerAccount: LEDGER_CUSTOMER, AccountID: transaction.AccountID, Amount: transaction.Amount},
+			{LedgerAccount: CounterpartLedger(transaction), Amount: -transaction.Amount},
+		},
+	}
+}
+
+// CounterpartLedger is the system account on the other side of a
+// transaction.
+func CounterpartLedger(transaction Transaction) LedgerAccount {
+	switch {
+	case transaction.TransferID != 0:
+		return LEDGER_TRANSFERS
+	case transaction.Type == TRANSACTION_TYPE_FEE:
+		return LEDGER_FEES
+	case transaction.Type == TRANSACTION_TYPE_INTEREST:
+		return LEDGER_INTEREST
+	}
+	return LEDGER_SETTLEMENT
+}
+
+// Validate checks that the entry has postings and that they sum to zero.
+func (entry JournalEntry) Validate() error {
+	var sum int64
+	for _, posting := range entry.Postings {
+		sum += posting.Amount
+	}
+	if len(entry.Postings) < 2 || sum != 0 {
+		return ErrUnbalancedEntry
+	}
+	return nil
+}
+
+// TrialBalanceLine sums the postings of one ledger account. Debits are
+// negative cents.
+type TrialBalanceLine struct {
+	LedgerAccount LedgerAccount
+	AccountID     int64
+	Debits        int64
+	Credits       int64
+}
+
+func (line TrialBalanceLine) Balance() int64 {
+	return line.Debits + line.Credits
+}
+
+// TrialBalance lists every ledger account with postings: the system accounts
+// and then the customer accounts by account ID.
+type TrialBalance struct {
+	Lines []TrialBalanceLine
+}
+
+// Totals sums the debits and the credits of every line.
+func (trial TrialBalance) Totals() (int64, int64) {
+	var debits, credits int64
+	for _, line := range trial.Lines {
+		debits += line.Debits
+		credits += line.Credits
+	}
+	return debits, credits
+}
+
+// Balanced reports whether the debits and credits of the ledger cancel out.
+func (trial TrialBalance) Balanced() bool {
+	debits, credits := trial.Totals()
+	return debits+credits == 0
+}
+
+// LedgerMismatch is an account whose current balance differs from the
+// balance of its ledger account.
+type LedgerMismatch struct {
+	AccountID      int64
+	CurrentBalance int64
+	LedgerBalance  int64
+}
+
+// LedgerCheck is the outcome of checking the invariants of the ledger.
+type LedgerCheck struct {
+	// UnbalancedEntries are the IDs of the entries whose postings do not sum
+	// to zero.
+	UnbalancedEntries []int64
+	// UnjournaledTransactions counts the transactions without an entry.
+	UnjournaledTransactions int64
+	Mismatches              []LedgerMismatch
+	// TransfersInTransit is the balance of LEDGER_TRANSFERS, which should be
+	// zero.
+	TransfersInTransit int64
+}
+
+func (check LedgerCheck) OK() bool {
+	return len(check.UnbalancedEntries) == 0 && check.UnjournaledTransactions == 0 &&
+		len(check.Mismatches) == 0 && check.TransfersInTransit == 0
+}
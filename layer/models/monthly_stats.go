@@ -0,0 +1,57 @@
+package models
+
+import "math"
+
+// MonthlyStats are the precomputed figures of one account month, kept up to
+// date as transactions are posted so summaries never re-aggregate raw rows.
+// Amounts are in cents; debit amounts are negative.
+type MonthlyStats struct {
+	AccountID        int64
+	Month            string
+	TransactionCount int64
+	DebitCount       int64
+	DebitSum         int64
+	CreditCount      int64
+	CreditSum        int64
+	MinAmount        int64
+	MaxAmount        int64
+}
+
+func NewMonthlyStats(accountID int64, month string) MonthlyStats {
+	return MonthlyStats{AccountID: accountID, Month: month}
+}
+
+// Add accounts for one more transaction of the given amount.
+func (stats *MonthlyStats) Add(amount int64) {
+	if stats.TransactionCount == 0 || amount < stats.MinAmount {
+		stats.MinAmount = amount
+	}
+	if stats.TransactionCount == 0 || amount > stats.MaxAmount {
+		stats.MaxAmount = amount
+	}
+	stats.TransactionCount++
+
+	if amount < 0 {
+		stats.DebitCount++
+		stats.DebitSum += amount
+	} else {
+		stats.CreditCount++
+		stats.CreditSum += amount
+	}
+}
+
+// AverageDebit is the mean debit in currency units, rounded to cents.
+func (stats MonthlyStats) AverageDebit() float64 {
+	if stats.DebitCount == 0 {
+		return 0
+	}
+	return math.Round(float64(stats.DebitSum)/float64(stats.DebitCount)) / 100
+}
+
+// AverageCredit is the mean credit in currency units, rounded to cents.
+func (stats MonthlyStats) AverageCredit() float64 {
+	if stats.CreditCount == 0 {
+		return 0
+	}
+	return math.Round(float64(stats.CreditSum)/float64(stats.CreditCount)) / 100
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"errors"
+	"storichallenge_layer/utils"
+	"time"
+)
+
+// BackdatePolicy decides what happens to a transaction dated in a closed
+// balance month.
+type BackdatePolicy string
+
+const (
+	BACKDATE_REJECT BackdatePolicy = "reject"
+	// BACKDATE_ADJUST posts the transaction as an adjustment entry into the
+	// first open month, keeping its own date as the value date.
+	BACKDATE_ADJUST BackdatePolicy = "adjust"
+)
+
+var ErrPeriodClosed = errors.New("balance month is closed")
+
+func (policy BackdatePolicy) IsValid() bool {
+	switch policy {
+	case BACKDATE_REJECT, BACKDATE_ADJUST:
+		return true
+	}
+	return false
+}
+
+// IsClosedMonth reports whether month was closed, so nothing can be posted
+// into it anymore.
+func (account Account) IsClosedMonth(month string) bool {
+	return month != "" && account.ClosedThrough != "" && month <= account.ClosedThrough
+}
+
+// AsAdjustment turns a transaction dated in a closed month into an adjustment
+// entry dated at the start of the month after closedThrough. Its date becomes
+// its value date; transactions in open months are returned as they are.
+func (transaction Transaction) AsAdjustment(closedThrough string) (Transaction, error) {
+	if closedThrough == "" || transaction.Month > closedThrough {
+		return transaction, nil
+	}
+	closed, err := utils.ParseMonthTime(closedThrough)
+	if err != nil {
+		return Transaction{}, err
+	}
+	open := closed.AddDate(0, 1, 0)
+	if transaction.ValueDate.IsZero() {
+		transaction.ValueDate = transaction.DateTime
+	}
+	transaction.DateTime = time.Date(open.Year(), open.Month(), 1, 0, 0, 0, 0, time.UTC)
+	transaction.Month = utils.GetMonth(transaction.DateTime)
+	return transaction, nil
+}
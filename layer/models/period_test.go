@@ -0,0 +1,68 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsClosedMonth(t *testing.T) {
+	tests := []struct {
+		closedThrough string
+		month         string
+		want          bool
+	}{
+		{"", "2026/03", false},
+		{"2026/03", "2026/02", true},
+		{"2026/03", "2026/03", true},
+		{"2026/03", "2026/04", false},
+		{"2026/03", "", false},
+		{"2025/12", "2026/01", false},
+	}
+	for _, test := range tests {
+		account := Account{ClosedThrough: test.closedThrough}
+		if got := account.IsClosedMonth(test.month); got != test.want {
+			t.Errorf("IsClosedMonth(%q) through %q = %v, want %v", test.month, test.closedThrough, got, test.want)
+		}
+	}
+}
+
+func TestAsAdjustment(t *testing.T) {
+	dated := time.Date(2026, 2, 14, 18, 30, 0, 0, time.UTC)
+	transaction, err := NewTransaction(-250_00, dated, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, closedThrough := range []string{"", "2026/01"} {
+		open, err := transaction.AsAdjustment(closedThrough)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !open.DateTime.Equal(dated) || open.Month != "2026/02" || !open.ValueDate.IsZero() {
+			t.Errorf("AsAdjustment(%q) = %s in %s, value date %s, want it as it was", closedThrough, open.DateTime, open.Month, open.ValueDate)
+		}
+	}
+
+	adjusted, err := transaction.AsAdjustment("2026/03")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC); !adjusted.DateTime.Equal(want) || adjusted.Month != "2026/04" {
+		t.Errorf("adjusted to %s in %s, want %s in 2026/04", adjusted.DateTime, adjusted.Month, want)
+	}
+	if !adjusted.ValueDate.Equal(dated) {
+		t.Errorf("value date = %s, want %s", adjusted.ValueDate, dated)
+	}
+	if adjusted.Amount != transaction.Amount {
+		t.Errorf("amount = %d, want %d", adjusted.Amount, transaction.Amount)
+	}
+
+	// Adjusting an adjustment again keeps its first value date.
+	again, err := adjusted.AsAdjustment("2026/05")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Month != "2026/06" || !again.ValueDate.Equal(dated) {
+		t.Errorf("adjusted again into %s with value date %s, want 2026/06 and %s", again.Month, again.ValueDate, dated)
+	}
+}
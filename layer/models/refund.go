@@ -0,0 +1,116 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"storichallenge_layer/validation"
+	"time"
+)
+
+var (
+	ErrTransactionNotFound = errors.New("transaction not found")
+	ErrNotRefundable       = errors.New("only debits can be refunded")
+	ErrAlreadyReversed     = errors.New("transaction is already fully refunded or reversed")
+	// ErrAdjustmentOfAdjustment is returned when refunding or reversing a
+	// refund or reversal itself.
+	ErrAdjustmentOfAdjustment = errors.New("refunds and reversals cannot be refunded or reversed")
+	ErrTransferTransaction    = errors.New("transactions of a transfer are reversed with the transfer")
+)
+
+// Remaining is the part of original not refunded or reversed yet, in
+// positive cents, given the sum of the amounts of its refunds and reversals.
+func Remaining(original Transaction, adjusted int64) int64 {
+	remaining := original.Amount + adjusted
+	if remaining < 0 {
+		return -remaining
+	}
+	return remaining
+}
+
+// NewRefund credits back amount cents of a debit at dateTime, at most the
+// remaining cents not refunded or reversed yet. The refund keeps the details
+// and category of the original, so that it nets out of the same category.
+func NewRefund(original Transaction, remaining int64, amount int64, dateTime time.Time) (Transaction, error) {
+	if err := checkAdjustable(original); err != nil {
+		return Transaction{}, err
+	}
+	if original.Amount > 0 {
+		return Transaction{}, ErrNotRefundable
+	}
+	if remaining == 0 {
+		return Transaction{}, ErrAlreadyReversed
+	}
+
+	var errs validation.ValidationError
+	if errs.Check(amount > 0, "amount", validation.CodePositive, "amount", amount) {
+		errs.Check(amount <= remaining, "amount", validation.CodeMax, "amount", remaining, amount)
+	}
+	checkNotBefore(&errs, original, dateTime)
+	if err := errs.Err(); err != nil {
+		return Transaction{}, err
+	}
+
+	return adjustment(original, TRANSACTION_TYPE_REFUND, "Refund of ", amount, dateTime)
+}
+
+// NewReversal undoes the remaining cents of original at dateTime, with the
+// opposite sign.
+func NewReversal(original Transaction, remaining int64, dateTime time.Time) (Transaction, error) {
+	if err := checkAdjustable(original); err != nil {
+		return Transaction{}, err
+	}
+	if remaining == 0 {
+		return Transaction{}, ErrAlreadyReversed
+	}
+
+	var errs validation.ValidationError
+	checkNotBefore(&errs, original, dateTime)
+	if err := errs.Err(); err != nil {
+		return Transaction{}, err
+	}
+
+	amount := remaining
+	if original.Amount > 0 {
+		amount = -remaining
+	}
+	return adjustment(original, TRANSACTION_TYPE_REVERSAL, "Reversal of ", amount, dateTime)
+}
+
+func checkAdjustable(original Transaction) error {
+	if original.OriginalTransactionID != 0 {
+		return ErrAdjustmentOfAdjustment
+	}
+	if original.TransferID != 0 {
+		return ErrTransferTransaction
+	}
+	return nil
+}
+
+func checkNotBefore(errs *validation.ValidationError, original Transaction, dateTime time.Time) {
+	errs.Check(!dateTime.Before(original.DateTime), "dateTime", validation.CodeNotBefore, "dateTime",
+		original.DateTime.Format(time.DateTime), dateTime.Format(time.DateTime))
+}
+
+// adjustment posts in the balance month of dateTime, whatever the month of
+// the original: that month may have been reported already.
+func adjustment(original Transaction, txnType TransactionType, prefix string, amount int64, dateTime time.Time) (Transaction, error) {
+	description := []rune(prefix + original.Label())
+	if len(description) > MAX_DESCRIPTION_LENGTH {
+		description = description[:MAX_DESCRIPTION_LENGTH]
+	}
+	transaction, err := NewTransactionWithDetails(amount, dateTime, original.AccountID, TransactionDetails{
+		Type:        txnType,
+		Description: string(description),
+		Merchant:    original.Merchant,
+		MCC:         original.MCC,
+		Channel:     original.Channel,
+		Location:    original.Location,
+	})
+	if err != nil {
+		return Transaction{}, fmt.Errorf("error while building %s: %w", txnType, err)
+	}
+	transaction.Category = original.Category
+	transaction.CategoryOverride = original.CategoryOverride
+	transaction.OriginalTransactionID = original.ID
+	return transaction, nil
+}
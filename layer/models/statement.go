@@ -0,0 +1,106 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// The minimum payment follows the Banxico rule for credit cards: the greater
+// of 1.5% of the statement balance and 1.25% of the credit limit, never more
+// than the balance itself.
+const (
+	MIN_PAYMENT_BALANCE_RATE = 0.015
+	MIN_PAYMENT_LIMIT_RATE   = 0.0125
+)
+
+// Statement is the closing of one credit card cycle. Amounts are positive
+// cents: balances are what the customer owes, zero when the account is in
+// their favor. Purchases are the debits of the customer and Charges those of
+// the bank itself, i.e. interest, fees and installments.
+type Statement struct {
+	ID        int64
+	AccountID int64
+	// The cycle runs from PeriodStart to PeriodEnd, the cut-off date, both
+	// inclusive.
+	PeriodStart      time.Time
+	PeriodEnd        time.Time
+	DueDate          time.Time
+	PreviousBalance  int64
+	Purchases        int64
+	Charges          int64
+	Payments         int64
+	StatementBalance int64
+	MinimumPayment   int64
+	CreditLimit      int64
+	AvailableCredit  int64
+}
+
+// CutoffDate is the cut-off date of the cycle of account that closes in the
+// given month.
+func CutoffDate(account Account, year int, month time.Month) time.Time {
+	return time.Date(year, month, account.CutoffDay, 0, 0, 0, 0, time.UTC)
+}
+
+// StatementPeriod returns the first day of the cycle that closes on cutoff,
+// the day after the previous cut-off.
+func StatementPeriod(account Account, cutoff time.Time) time.Time {
+	previous := CutoffDate(account, cutoff.Year(), cutoff.Month()-1)
+	return previous.AddDate(0, 0, 1)
+}
+
+// CycleTotals sums the transactions of a cycle, signed as stored. Purchases
+// are the debits of a type other than a system one, see
+// TransactionType.IsSystem, and Charges the debits of a system type.
+type CycleTotals struct {
+	Purchases int64
+	Charges   int64
+	Credits   int64
+}
+
+// NewStatement closes the cycle of a credit account ending on cutoff.
+// openingBalance is the account balance before the cycle started, signed as
+// stored.
+func NewStatement(account Account, cutoff time.Time, openingBalance int64, totals CycleTotals) Statement {
+	cutoff = time.Date(cutoff.Year(), cutoff.Month(), cutoff.Day(), 0, 0, 0, 0, time.UTC)
+	closingBalance := openingBalance + totals.Purchases + totals.Charges + totals.Credits
+
+	statement := Statement{
+		AccountID:        account.ID,
+		PeriodStart:      StatementPeriod(account, cutoff),
+		PeriodEnd:        cutoff,
+		DueDate:          cutoff.AddDate(0, 0, account.PaymentDueDays),
+		PreviousBalance:  owed(openingBalance),
+		Purchases:        -totals.Purchases,
+		Charges:          -totals.Charges,
+		Payments:         totals.Credits,
+		StatementBalance: owed(closingBalance),
+		CreditLimit:      account.CreditLimit,
+		AvailableCredit:  account.CreditLimit + closingBalance,
+	}
+	statement.MinimumPayment = MinimumPayment(statement.StatementBalance, account.CreditLimit)
+	return statement
+}
+
+// MinimumPayment is the least the customer must pay by the due date to stay
+// current, in cents.
+func MinimumPayment(statementBalance int64, creditLimit int64) int64 {
+	if statementBalance <= 0 {
+		return 0
+	}
+	minimum := int64(math.Round(float64(statementBalance) * MIN_PAYMENT_BALANCE_RATE))
+	if byLimit := int64(math.Round(float64(creditLimit) * MIN_PAYMENT_LIMIT_RATE)); byLimit > minimum {
+		minimum = byLimit
+	}
+	if minimum > statementBalance {
+		minimum = statementBalance
+	}
+	return minimum
+}
+
+// owed turns a signed credit card balance into the amount owed.
+func owed(balance int64) int64 {
+	if balance >= 0 {
+		return 0
+	}
+	return -balance
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMinimumPayment(t *testing.T) {
+	tests := []struct {
+		name             string
+		statementBalance int64
+		creditLimit      int64
+		want             int64
+	}{
+		{"nothing owed", 0, 50_000_00, 0},
+		{"in the customer's favor", -100_00, 50_000_00, 0},
+		{"1.5% of the balance", 40_000_00, 10_000_00, 600_00},
+		{"1.25% of the limit", 10_000_00, 50_000_00, 625_00},
+		{"never more than the balance", 300_00, 50_000_00, 300_00},
+		{"rounded to the cent", 1_234_57, 0, 18_52},
+	}
+	for _, test := range tests {
+		if got := MinimumPayment(test.statementBalance, test.creditLimit); got != test.want {
+			t.Errorf("%s: MinimumPayment(%d, %d) = %d, want %d", test.name, test.statementBalance, test.creditLimit, got, test.want)
+		}
+	}
+}
+
+func TestNewStatement(t *testing.T) {
+	account, err := NewCreditAccount(1, 20_000_00, 10, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account.ID = 7
+
+	cst := time.FixedZone("CST", -6*60*60)
+	statement := NewStatement(account, time.Date(2026, 3, 10, 23, 0, 0, 0, cst), -1_000_00, CycleTotals{
+		Purchases: -3_000_00,
+		Charges:   -150_00,
+		Credits:   500_00,
+	})
+
+	want := Statement{
+		AccountID:        7,
+		PeriodStart:      time.Date(2026, 2, 11, 0, 0, 0, 0, time.UTC),
+		PeriodEnd:        time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC),
+		DueDate:          time.Date(2026, 3, 30, 0, 0, 0, 0, time.UTC),
+		PreviousBalance:  1_000_00,
+		Purchases:        3_000_00,
+		Charges:          150_00,
+		Payments:         500_00,
+		StatementBalance: 3_650_00,
+		MinimumPayment:   250_00,
+		CreditLimit:      20_000_00,
+		AvailableCredit:  16_350_00,
+	}
+	if statement != want {
+		t.Errorf("NewStatement =\n%+v, want\n%+v", statement, want)
+	}
+}
+
+func TestNewStatementInFavor(t *testing.T) {
+	account, err := NewCreditAccount(1, 10_000_00, 28, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statement := NewStatement(account, time.Date(2026, 1, 28, 0, 0, 0, 0, time.UTC), 200_00, CycleTotals{Purchases: -50_00})
+
+	if statement.PeriodStart != time.Date(2025, 12, 29, 0, 0, 0, 0, time.UTC) {
+		t.Errorf("PeriodStart = %s, want the day after the December cut-off", statement.PeriodStart)
+	}
+	if statement.DueDate != statement.PeriodEnd.AddDate(0, 0, DEFAULT_PAYMENT_DUE_DAYS) {
+		t.Errorf("DueDate = %s, want %d days after %s", statement.DueDate, DEFAULT_PAYMENT_DUE_DAYS, statement.PeriodEnd)
+	}
+	if statement.PreviousBalance != 0 || statement.StatementBalance != 0 || statement.MinimumPayment != 0 {
+		t.Errorf("statement = %+v, want nothing owed", statement)
+	}
+	if statement.AvailableCredit != 10_150_00 {
+		t.Errorf("AvailableCredit = %d, want the limit plus the balance in favor", statement.AvailableCredit)
+	}
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Timestamp is t the way models keep their times: in UTC and truncated to the
+// second, the precision of the DATETIME columns. Times given in any zone then
+// fall in the same balance month and day, see Day, and compare in the
+// database the way they compare in Go.
+func Timestamp(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Second)
+}
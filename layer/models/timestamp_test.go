@@ -0,0 +1,31 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewTransactionTimestamp(t *testing.T) {
+	cst := time.FixedZone("CST", -6*60*60)
+	// The last evening of March in Mexico City is already April in UTC.
+	dateTime := time.Date(2026, 3, 31, 22, 15, 30, 123_456_789, cst)
+	transaction, err := NewTransaction(-100_00, dateTime, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := time.Date(2026, 4, 1, 4, 15, 30, 0, time.UTC)
+	if transaction.DateTime != want {
+		t.Errorf("DateTime = %s, want %s", transaction.DateTime, want)
+	}
+	if transaction.Month != "2026/04" {
+		t.Errorf("Month = %s, want 2026/04, the month of %s", transaction.Month, Day(dateTime))
+	}
+}
+
+func TestTimestamp(t *testing.T) {
+	cst := time.FixedZone("CST", -6*60*60)
+	got := Timestamp(time.Date(2026, 3, 10, 20, 0, 0, 999_999_999, cst))
+	if want := time.Date(2026, 3, 11, 2, 0, 0, 0, time.UTC); got != want {
+		t.Errorf("Timestamp = %s, want %s", got, want)
+	}
+}
@@ -1,37 +1,220 @@
 package models
 
 import (
-	"fmt"
+	"regexp"
+	"slices"
 	"storichallenge_layer/utils"
 	"storichallenge_layer/validation"
 	"time"
+	"unicode/utf8"
 )
 
+// TransactionType tells what originated a transaction. Transactions created
+// without one are purchases when they are debits and payments otherwise.
+type TransactionType string
+
+const (
+	TRANSACTION_TYPE_PURCHASE TransactionType = "purchase"
+	TRANSACTION_TYPE_PAYMENT  TransactionType = "payment"
+	// TRANSACTION_TYPE_TRANSFER moves money from or to another account, so it
+	// may be either a debit or a credit.
+	TRANSACTION_TYPE_TRANSFER TransactionType = "transfer"
+	TRANSACTION_TYPE_FEE      TransactionType = "fee"
+	TRANSACTION_TYPE_INTEREST TransactionType = "interest"
+	TRANSACTION_TYPE_REFUND   TransactionType = "refund"
+	// TRANSACTION_TYPE_REVERSAL undoes another transaction, with the opposite
+	// sign.
+	TRANSACTION_TYPE_REVERSAL TransactionType = "reversal"
+	// TRANSACTION_TYPE_INSTALLMENT charges, or credits back on cancellation,
+	// installments of a plan whose credit was approved at the purchase.
+	TRANSACTION_TYPE_INSTALLMENT TransactionType = "installment"
+)
+
+// TransactionChannel is where a transaction was made.
+type TransactionChannel string
+
+const (
+	CHANNEL_POS    TransactionChannel = "pos"
+	CHANNEL_ONLINE TransactionChannel = "online"
+	CHANNEL_ATM    TransactionChannel = "atm"
+	CHANNEL_BRANCH TransactionChannel = "branch"
+	CHANNEL_APP    TransactionChannel = "app"
+	// CHANNEL_SYSTEM is used for the transactions posted by the bank itself.
+	CHANNEL_SYSTEM TransactionChannel = "system"
+)
+
+const (
+	MAX_DESCRIPTION_LENGTH = 255
+	MAX_MERCHANT_LENGTH    = 100
+	MAX_LOCATION_LENGTH    = 100
+	MCC_DIGITS             = 4
+)
+
+var mccFormat = regexp.MustCompile(`^[0-9]{4}$`)
+
+func (txnType TransactionType) IsValid() bool {
+	switch txnType {
+	case TRANSACTION_TYPE_PURCHASE, TRANSACTION_TYPE_PAYMENT, TRANSACTION_TYPE_TRANSFER, TRANSACTION_TYPE_FEE,
+		TRANSACTION_TYPE_INTEREST, TRANSACTION_TYPE_REFUND, TRANSACTION_TYPE_REVERSAL, TRANSACTION_TYPE_INSTALLMENT:
+		return true
+	}
+	return false
+}
+
+// SYSTEM_TRANSACTION_TYPES are the types of the transactions charged by the
+// bank itself.
+var SYSTEM_TRANSACTION_TYPES = []TransactionType{TRANSACTION_TYPE_INTEREST, TRANSACTION_TYPE_FEE, TRANSACTION_TYPE_INSTALLMENT}
+
+// IsSystem reports whether transactions of this type are charged by the bank
+// itself. They are accepted on frozen accounts and beyond the credit limit.
+func (txnType TransactionType) IsSystem() bool {
+	return slices.Contains(SYSTEM_TRANSACTION_TYPES, txnType)
+}
+
+// allowsAmount reports whether a transaction of this type may have the sign
+// of amount: purchases, fees and interest are debits, payments and refunds
+// credits, and the rest may be either.
+func (txnType TransactionType) allowsAmount(amount int64) bool {
+	switch txnType {
+	case TRANSACTION_TYPE_PURCHASE, TRANSACTION_TYPE_FEE, TRANSACTION_TYPE_INTEREST:
+		return amount < 0
+	case TRANSACTION_TYPE_PAYMENT, TRANSACTION_TYPE_REFUND:
+		return amount > 0
+	}
+	return true
+}
+
+func (channel TransactionChannel) IsValid() bool {
+	switch channel {
+	case CHANNEL_POS, CHANNEL_ONLINE, CHANNEL_ATM, CHANNEL_BRANCH, CHANNEL_APP, CHANNEL_SYSTEM:
+		return true
+	}
+	return false
+}
+
+// TransactionDetails says what a transaction was. Every field is optional
+// but the type; MCC is the 4 digit ISO 18245 merchant category code.
+type TransactionDetails struct {
+	Type        TransactionType
+	Description string
+	Merchant    string
+	MCC         string
+	Channel     TransactionChannel
+	Location    string
+}
+
 type Transaction struct {
 	ID        int64
 	AccountID int64
 	Month     string
 	DateTime  time.Time
 	Amount    int64
+	TransactionDetails
+	// Category is set by the categorization rules when the transaction is
+	// posted or recategorized; CategoryOverride, when set, is the category
+	// chosen by hand and takes precedence.
+	Category         string
+	CategoryOverride string
+	// TransferID links the two transactions of a transfer, and those of its
+	// reversal; it is 0 for any other transaction.
+	TransferID int64
+	// OriginalTransactionID is the transaction a refund or reversal undoes,
+	// in whole or in part; it is 0 for any other transaction.
+	OriginalTransactionID int64
+	// ValueDate is the date an adjustment entry was meant for, in a month
+	// closed by the time it was posted; it is zero for any other transaction.
+	ValueDate time.Time
 }
 
 func NewTransaction(amount int64, dateTime time.Time, accountID int64) (Transaction, error) {
-	if amount == 0 {
-		return Transaction{}, fmt.Errorf(validation.ErrFieldRequired, "Transaction Amount")
+	return NewTransactionWithDetails(amount, dateTime, accountID, TransactionDetails{})
+}
+
+// NewTransactionWithDetails builds a transaction described by details. An
+// empty type is set from the sign of amount, and a zero dateTime means now;
+// either way it is kept as a Timestamp, so its month is the UTC one.
+func NewTransactionWithDetails(amount int64, dateTime time.Time, accountID int64, details TransactionDetails) (Transaction, error) {
+	if details.Type == "" {
+		details.Type = TRANSACTION_TYPE_PAYMENT
+		if amount < 0 {
+			details.Type = TRANSACTION_TYPE_PURCHASE
+		}
+	}
+
+	var errs validation.ValidationError
+	if errs.Check(amount != 0, "amount", validation.CodeRequired, "amount") &&
+		errs.Check(details.Type.IsValid(), "type", validation.CodeTransactionType, string(details.Type)) {
+		if amount < 0 {
+			errs.Check(details.Type.allowsAmount(amount), "amount", validation.CodeCreditType, string(details.Type), amount)
+		} else {
+			errs.Check(details.Type.allowsAmount(amount), "amount", validation.CodeDebitType, string(details.Type), amount)
+		}
+	}
+	if details.Channel != "" {
+		errs.Check(details.Channel.IsValid(), "channel", validation.CodeChannel, string(details.Channel))
+	}
+	if details.MCC != "" {
+		errs.Check(mccFormat.MatchString(details.MCC), "mcc", validation.CodeCodeDigits, "MCC", MCC_DIGITS, details.MCC)
+	}
+	checkLength(&errs, "description", details.Description, MAX_DESCRIPTION_LENGTH)
+	checkLength(&errs, "merchant", details.Merchant, MAX_MERCHANT_LENGTH)
+	checkLength(&errs, "location", details.Location, MAX_LOCATION_LENGTH)
+	if err := errs.Err(); err != nil {
+		return Transaction{}, err
 	}
 
 	if dateTime.IsZero() {
 		dateTime = time.Now()
 	}
+	dateTime = Timestamp(dateTime)
 
 	month := utils.GetMonth(dateTime)
 
 	transaction := Transaction{
-		AccountID: accountID,
-		Month:     month,
-		DateTime:  dateTime,
-		Amount:    amount,
+		AccountID:          accountID,
+		Month:              month,
+		DateTime:           dateTime,
+		Amount:             amount,
+		TransactionDetails: details,
 	}
 
 	return transaction, nil
 }
+
+// NewSystemTransaction builds a charge of the bank, e.g. interest or a fee,
+// debiting amount cents from the account.
+func NewSystemTransaction(accountID int64, txnType TransactionType, amount int64, dateTime time.Time, description string) (Transaction, error) {
+	return NewTransactionWithDetails(-amount, dateTime, accountID, TransactionDetails{
+		Type:        txnType,
+		Description: description,
+		Channel:     CHANNEL_SYSTEM,
+	})
+}
+
+// Label is the text that names the transaction in statements: its
+// description, else its merchant, else its type.
+func (transaction Transaction) Label() string {
+	switch {
+	case transaction.Description != "":
+		return transaction.Description
+	case transaction.Merchant != "":
+		return transaction.Merchant
+	}
+	return string(transaction.Type)
+}
+
+// EffectiveCategory is the category the transaction counts under.
+func (transaction Transaction) EffectiveCategory() string {
+	if transaction.CategoryOverride != "" {
+		return transaction.CategoryOverride
+	}
+	if transaction.Category != "" {
+		return transaction.Category
+	}
+	return CATEGORY_UNCATEGORIZED
+}
+
+func checkLength(errs *validation.ValidationError, field string, value string, max int) {
+	length := utf8.RuneCountInString(value)
+	errs.Check(length <= max, field, validation.CodeMaxLength, field, max, length)
+}
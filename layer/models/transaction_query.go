@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+type SortOrder string
+
+const (
+	SORT_DESC SortOrder = "desc"
+	SORT_ASC  SortOrder = "asc"
+)
+
+// TransactionKind restricts a query to debits (negative amounts) or credits
+// (positive amounts). The zero value matches both.
+type TransactionKind string
+
+const (
+	TRANSACTION_KIND_ANY    TransactionKind = ""
+	TRANSACTION_KIND_DEBIT  TransactionKind = "debit"
+	TRANSACTION_KIND_CREDIT TransactionKind = "credit"
+)
+
+const (
+	DEFAULT_TRANSACTION_PAGE_SIZE = 50
+	MAX_TRANSACTION_PAGE_SIZE     = 500
+)
+
+// TransactionQuery filters the transactions of one account. From is inclusive
+// and To exclusive; amount bounds are inclusive, in cents and signed. Cursor is
+// the NextCursor of the previous page and must be used with the same filters
+// and order.
+type TransactionQuery struct {
+	AccountID int64
+	From      time.Time
+	To        time.Time
+	MinAmount *int64
+	MaxAmount *int64
+	Kind      TransactionKind
+	Order     SortOrder
+	Limit     int
+	Cursor    string
+}
+
+// TransactionPage is one page of a TransactionQuery. NextCursor is empty on
+// the last page.
+type TransactionPage struct {
+	Transactions []Transaction
+	NextCursor   string
+}
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"storichallenge_layer/validation"
+	"time"
+)
+
+// TransferStatus is the state of a transfer. Reversed transfers had both
+// their transactions undone together.
+type TransferStatus string
+
+const (
+	TRANSFER_STATUS_POSTED   TransferStatus = "posted"
+	TRANSFER_STATUS_REVERSED TransferStatus = "reversed"
+)
+
+const MAX_CLIENT_REFERENCE_LENGTH = 64
+
+var (
+	ErrInsufficientFunds = errors.New("account does not have enough funds")
+	ErrTransferNotFound  = errors.New("transfer not found")
+	ErrTransferReversed  = errors.New("transfer is already reversed")
+	// ErrTransferReferenceConflict is returned when a client reference is
+	// reused for a different transfer.
+	ErrTransferReferenceConflict = errors.New("client reference was already used for a different transfer")
+)
+
+// Transfer moves Amount cents from one account to another as a debit and a
+// credit posted together. ClientReference is chosen by the client and
+// identifies the transfer, so that retrying a request does not move the
+// money twice.
+type Transfer struct {
+	ID              int64
+	ClientReference string
+	FromAccountID   int64
+	ToAccountID     int64
+	Amount          int64
+	Description     string
+	DateTime        time.Time
+	Status          TransferStatus
+	// ReversedAt is zero unless the transfer was reversed.
+	ReversedAt time.Time
+}
+
+// NewTransfer validates a transfer of amount cents between two different
+// accounts. A zero dateTime means now.
+func NewTransfer(fromAccountID, toAccountID int64, amount int64, clientReference string, description string, dateTime time.Time) (Transfer, error) {
+	var errs validation.ValidationError
+	errs.Check(fromAccountID != 0, "fromAccountID", validation.CodeRequired, "fromAccountID")
+	if errs.Check(toAccountID != 0, "toAccountID", validation.CodeRequired, "toAccountID") && fromAccountID != 0 {
+		errs.Check(toAccountID != fromAccountID, "toAccountID", validation.CodeDifferent, "toAccountID", "fromAccountID")
+	}
+	errs.Check(amount > 0, "amount", validation.CodePositive, "amount", amount)
+	if errs.Required("clientReference", clientReference) {
+		checkLength(&errs, "clientReference", clientReference, MAX_CLIENT_REFERENCE_LENGTH)
+	}
+	checkLength(&errs, "description", description, MAX_DESCRIPTION_LENGTH)
+	if err := errs.Err(); err != nil {
+		return Transfer{}, err
+	}
+
+	if dateTime.IsZero() {
+		dateTime = time.Now()
+	}
+	return Transfer{
+		ClientReference: clientReference,
+		FromAccountID:   fromAccountID,
+		ToAccountID:     toAccountID,
+		Amount:          amount,
+		Description:     description,
+		DateTime:        Timestamp(dateTime),
+		Status:          TRANSFER_STATUS_POSTED,
+	}, nil
+}
+
+// Matches reports whether other asks for the same transfer, i.e. a retry of
+// it with the same client reference.
+func (transfer Transfer) Matches(other Transfer) bool {
+	return transfer.ClientReference == other.ClientReference &&
+		transfer.FromAccountID == other.FromAccountID &&
+		transfer.ToAccountID == other.ToAccountID &&
+		transfer.Amount == other.Amount
+}
+
+// Transactions returns the debit of the source account and the credit of the
+// destination one.
+func (transfer Transfer) Transactions() (Transaction, Transaction, error) {
+	description := transfer.Description
+	if description == "" {
+		description = "Transfer"
+	}
+	return transfer.pair(TransactionDetails{Type: TRANSACTION_TYPE_TRANSFER, Description: description}, transfer.DateTime, 1)
+}
+
+// ReversalTransactions returns the transactions that undo the transfer at
+// dateTime: a credit of the source account and a debit of the destination
+// one.
+func (transfer Transfer) ReversalTransactions(dateTime time.Time) (Transaction, Transaction, error) {
+	return transfer.pair(TransactionDetails{
+		Type:        TRANSACTION_TYPE_REVERSAL,
+		Description: fmt.Sprintf("Reversal of transfer %s", transfer.ClientReference),
+		Channel:     CHANNEL_SYSTEM,
+	}, dateTime, -1)
+}
+
+// pair builds the transactions of the source and destination accounts; sign
+// is 1 to move the money and -1 to move it back.
+func (transfer Transfer) pair(details TransactionDetails, dateTime time.Time, sign int64) (Transaction, Transaction, error) {
+	from, err := NewTransactionWithDetails(-sign*transfer.Amount, dateTime, transfer.FromAccountID, details)
+	if err != nil {
+		return Transaction{}, Transaction{}, err
+	}
+	to, err := NewTransactionWithDetails(sign*transfer.Amount, dateTime, transfer.ToAccountID, details)
+	if err != nil {
+		return Transaction{}, Transaction{}, err
+	}
+	from.TransferID = transfer.ID
+	to.TransferID = transfer.ID
+	return from, to, nil
+}
@@ -5,22 +5,74 @@ import (
 	"errors"
 	"fmt"
 	"storichallenge_layer/models"
+	"storichallenge_layer/storage"
+	"storichallenge_layer/utils"
+	"time"
 )
 
+// selectAccounts reads accounts together with their customer; scan its rows
+// with scanAccount. Held amounts depend on a time and are read apart, see
+// heldAmount.
+const selectAccounts = "SELECT account.id, account.customer_id, account.account_number, account.account_type, account.current_balance_amt, " +
+	"account.credit_limit_amt, account.cutoff_day, account.payment_due_days, " + pendingInstallments + ", " +
+	"account.closed_through, account.status, account.status_reason, account.status_changed_at, " + customerColumns +
+	" FROM account JOIN customer ON customer.id = account.customer_id"
+
+// pendingInstallments sums the installments of the account not charged yet.
+const pendingInstallments = "(SELECT COALESCE(SUM(installment.amt), 0) FROM installment WHERE installment.account_id = account.id AND installment.status = '" +
+	string(models.INSTALLMENT_STATUS_PENDING) + "')"
+
+// heldAmount sums the pending authorization holds of an account that have not
+// expired at at, i.e. that could still be captured then, see
+// models.Hold.IsExpired. Expired holds count for nothing even before
+// HoldRepository.ExpireDue marks them.
+func heldAmount(q storage.Querier, accountID int64, at time.Time) (int64, error) {
+	var held int64
+	query := "SELECT COALESCE(SUM(amt), 0) FROM authorization_hold WHERE account_id = ? AND status = ? AND expires_at > ?"
+	if err := q.QueryRow(query, accountID, models.HOLD_STATUS_PENDING, at).Scan(&held); err != nil {
+		return 0, fmt.Errorf("error while reading held amount of account %d: %v", accountID, err)
+	}
+	return held, nil
+}
+
+// heldAmounts is heldAmount for every account, keyed by account ID. Accounts
+// without holds are missing.
+func heldAmounts(q storage.Querier, at time.Time) (map[int64]int64, error) {
+	query := "SELECT account_id, SUM(amt) FROM authorization_hold WHERE status = ? AND expires_at > ? GROUP BY account_id"
+	rows, err := q.Query(query, models.HOLD_STATUS_PENDING, at)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading held amounts: %v", err)
+	}
+	defer rows.Close()
+
+	held := map[int64]int64{}
+	for rows.Next() {
+		var accountID, amount int64
+		if err := rows.Scan(&accountID, &amount); err != nil {
+			return nil, err
+		}
+		held[accountID] = amount
+	}
+	return held, rows.Err()
+}
+
 type AccountRepository struct {
-	DB          *sql.DB
+	DB          *storage.DB
 	BalanceRepo *BalanceRepository
 }
 
 func (repo *AccountRepository) Create(account models.Account) (int64, error) {
-	query := "INSERT INTO account (account_number, name, last_name, age, email, cur_balance_amt) VALUES (?,?,?,?,?,?)"
-	result, err := repo.DB.Exec(query, account.AccountNumber, account.Name, account.LastName, account.Age, account.Email, account.CurrentBalanceAmount)
-	if err != nil {
-		return 0, fmt.Errorf("error while creating account: %v", err)
+	if account.Status == "" {
+		account.Status = models.ACCOUNT_STATUS_ACTIVE
+	}
+	if account.Type == "" {
+		account.Type = models.ACCOUNT_TYPE_DEBIT
 	}
-	accountID, err := result.LastInsertId()
+	query := "INSERT INTO account (customer_id, account_number, account_type, current_balance_amt, credit_limit_amt, cutoff_day, payment_due_days, status) VALUES (?,?,?,?,?,?,?,?)"
+	accountID, err := repo.DB.InsertID(query, account.CustomerID, account.AccountNumber, account.Type, account.CurrentBalanceAmount,
+		account.CreditLimit, account.CutoffDay, account.PaymentDueDays, account.Status)
 	if err != nil {
-		return 0, errors.New("error occured when getting last inserted account id")
+		return 0, fmt.Errorf("error while creating account: %w", err)
 	}
 
 	initBalance, err := models.NewBalance(accountID, 0, "")
@@ -39,18 +91,18 @@ func (repo *AccountRepository) Create(account models.Account) (int64, error) {
 }
 
 func (repo *AccountRepository) GetByID(id int64, includeBalances, includeTransactions bool) (models.Account, error) {
-	query := "SELECT id, account_number, name, last_name, age, email, cur_balance_amt FROM account WHERE id = ?"
-	var account models.Account
-	err := repo.DB.QueryRow(query, id).Scan(
-		&account.ID, &account.AccountNumber, &account.Name, &account.LastName,
-		&account.Age, &account.Email, &account.CurrentBalanceAmount,
-	)
+	query := selectAccounts + " WHERE account.id = ?"
+	account, err := scanAccount(repo.DB.QueryRow(query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return models.Account{}, errors.New("account not found")
 		}
 		return models.Account{}, err
 	}
+	account.HeldAmount, err = heldAmount(repo.DB, account.ID, time.Now())
+	if err != nil {
+		return models.Account{}, err
+	}
 	if includeBalances {
 		balances, err := repo.BalanceRepo.GetByAccountID(id, includeTransactions)
 		if err != nil {
@@ -62,18 +114,18 @@ func (repo *AccountRepository) GetByID(id int64, includeBalances, includeTransac
 }
 
 func (repo *AccountRepository) GetByAccountNumber(accountNumber string, includeBalances, includeTransactions bool) (models.Account, error) {
-	query := "SELECT id, account_number, name, last_name, age, email, cur_balance_amt FROM account WHERE account_number = ?"
-	var account models.Account
-	err := repo.DB.QueryRow(query, accountNumber).Scan(
-		&account.ID, &account.AccountNumber, &account.Name, &account.LastName,
-		&account.Age, &account.Email, &account.CurrentBalanceAmount,
-	)
+	query := selectAccounts + " WHERE account.account_number = ?"
+	account, err := scanAccount(repo.DB.QueryRow(query, accountNumber))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return models.Account{}, errors.New("account not found")
 		}
 		return models.Account{}, err
 	}
+	account.HeldAmount, err = heldAmount(repo.DB, account.ID, time.Now())
+	if err != nil {
+		return models.Account{}, err
+	}
 	if includeBalances {
 		balances, err := repo.BalanceRepo.GetByAccountID(account.ID, includeTransactions)
 		if err != nil {
@@ -85,10 +137,35 @@ func (repo *AccountRepository) GetByAccountNumber(accountNumber string, includeB
 }
 
 func (repo *AccountRepository) GetAll() ([]models.Account, error) {
-	query := `SELECT id, account_number, name, last_name, age, email, current_balance_amount 
-			  FROM accounts`
+	return repo.list(time.Now(), selectAccounts+" ORDER BY account.id")
+}
+
+// GetByCustomerID returns every account of a customer, closed ones included.
+func (repo *AccountRepository) GetByCustomerID(customerID int64) ([]models.Account, error) {
+	return repo.list(time.Now(), selectAccounts+" WHERE account.customer_id = ? ORDER BY account.id", customerID)
+}
+
+// GetCreditByCutoffDay returns the open credit accounts whose cycle closes on
+// the given day of the month.
+func (repo *AccountRepository) GetCreditByCutoffDay(day int) ([]models.Account, error) {
+	query := selectAccounts + " WHERE account.account_type = ? AND account.cutoff_day = ? AND account.status <> ? ORDER BY account.id"
+	return repo.list(time.Now(), query, models.ACCOUNT_TYPE_CREDIT, day, models.ACCOUNT_STATUS_CLOSED)
+}
+
+// GetOpenCredit returns every credit account that is not closed.
+func (repo *AccountRepository) GetOpenCredit() ([]models.Account, error) {
+	query := selectAccounts + " WHERE account.account_type = ? AND account.status <> ? ORDER BY account.id"
+	return repo.list(time.Now(), query, models.ACCOUNT_TYPE_CREDIT, models.ACCOUNT_STATUS_CLOSED)
+}
+
+// list reads the accounts selected by query, with their holds held at at.
+func (repo *AccountRepository) list(at time.Time, query string, args ...any) ([]models.Account, error) {
+	held, err := heldAmounts(repo.DB, at)
+	if err != nil {
+		return nil, err
+	}
 
-	rows, err := repo.DB.Query(query)
+	rows, err := repo.DB.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -96,14 +173,11 @@ func (repo *AccountRepository) GetAll() ([]models.Account, error) {
 
 	var accounts []models.Account
 	for rows.Next() {
-		var account models.Account
-		err := rows.Scan(
-			&account.ID, &account.AccountNumber, &account.Name, &account.LastName,
-			&account.Age, &account.Email, &account.CurrentBalanceAmount,
-		)
+		account, err := scanAccount(rows)
 		if err != nil {
 			return nil, err
 		}
+		account.HeldAmount = held[account.ID]
 		accounts = append(accounts, account)
 	}
 
@@ -114,9 +188,97 @@ func (repo *AccountRepository) GetAll() ([]models.Account, error) {
 	return accounts, nil
 }
 
+// scanAccount reads a row selected with selectAccounts.
+func scanAccount(row interface{ Scan(dest ...any) error }) (models.Account, error) {
+	var account models.Account
+	var statusChangedAt sql.NullTime
+	fields := []any{
+		&account.ID, &account.CustomerID, &account.AccountNumber, &account.Type, &account.CurrentBalanceAmount,
+		&account.CreditLimit, &account.CutoffDay, &account.PaymentDueDays, &account.PendingInstallments,
+		&account.ClosedThrough, &account.Status, &account.StatusReason, &statusChangedAt,
+	}
+	err := row.Scan(append(fields, customerFields(&account.Customer)...)...)
+	account.StatusChangedAt = statusChangedAt.Time
+	return account, err
+}
+
+// ChangeStatus applies a validated status change and records it in the
+// account history. It fails if the account status is no longer change.From,
+// i.e. another change got there first.
+func (repo *AccountRepository) ChangeStatus(change models.AccountStatusChange) error {
+	return repo.DB.WithTx(func(tx *storage.Tx) error {
+		query := "UPDATE account SET status = ?, status_reason = ?, status_changed_at = ? WHERE id = ? AND status = ?"
+		result, err := tx.Exec(query, change.To, change.Reason, change.ChangedAt, change.AccountID, change.From)
+		if err != nil {
+			return fmt.Errorf("error while changing account status: %v", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("error while changing account status: %v", err)
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("account %d is no longer %s", change.AccountID, change.From)
+		}
+
+		query = "INSERT INTO account_status_history (account_id, from_status, to_status, reason, changed_at) VALUES (?,?,?,?,?)"
+		_, err = tx.Exec(query, change.AccountID, change.From, change.To, change.Reason, change.ChangedAt)
+		if err != nil {
+			return fmt.Errorf("error while recording account status change: %v", err)
+		}
+		return nil
+	})
+}
+
+// GetStatusHistory returns the status changes of an account, oldest first.
+func (repo *AccountRepository) GetStatusHistory(accountID int64) ([]models.AccountStatusChange, error) {
+	query := "SELECT id, account_id, from_status, to_status, reason, changed_at FROM account_status_history WHERE account_id = ? ORDER BY changed_at, id"
+	rows, err := repo.DB.Query(query, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []models.AccountStatusChange
+	for rows.Next() {
+		var change models.AccountStatusChange
+		err := rows.Scan(&change.ID, &change.AccountID, &change.From, &change.To, &change.Reason, &change.ChangedAt)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, change)
+	}
+	return history, rows.Err()
+}
+
+// forPosting reads what decides whether the account accepts a transaction
+// inside q, with its holds held at at. Callers first update the account row,
+// e.g. its current balance, so the row is already locked and cannot change
+// until their database transaction ends.
+func (repo *AccountRepository) forPosting(q storage.Querier, accountID int64, at time.Time) (models.Account, error) {
+	account := models.Account{ID: accountID}
+	query := "SELECT account_type, current_balance_amt, credit_limit_amt, " + pendingInstallments + ", closed_through, status FROM account WHERE id = ?"
+	err := q.QueryRow(query, accountID).Scan(&account.Type, &account.CurrentBalanceAmount, &account.CreditLimit, &account.PendingInstallments,
+		&account.ClosedThrough, &account.Status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Account{}, errors.New("account not found")
+		}
+		return models.Account{}, fmt.Errorf("error while reading account status: %v", err)
+	}
+	account.HeldAmount, err = heldAmount(q, accountID, at)
+	if err != nil {
+		return models.Account{}, err
+	}
+	return account, nil
+}
+
 func (repo *AccountRepository) UpdateCurrentBalanceAmountArithmetrically(accountID int64, amountToAdd int64) error {
-	query := "UPDATE accounts SET cur_balance_amt = cur_balance_amt + ? WHERE id = ?"
-	result, err := repo.DB.Exec(query, amountToAdd)
+	return repo.updateCurrentBalanceAmountArithmetrically(repo.DB, accountID, amountToAdd)
+}
+
+func (repo *AccountRepository) updateCurrentBalanceAmountArithmetrically(q storage.Querier, accountID int64, amountToAdd int64) error {
+	query := "UPDATE account SET current_balance_amt = current_balance_amt + ? WHERE id = ?"
+	result, err := q.Exec(query, amountToAdd, accountID)
 	if err != nil {
 		return err
 	}
@@ -132,3 +294,57 @@ func (repo *AccountRepository) UpdateCurrentBalanceAmountArithmetrically(account
 
 	return nil
 }
+
+// closedThrough reads the last closed balance month of an account.
+func (repo *AccountRepository) closedThrough(q storage.Querier, accountID int64) (string, error) {
+	var closedThrough string
+	err := q.QueryRow("SELECT closed_through FROM account WHERE id = ?", accountID).Scan(&closedThrough)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", errors.New("account not found")
+		}
+		return "", fmt.Errorf("error while reading closed periods: %v", err)
+	}
+	return closedThrough, nil
+}
+
+// ClosePeriods closes month, and every earlier one, on every account where it
+// was still open, and returns how many accounts changed.
+func (repo *AccountRepository) ClosePeriods(month string) (int64, error) {
+	query := "UPDATE account SET closed_through = ? WHERE closed_through < ?"
+	result, err := repo.DB.Exec(query, month, month)
+	if err != nil {
+		return 0, fmt.Errorf("error while closing balance months: %v", err)
+	}
+	closed, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error while closing balance months: %v", err)
+	}
+	return closed, nil
+}
+
+// ClosePeriod closes month, and every earlier one, on an account. Closing a
+// month already closed does nothing.
+func (repo *AccountRepository) ClosePeriod(accountID int64, month string) error {
+	query := "UPDATE account SET closed_through = ? WHERE id = ? AND closed_through < ?"
+	if _, err := repo.DB.Exec(query, month, accountID, month); err != nil {
+		return fmt.Errorf("error while closing balance month: %v", err)
+	}
+	_, err := repo.closedThrough(repo.DB, accountID)
+	return err
+}
+
+// ReopenPeriod opens month, and every later one, on an account again, so
+// that corrections can be posted into it.
+func (repo *AccountRepository) ReopenPeriod(accountID int64, month string) error {
+	start, err := utils.ParseMonthTime(month)
+	if err != nil {
+		return err
+	}
+	query := "UPDATE account SET closed_through = ? WHERE id = ? AND closed_through >= ?"
+	if _, err := repo.DB.Exec(query, utils.GetMonth(start.AddDate(0, -1, 0)), accountID, month); err != nil {
+		return fmt.Errorf("error while reopening balance month: %v", err)
+	}
+	_, err = repo.closedThrough(repo.DB, accountID)
+	return err
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"storichallenge_layer/models"
+)
+
+// changeStatus moves an account to next, validating the change against the
+// account as stored.
+func (store *testStore) changeStatus(tb testing.TB, accountID int64, next models.AccountStatus, reason string, at time.Time) {
+	tb.Helper()
+	account, err := store.Accounts.GetByID(accountID, false, false)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	change, err := models.NewAccountStatusChange(account, next, reason, at)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if err := store.Accounts.ChangeStatus(change); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func TestChangeStatus(t *testing.T) {
+	store := newTestStore(t)
+	at := time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+	accountID := store.newAccount(t, 1000_00, at)
+
+	store.changeStatus(t, accountID, models.ACCOUNT_STATUS_FROZEN, "fraud report", at)
+	account, err := store.Accounts.GetByID(accountID, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Status != models.ACCOUNT_STATUS_FROZEN || account.StatusReason != "fraud report" || !account.StatusChangedAt.Equal(at) {
+		t.Errorf("account is %s (%q) since %s, want frozen (fraud report) since %s",
+			account.Status, account.StatusReason, account.StatusChangedAt, at)
+	}
+
+	// A frozen account takes credits only.
+	debit, err := models.NewTransaction(-100_00, at.Add(time.Hour), accountID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkErr(t, store.Transactions.Create(debit), models.ErrAccountFrozen)
+	store.post(t, accountID, 50_00, at.Add(time.Hour))
+
+	store.changeStatus(t, accountID, models.ACCOUNT_STATUS_ACTIVE, "report dismissed", at.Add(24*time.Hour))
+	store.changeStatus(t, accountID, models.ACCOUNT_STATUS_CLOSED, "customer request", at.Add(48*time.Hour))
+	checkErr(t, store.Transactions.Create(debit), models.ErrAccountClosed)
+	store.checkBalance(t, accountID, 1050_00)
+
+	history, err := store.Accounts.GetStatusHistory(accountID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"active -> frozen: fraud report",
+		"frozen -> active: report dismissed",
+		"active -> closed: customer request",
+	}
+	if len(history) != len(want) {
+		t.Fatalf("history %v, want %v", history, want)
+	}
+	for i, change := range history {
+		if change.String() != want[i] || change.AccountID != accountID || !change.ChangedAt.Equal(at.Add(time.Duration(i)*24*time.Hour)) {
+			t.Errorf("history[%d] = %s for account %d at %s, want %s", i, change, change.AccountID, change.ChangedAt, want[i])
+		}
+	}
+
+	other := store.newAccount(t, 0, at)
+	history, err = store.Accounts.GetStatusHistory(other)
+	if err != nil || len(history) != 0 {
+		t.Errorf("history of an unchanged account %v, %v, want none", history, err)
+	}
+}
+
+func TestChangeStatusConcurrentChange(t *testing.T) {
+	store := newTestStore(t)
+	at := time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+	accountID := store.newAccount(t, 0, at)
+
+	// Both changes are validated against the account while it is active.
+	account, err := store.Accounts.GetByID(accountID, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	freeze, err := models.NewAccountStatusChange(account, models.ACCOUNT_STATUS_FROZEN, "fraud report", at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closure, err := models.NewAccountStatusChange(account, models.ACCOUNT_STATUS_CLOSED, "customer request", at)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Accounts.ChangeStatus(closure); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Accounts.ChangeStatus(freeze); err == nil {
+		t.Fatal("freezing an account closed meanwhile succeeded")
+	}
+
+	account, err = store.Accounts.GetByID(accountID, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Status != models.ACCOUNT_STATUS_CLOSED || account.StatusReason != "customer request" {
+		t.Errorf("account is %s (%q), want closed (customer request)", account.Status, account.StatusReason)
+	}
+	history, err := store.Accounts.GetStatusHistory(accountID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].To != models.ACCOUNT_STATUS_CLOSED {
+		t.Errorf("history %v, want the closure only", history)
+	}
+}
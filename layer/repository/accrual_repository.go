@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"fmt"
+	"storichallenge_layer/models"
+	"storichallenge_layer/storage"
+	"time"
+)
+
+type AccrualRepository struct {
+	DB              *storage.DB
+	TransactionRepo *TransactionRepository
+}
+
+// Post charges accruals, posting a system transaction for each of them, all
+// or none. There is one accrual per account, day and kind, so posting one
+// again fails with a unique violation on accrual_date and posts nothing.
+// Charges are owed whatever PERIOD_BACKDATING says, so those of a day in a
+// closed month, e.g. when back-filling, are always posted as adjustment
+// entries into the first open month; the accrual keeps its own day.
+func (repo *AccrualRepository) Post(accruals []models.Accrual) ([]models.Accrual, error) {
+	var posted []models.Accrual
+	err := repo.DB.WithTx(func(tx *storage.Tx) error {
+		posted = make([]models.Accrual, 0, len(accruals))
+		for _, accrual := range accruals {
+			transaction, err := accrual.Transaction()
+			if err != nil {
+				return err
+			}
+			closedThrough, err := repo.TransactionRepo.AccountRepo.closedThrough(tx, accrual.AccountID)
+			if err != nil {
+				return err
+			}
+			transaction, err = transaction.AsAdjustment(closedThrough)
+			if err != nil {
+				return err
+			}
+			accrual.TransactionID, err = repo.TransactionRepo.create(tx, transaction)
+			if err != nil {
+				return err
+			}
+
+			query := "INSERT INTO accrual (account_id, accrual_date, kind, amt, transaction_id) VALUES (?,?,?,?,?)"
+			accrual.ID, err = tx.InsertID(query, accrual.AccountID, accrual.Date, accrual.Kind, accrual.Amount, accrual.TransactionID)
+			if err != nil {
+				return fmt.Errorf("error while creating accrual: %w", err)
+			}
+			posted = append(posted, accrual)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return posted, nil
+}
+
+// GetByAccountID returns the accruals of an account charged from from to to,
+// both inclusive, oldest first.
+func (repo *AccrualRepository) GetByAccountID(accountID int64, from time.Time, to time.Time) ([]models.Accrual, error) {
+	query := `SELECT id, account_id, accrual_date, kind, amt, transaction_id FROM accrual
+			  WHERE account_id = ? AND accrual_date >= ? AND accrual_date <= ? ORDER BY accrual_date, id`
+	rows, err := repo.DB.Query(query, accountID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading accruals: %v", err)
+	}
+	defer rows.Close()
+
+	var accruals []models.Accrual
+	for rows.Next() {
+		var accrual models.Accrual
+		err := rows.Scan(&accrual.ID, &accrual.AccountID, &accrual.Date, &accrual.Kind, &accrual.Amount, &accrual.TransactionID)
+		if err != nil {
+			return nil, err
+		}
+		accruals = append(accruals, accrual)
+	}
+	return accruals, rows.Err()
+}
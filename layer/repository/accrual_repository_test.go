@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"storichallenge_layer/models"
+	"storichallenge_layer/storage"
+)
+
+func TestAccrualPostIntoClosedMonth(t *testing.T) {
+	store := newTestStore(t)
+	day := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+	accountID := store.newAccount(t, 1000_00, day)
+	if err := store.Accounts.ClosePeriod(accountID, "2026/03"); err != nil {
+		t.Fatal(err)
+	}
+
+	accrual := models.NewAccrual(accountID, day, models.ACCRUAL_KIND_INTEREST, 12_34)
+	posted, err := store.Accruals.Post([]models.Accrual{accrual})
+	if err != nil {
+		t.Fatalf("posting into a closed month with backdating %q: %v", store.Transactions.Backdating, err)
+	}
+	transaction, err := store.Transactions.GetByID(posted[0].TransactionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transaction.Month != "2026/04" || !transaction.DateTime.Equal(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("charge posted on %s into %s, want the first day of 2026/04", transaction.DateTime, transaction.Month)
+	}
+	if !transaction.ValueDate.Equal(models.AccrualTime(day)) {
+		t.Errorf("charge value date = %s, want %s", transaction.ValueDate, models.AccrualTime(day))
+	}
+	store.checkBalance(t, accountID, 1000_00-12_34)
+
+	_, err = store.Accruals.Post([]models.Accrual{accrual})
+	if !storage.IsUniqueViolation(err, "accrual_date") {
+		t.Errorf("posting the accrual again: error = %v, want a unique violation", err)
+	}
+	store.checkBalance(t, accountID, 1000_00-12_34)
+	store.checkLedger(t)
+}
@@ -2,20 +2,24 @@ package repository
 
 import (
 	"database/sql"
-	"errors"
 	"fmt"
 	"storichallenge_layer/models"
+	"storichallenge_layer/storage"
 )
 
 type BalanceRepository struct {
-	DB              *sql.DB
+	DB              *storage.DB
 	AccountRepo     *AccountRepository
 	TransactionRepo *TransactionRepository
 }
 
 func (repo *BalanceRepository) Create(balance models.Balance) error {
-	query := "INSERT INTO balance (account_id, month, amt) VALUES (?,?,?)"
-	_, err := repo.DB.Exec(query, balance.AccountID, balance.Month, balance.Amount)
+	return repo.create(repo.DB, balance)
+}
+
+func (repo *BalanceRepository) create(q storage.Querier, balance models.Balance) error {
+	query := "INSERT INTO balance (account_id, month, amt, opening_amt) VALUES (?,?,?,?)"
+	_, err := q.Exec(query, balance.AccountID, balance.Month, balance.Amount, balance.OpeningAmount)
 	if err != nil {
 		return fmt.Errorf("error while creating balance: %v", err)
 	}
@@ -24,7 +28,7 @@ func (repo *BalanceRepository) Create(balance models.Balance) error {
 }
 
 func (repo *BalanceRepository) GetByAccountID(accountID int64, includeTransactions bool) ([]models.Balance, error) {
-	query := "SELECT account_id, month, amt FROM balance WHERE account_id = ? ORDER BY month DESC"
+	query := "SELECT account_id, month, amt, opening_amt FROM balance WHERE account_id = ? ORDER BY month DESC"
 	rows, err := repo.DB.Query(query, accountID)
 	if err != nil {
 		return nil, err
@@ -34,25 +38,24 @@ func (repo *BalanceRepository) GetByAccountID(accountID int64, includeTransactio
 	var balances []models.Balance
 	for rows.Next() {
 		var balance models.Balance
-		err := rows.Scan(&balance.AccountID, &balance.Month, &balance.Amount)
+		err := rows.Scan(&balance.AccountID, &balance.Month, &balance.Amount, &balance.OpeningAmount)
 		if err != nil {
 			return nil, err
 		}
 		balances = append(balances, balance)
 	}
 	if includeTransactions {
-		transactions, err := repo.TransactionRepo.GetByAccountID(accountID)
-		if err != nil {
-			return nil, err
+		monthIndex := make(map[string]int, len(balances))
+		for i := range balances {
+			monthIndex[balances[i].Month] = i
 		}
-		for i := len(balances) - 1; i > 0; i-- {
-			for j := len(transactions) - 1; j > 0; j-- {
-				if transactions[j].Month == balances[i].Month {
-					balances[i].Transactions = append(balances[i].Transactions, transactions[j])
-					transactions = append(transactions[:j], transactions[j+1:]...)
-					continue
-				}
-				break
+		transactions := repo.TransactionRepo.Stream(models.TransactionQuery{AccountID: accountID})
+		for transaction, err := range transactions {
+			if err != nil {
+				return nil, err
+			}
+			if i, ok := monthIndex[transaction.Month]; ok {
+				balances[i].Transactions = append(balances[i].Transactions, transaction)
 			}
 		}
 	}
@@ -60,14 +63,14 @@ func (repo *BalanceRepository) GetByAccountID(accountID int64, includeTransactio
 }
 
 func (repo *BalanceRepository) GetByAccountIDMonth(accountID int64, month string, includeTransactions bool) (models.Balance, error) {
-	query := "SELECT account_id, month, amt FROM balance WHERE account_id = ? AND month = ?"
+	query := "SELECT account_id, month, amt, opening_amt FROM balance WHERE account_id = ? AND month = ?"
 	var balance models.Balance
-	err := repo.DB.QueryRow(query, accountID).Scan(
-		&balance.AccountID, &balance.Month, &balance.Amount,
+	err := repo.DB.QueryRow(query, accountID, month).Scan(
+		&balance.AccountID, &balance.Month, &balance.Amount, &balance.OpeningAmount,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return models.Balance{}, errors.New("balance not found")
+			return models.Balance{}, models.ErrBalanceNotFound
 		}
 		return models.Balance{}, err
 	}
@@ -81,9 +84,40 @@ func (repo *BalanceRepository) GetByAccountIDMonth(accountID int64, month string
 	return balance, nil
 }
 
+// GetMonth returns the balance of an account month with its opening balance,
+// also for months without transactions, which have none stored.
+func (repo *BalanceRepository) GetMonth(accountID int64, month string) (models.Balance, error) {
+	balance, err := repo.GetByAccountIDMonth(accountID, month, false)
+	if err == models.ErrBalanceNotFound {
+		balance = models.Balance{AccountID: accountID, Month: month}
+		balance.OpeningAmount, err = repo.openingAmount(repo.DB, accountID, month)
+	}
+	if err != nil {
+		return models.Balance{}, err
+	}
+	return balance, nil
+}
+
+// openingAmount sums the amounts of the months of an account before month.
+func (repo *BalanceRepository) openingAmount(q storage.Querier, accountID int64, month string) (int64, error) {
+	var opening int64
+	query := "SELECT COALESCE(SUM(amt), 0) FROM balance WHERE account_id = ? AND month < ?"
+	if err := q.QueryRow(query, accountID, month).Scan(&opening); err != nil {
+		return 0, fmt.Errorf("error while computing opening balance: %v", err)
+	}
+	return opening, nil
+}
+
 func (repo *BalanceRepository) UpdateAmountArithmetically(accountID int64, month string, amountToAdd int64) error {
-	query := "UPDATE balance SET amount = amount + ? WHERE account_id = ? AND month = ?"
-	result, err := repo.DB.Exec(query, amountToAdd, accountID, month)
+	return repo.updateAmountArithmetically(repo.DB, accountID, month, amountToAdd)
+}
+
+// updateAmountArithmetically adds amountToAdd to the balance month, creating
+// it if needed, to the opening balance of every later month and to the
+// account current balance, all through q.
+func (repo *BalanceRepository) updateAmountArithmetically(q storage.Querier, accountID int64, month string, amountToAdd int64) error {
+	query := "UPDATE balance SET amt = amt + ? WHERE account_id = ? AND month = ?"
+	result, err := q.Exec(query, amountToAdd, accountID, month)
 	if err != nil {
 		return err
 	}
@@ -98,11 +132,25 @@ func (repo *BalanceRepository) UpdateAmountArithmetically(accountID int64, month
 		if err != nil {
 			return err
 		}
-		repo.Create(newBalance)
-		return repo.UpdateAmountArithmetically(accountID, month, amountToAdd)
+		newBalance.OpeningAmount, err = repo.openingAmount(q, accountID, month)
+		if err != nil {
+			return err
+		}
+		err = repo.create(q, newBalance)
+		if err != nil {
+			return err
+		}
+		return repo.updateAmountArithmetically(q, accountID, month, amountToAdd)
+	}
+
+	// Backdated postings move the opening balance of the months after them;
+	// for a posting into the last month there are none.
+	query = "UPDATE balance SET opening_amt = opening_amt + ? WHERE account_id = ? AND month > ?"
+	if _, err := q.Exec(query, amountToAdd, accountID, month); err != nil {
+		return fmt.Errorf("error while updating opening balances: %v", err)
 	}
 
-	err = repo.AccountRepo.UpdateCurrentBalanceAmountArithmetrically(accountID, amountToAdd)
+	err = repo.AccountRepo.updateCurrentBalanceAmountArithmetrically(q, accountID, amountToAdd)
 
 	if err != nil {
 		return err
@@ -110,3 +158,53 @@ func (repo *BalanceRepository) UpdateAmountArithmetically(accountID int64, month
 
 	return nil
 }
+
+// RebuildOpenings computes again the opening balance of every month of an
+// account, or of every account when accountID is 0, from the amounts of the
+// earlier months. It fills in the openings of months created before they
+// were kept.
+func (repo *BalanceRepository) RebuildOpenings(accountID int64) error {
+	query := "SELECT account_id, month, amt, opening_amt FROM balance"
+	args := []any{}
+	if accountID != 0 {
+		query += " WHERE account_id = ?"
+		args = append(args, accountID)
+	}
+	query += " ORDER BY account_id, month"
+	rows, err := repo.DB.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("error while reading balances: %v", err)
+	}
+	var updates []models.Balance
+	var previous models.Balance
+	for rows.Next() {
+		var balance models.Balance
+		if err := rows.Scan(&balance.AccountID, &balance.Month, &balance.Amount, &balance.OpeningAmount); err != nil {
+			rows.Close()
+			return err
+		}
+		opening := int64(0)
+		if previous.AccountID == balance.AccountID {
+			opening = previous.ClosingAmount()
+		}
+		if balance.OpeningAmount != opening {
+			balance.OpeningAmount = opening
+			updates = append(updates, balance)
+		}
+		previous = balance
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return repo.DB.WithTx(func(tx *storage.Tx) error {
+		for _, balance := range updates {
+			query := "UPDATE balance SET opening_amt = ? WHERE account_id = ? AND month = ?"
+			if _, err := tx.Exec(query, balance.OpeningAmount, balance.AccountID, balance.Month); err != nil {
+				return fmt.Errorf("error while rebuilding opening balances: %v", err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"storichallenge_layer/models"
+	"storichallenge_layer/storage"
+)
+
+// customerColumns are qualified with the table name, so they can also be
+// selected in joins with account.
+const customerColumns = "customer.id, customer.name, customer.last_name, customer.second_last_name, customer.date_of_birth, customer.curp, customer.rfc, customer.phone, customer.email"
+
+type CustomerRepository struct {
+	DB *storage.DB
+}
+
+func (repo *CustomerRepository) Create(customer models.Customer) (int64, error) {
+	query := "INSERT INTO customer (name, last_name, second_last_name, date_of_birth, curp, rfc, phone, email) VALUES (?,?,?,?,?,?,?,?)"
+	customerID, err := repo.DB.InsertID(query, customer.Name, customer.LastName, customer.SecondLastName,
+		customer.DateOfBirth, customer.CURP, customer.RFC, customer.Phone, customer.Email)
+	if err != nil {
+		return 0, fmt.Errorf("error while creating customer: %w", err)
+	}
+	return customerID, nil
+}
+
+func (repo *CustomerRepository) GetByID(id int64) (models.Customer, error) {
+	query := "SELECT " + customerColumns + " FROM customer WHERE id = ?"
+	return repo.get(query, id)
+}
+
+func (repo *CustomerRepository) GetByCURP(curp string) (models.Customer, error) {
+	query := "SELECT " + customerColumns + " FROM customer WHERE curp = ?"
+	return repo.get(query, curp)
+}
+
+func (repo *CustomerRepository) get(query string, arg any) (models.Customer, error) {
+	var customer models.Customer
+	err := repo.DB.QueryRow(query, arg).Scan(customerFields(&customer)...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Customer{}, errors.New("customer not found")
+		}
+		return models.Customer{}, err
+	}
+	return customer, nil
+}
+
+// customerFields are the scan destinations matching customerColumns.
+func customerFields(customer *models.Customer) []any {
+	return []any{
+		&customer.ID, &customer.Name, &customer.LastName, &customer.SecondLastName,
+		&customer.DateOfBirth, &customer.CURP, &customer.RFC, &customer.Phone, &customer.Email,
+	}
+}
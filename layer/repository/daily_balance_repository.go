@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"storichallenge_layer/models"
+	"storichallenge_layer/storage"
+	"time"
+)
+
+type DailyBalanceRepository struct {
+	DB          *storage.DB
+	AccountRepo *AccountRepository
+}
+
+// Record computes the end-of-day balances of an account from from to to,
+// both inclusive, from its transactions and stores them, replacing any stored
+// for those days. Recording the same days again is harmless.
+func (repo *DailyBalanceRepository) Record(accountID int64, from time.Time, to time.Time) ([]models.DailyBalance, error) {
+	var balances []models.DailyBalance
+	err := repo.DB.WithTx(func(tx *storage.Tx) error {
+		// Locks the account row, so that no transaction is posted between
+		// computing the balances and storing them, see shift.
+		if err := repo.AccountRepo.updateCurrentBalanceAmountArithmetrically(tx, accountID, 0); err != nil {
+			return err
+		}
+
+		var err error
+		balances, err = repo.compute(tx, accountID, from, to)
+		if err != nil {
+			return err
+		}
+
+		query := "DELETE FROM daily_balance WHERE account_id = ? AND balance_date >= ? AND balance_date <= ?"
+		if _, err := tx.Exec(query, accountID, models.Day(from), models.Day(to)); err != nil {
+			return fmt.Errorf("error while clearing daily balances: %v", err)
+		}
+		query = "INSERT INTO daily_balance (account_id, balance_date, amt) VALUES (?,?,?)"
+		for _, balance := range balances {
+			if _, err := tx.Exec(query, balance.AccountID, balance.Date, balance.Amount); err != nil {
+				return fmt.Errorf("error while recording daily balance: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+// Compute computes the end-of-day balances of an account from from to to,
+// both inclusive, from its transactions, without storing them.
+func (repo *DailyBalanceRepository) Compute(accountID int64, from time.Time, to time.Time) ([]models.DailyBalance, error) {
+	return repo.compute(repo.DB, accountID, from, to)
+}
+
+func (repo *DailyBalanceRepository) compute(q storage.Querier, accountID int64, from time.Time, to time.Time) ([]models.DailyBalance, error) {
+	from, end := models.Day(from), models.Day(to).AddDate(0, 0, 1)
+
+	var balance int64
+	query := "SELECT COALESCE(SUM(amt), 0) FROM `transaction` WHERE account_id = ? AND dt < ?"
+	if err := q.QueryRow(query, accountID, from).Scan(&balance); err != nil {
+		return nil, fmt.Errorf("error while computing balance before %s: %v", from.Format(time.DateOnly), err)
+	}
+
+	query = "SELECT dt, amt FROM `transaction` WHERE account_id = ? AND dt >= ? AND dt < ? ORDER BY dt"
+	rows, err := q.Query(query, accountID, from, end)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading transactions: %v", err)
+	}
+	defer rows.Close()
+
+	balances := make([]models.DailyBalance, 0, models.Days(from, to))
+	day := from
+	for rows.Next() {
+		var dateTime time.Time
+		var amount int64
+		if err := rows.Scan(&dateTime, &amount); err != nil {
+			return nil, err
+		}
+		for ; !day.AddDate(0, 0, 1).After(dateTime); day = day.AddDate(0, 0, 1) {
+			balances = append(balances, models.DailyBalance{AccountID: accountID, Date: day, Amount: balance})
+		}
+		balance += amount
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for ; day.Before(end); day = day.AddDate(0, 0, 1) {
+		balances = append(balances, models.DailyBalance{AccountID: accountID, Date: day, Amount: balance})
+	}
+	return balances, nil
+}
+
+// GetByAccountID returns the end-of-day balances of an account stored from
+// from to to, both inclusive, oldest first. Days never recorded are missing.
+func (repo *DailyBalanceRepository) GetByAccountID(accountID int64, from time.Time, to time.Time) ([]models.DailyBalance, error) {
+	query := "SELECT account_id, balance_date, amt FROM daily_balance WHERE account_id = ? AND balance_date >= ? AND balance_date <= ? ORDER BY balance_date"
+	rows, err := repo.DB.Query(query, accountID, models.Day(from), models.Day(to))
+	if err != nil {
+		return nil, fmt.Errorf("error while reading daily balances: %v", err)
+	}
+	defer rows.Close()
+
+	var balances []models.DailyBalance
+	for rows.Next() {
+		var balance models.DailyBalance
+		if err := rows.Scan(&balance.AccountID, &balance.Date, &balance.Amount); err != nil {
+			return nil, err
+		}
+		balances = append(balances, balance)
+	}
+	return balances, rows.Err()
+}
+
+// shift adds amount to the stored end-of-day balances of an account from day
+// on, for a transaction posted after they were recorded. Callers hold the
+// account row lock.
+func (repo *DailyBalanceRepository) shift(q storage.Querier, accountID int64, day time.Time, amount int64) error {
+	query := "UPDATE daily_balance SET amt = amt + ? WHERE account_id = ? AND balance_date >= ?"
+	if _, err := q.Exec(query, amount, accountID, models.Day(day)); err != nil {
+		return fmt.Errorf("error while updating daily balances: %v", err)
+	}
+	return nil
+}
+
+// shiftDays is shift for the transactions of a batch, given the amount they
+// add per day.
+func (repo *DailyBalanceRepository) shiftDays(q storage.Querier, accountID int64, amounts map[time.Time]int64) error {
+	days := slices.SortedFunc(maps.Keys(amounts), time.Time.Compare)
+	if len(days) == 0 {
+		return nil
+	}
+	// Batches mostly load days not recorded yet, which need no update.
+	var stored int64
+	query := "SELECT COUNT(*) FROM daily_balance WHERE account_id = ? AND balance_date >= ?"
+	if err := q.QueryRow(query, accountID, days[0]).Scan(&stored); err != nil {
+		return fmt.Errorf("error while reading daily balances: %v", err)
+	}
+	if stored == 0 {
+		return nil
+	}
+	for _, day := range days {
+		if err := repo.shift(q, accountID, day, amounts[day]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
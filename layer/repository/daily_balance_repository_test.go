@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"storichallenge_layer/models"
+)
+
+func day(month time.Month, d int) time.Time {
+	return time.Date(2026, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+// checkDailyBalances checks the stored end-of-day balances of an account from
+// from on against want, and that they agree with the computed ones.
+func (store *testStore) checkDailyBalances(tb testing.TB, accountID int64, from time.Time, want []int64) {
+	tb.Helper()
+	to := from.AddDate(0, 0, len(want)-1)
+	stored, err := store.Transactions.DailyBalanceRepo.GetByAccountID(accountID, from, to)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	computed, err := store.Transactions.DailyBalanceRepo.Compute(accountID, from, to)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if !slices.Equal(amounts(stored), want) {
+		tb.Errorf("stored balances from %s = %v, want %v", from.Format(time.DateOnly), amounts(stored), want)
+	}
+	if !slices.Equal(amounts(computed), want) {
+		tb.Errorf("computed balances from %s = %v, want %v", from.Format(time.DateOnly), amounts(computed), want)
+	}
+	for i, balance := range stored {
+		if want := from.AddDate(0, 0, i); !balance.Date.Equal(want) {
+			tb.Errorf("balance %d is for %s, want %s", i, balance.Date, want)
+		}
+	}
+}
+
+func amounts(balances []models.DailyBalance) []int64 {
+	var amounts []int64
+	for _, balance := range balances {
+		amounts = append(amounts, balance.Amount)
+	}
+	return amounts
+}
+
+func TestDailyBalanceRecord(t *testing.T) {
+	store := newTestStore(t)
+	dailyBalances := store.Transactions.DailyBalanceRepo
+	cst := time.FixedZone("CST", -6*60*60)
+	accountID := store.newAccount(t, 0, day(3, 1))
+	store.post(t, accountID, 500_00, time.Date(2026, 2, 27, 12, 0, 0, 0, time.UTC))
+	store.post(t, accountID, 1000_00, time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC))
+	// The evening of March 3 in Mexico City is March 4 in UTC.
+	store.post(t, accountID, -200_00, time.Date(2026, 3, 3, 20, 0, 0, 0, cst))
+	store.post(t, accountID, -50_00, time.Date(2026, 3, 4, 23, 59, 59, 0, time.UTC))
+	store.post(t, accountID, -25_00, day(3, 6))
+
+	balances, err := dailyBalances.Record(accountID, day(3, 1), day(3, 6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int64{500_00, 1500_00, 1500_00, 1250_00, 1250_00, 1225_00}
+	if !slices.Equal(amounts(balances), want) {
+		t.Errorf("recorded %v, want %v", amounts(balances), want)
+	}
+	store.checkDailyBalances(t, accountID, day(3, 1), want)
+
+	// Recording again, over a wider range, replaces what was stored.
+	if _, err := dailyBalances.Record(accountID, day(2, 28), day(3, 7)); err != nil {
+		t.Fatal(err)
+	}
+	store.checkDailyBalances(t, accountID, day(2, 28), append([]int64{500_00}, append(want, 1225_00)...))
+
+	if got := models.AverageDailyBalance(balances); got != 1204_17 {
+		t.Errorf("AverageDailyBalance = %d, want 1204_17", got)
+	}
+}
+
+func TestDailyBalanceBackdatedPosting(t *testing.T) {
+	store := newTestStore(t)
+	accountID := store.newAccount(t, 0, day(3, 1))
+	store.post(t, accountID, 1000_00, day(3, 1))
+	if _, err := store.Transactions.DailyBalanceRepo.Record(accountID, day(3, 1), day(3, 5)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Backdated into recorded days, one by one and in a batch.
+	store.post(t, accountID, -100_00, time.Date(2026, 3, 3, 15, 0, 0, 0, time.UTC))
+	store.checkDailyBalances(t, accountID, day(3, 1), []int64{1000_00, 1000_00, 900_00, 900_00, 900_00})
+
+	var batch []models.Transaction
+	for _, posting := range []struct {
+		amount int64
+		at     time.Time
+	}{
+		{-10_00, time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)},
+		{-20_00, time.Date(2026, 3, 4, 8, 0, 0, 0, time.UTC)},
+		{-5_00, time.Date(2026, 3, 4, 9, 0, 0, 0, time.UTC)},
+		// After the recorded days, where there is nothing to update.
+		{-1_00, time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC)},
+	} {
+		transaction, err := models.NewTransaction(posting.amount, posting.at, accountID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		batch = append(batch, transaction)
+	}
+	if err := store.Transactions.CreateBatch(batch); err != nil {
+		t.Fatal(err)
+	}
+	store.checkDailyBalances(t, accountID, day(3, 1), []int64{1000_00, 990_00, 890_00, 865_00, 865_00})
+
+	stored, err := store.Transactions.DailyBalanceRepo.GetByAccountID(accountID, day(3, 6), day(3, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 0 {
+		t.Errorf("days never recorded have balances %v", amounts(stored))
+	}
+	store.checkLedger(t)
+}
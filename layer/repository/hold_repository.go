@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"storichallenge_layer/models"
+	"storichallenge_layer/storage"
+	"time"
+)
+
+const holdColumns = "id, account_id, amt, captured_amt, txn_type, description, merchant, mcc, channel, location, " +
+	"authorized_at, expires_at, status, resolved_at, transaction_id"
+
+type HoldRepository struct {
+	DB              *storage.DB
+	AccountRepo     *AccountRepository
+	TransactionRepo *TransactionRepository
+}
+
+// Create stores a pending hold. The account must accept the debit and have
+// the funds for it, counting the holds already pending, otherwise nothing is
+// stored and models.ErrInsufficientFunds is returned.
+func (repo *HoldRepository) Create(hold models.Hold) (models.Hold, error) {
+	err := repo.DB.WithTx(func(tx *storage.Tx) error {
+		// Locks the account row, see AccountRepository.forPosting, so that
+		// concurrent holds see each other.
+		if err := repo.AccountRepo.updateCurrentBalanceAmountArithmetrically(tx, hold.AccountID, 0); err != nil {
+			return err
+		}
+
+		var err error
+		query := "INSERT INTO authorization_hold (account_id, amt, txn_type, description, merchant, mcc, channel, location, authorized_at, expires_at, status) " +
+			"VALUES (?,?,?,?,?,?,?,?,?,?,?)"
+		hold.ID, err = tx.InsertID(query, hold.AccountID, hold.Amount, hold.Type, hold.Description, hold.Merchant, hold.MCC, hold.Channel,
+			hold.Location, hold.AuthorizedAt, hold.ExpiresAt, hold.Status)
+		if err != nil {
+			return fmt.Errorf("error while creating authorization hold: %v", err)
+		}
+
+		account, err := repo.AccountRepo.forPosting(tx, hold.AccountID, hold.AuthorizedAt)
+		if err != nil {
+			return err
+		}
+		if err := account.Status.Allows(-hold.Amount); err != nil {
+			return fmt.Errorf("error while authorizing on account %d: %w", hold.AccountID, err)
+		}
+		if account.AvailableFunds() < 0 {
+			return fmt.Errorf("error while authorizing on account %d: %w", hold.AccountID, models.ErrInsufficientFunds)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.Hold{}, err
+	}
+	return hold, nil
+}
+
+// Capture settles amount cents of a pending hold, or all of it when amount is
+// 0, as a transaction dated at, releasing the rest.
+func (repo *HoldRepository) Capture(holdID int64, amount int64, at time.Time) (models.Hold, error) {
+	var hold models.Hold
+	err := repo.DB.WithTx(func(tx *storage.Tx) error {
+		var err error
+		hold, err = repo.getByID(tx, holdID)
+		if err != nil {
+			return err
+		}
+		transaction, err := hold.Capture(amount, at)
+		if err != nil {
+			return err
+		}
+		// The hold is resolved first, so that its amount is not counted twice
+		// when the transaction is checked against the available funds.
+		if err := repo.resolve(tx, &hold, models.HOLD_STATUS_CAPTURED, at); err != nil {
+			return err
+		}
+		transactionID, err := repo.TransactionRepo.create(tx, transaction)
+		if err != nil {
+			return err
+		}
+
+		query := "UPDATE authorization_hold SET captured_amt = ?, transaction_id = ? WHERE id = ?"
+		if _, err := tx.Exec(query, -transaction.Amount, transactionID, holdID); err != nil {
+			return fmt.Errorf("error while capturing authorization hold: %v", err)
+		}
+		hold.CapturedAmount = -transaction.Amount
+		hold.TransactionID = transactionID
+		return nil
+	})
+	if err != nil {
+		return models.Hold{}, err
+	}
+	return hold, nil
+}
+
+// Release drops a pending hold without posting anything.
+func (repo *HoldRepository) Release(holdID int64, at time.Time) (models.Hold, error) {
+	var hold models.Hold
+	err := repo.DB.WithTx(func(tx *storage.Tx) error {
+		var err error
+		hold, err = repo.getByID(tx, holdID)
+		if err != nil {
+			return err
+		}
+		return repo.resolve(tx, &hold, models.HOLD_STATUS_RELEASED, at)
+	})
+	if err != nil {
+		return models.Hold{}, err
+	}
+	return hold, nil
+}
+
+// resolve moves a pending hold to status. It fails with models.ErrHoldResolved
+// when the hold is no longer pending, e.g. another request resolved it first.
+func (repo *HoldRepository) resolve(tx *storage.Tx, hold *models.Hold, status models.HoldStatus, at time.Time) error {
+	query := "UPDATE authorization_hold SET status = ?, resolved_at = ? WHERE id = ? AND status = ?"
+	result, err := tx.Exec(query, status, at, hold.ID, models.HOLD_STATUS_PENDING)
+	if err != nil {
+		return fmt.Errorf("error while updating authorization hold: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error while updating authorization hold: %v", err)
+	}
+	if rowsAffected == 0 {
+		return models.ErrHoldResolved
+	}
+	hold.Status = status
+	hold.ResolvedAt = at
+	return nil
+}
+
+// ExpireDue marks expired every pending hold whose expiry is not after at and
+// returns how many there were.
+func (repo *HoldRepository) ExpireDue(at time.Time) (int64, error) {
+	query := "UPDATE authorization_hold SET status = ?, resolved_at = expires_at WHERE status = ? AND expires_at <= ?"
+	result, err := repo.DB.Exec(query, models.HOLD_STATUS_EXPIRED, models.HOLD_STATUS_PENDING, at)
+	if err != nil {
+		return 0, fmt.Errorf("error while expiring authorization holds: %v", err)
+	}
+	expired, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error while expiring authorization holds: %v", err)
+	}
+	return expired, nil
+}
+
+func (repo *HoldRepository) GetByID(holdID int64) (models.Hold, error) {
+	return repo.getByID(repo.DB, holdID)
+}
+
+func (repo *HoldRepository) getByID(q storage.Querier, holdID int64) (models.Hold, error) {
+	query := "SELECT " + holdColumns + " FROM authorization_hold WHERE id = ?"
+	hold, err := scanHold(q.QueryRow(query, holdID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Hold{}, models.ErrHoldNotFound
+		}
+		return models.Hold{}, fmt.Errorf("error while reading authorization hold: %v", err)
+	}
+	return hold, nil
+}
+
+// GetPendingByAccountID returns the pending holds of an account, oldest
+// first.
+func (repo *HoldRepository) GetPendingByAccountID(accountID int64) ([]models.Hold, error) {
+	query := "SELECT " + holdColumns + " FROM authorization_hold WHERE account_id = ? AND status = ? ORDER BY authorized_at, id"
+	rows, err := repo.DB.Query(query, accountID, models.HOLD_STATUS_PENDING)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading authorization holds: %v", err)
+	}
+	defer rows.Close()
+
+	var holds []models.Hold
+	for rows.Next() {
+		hold, err := scanHold(rows)
+		if err != nil {
+			return nil, err
+		}
+		holds = append(holds, hold)
+	}
+	return holds, rows.Err()
+}
+
+func scanHold(row interface{ Scan(dest ...any) error }) (models.Hold, error) {
+	var hold models.Hold
+	var resolvedAt sql.NullTime
+	var transactionID sql.NullInt64
+	err := row.Scan(&hold.ID, &hold.AccountID, &hold.Amount, &hold.CapturedAmount, &hold.Type, &hold.Description, &hold.Merchant,
+		&hold.MCC, &hold.Channel, &hold.Location, &hold.AuthorizedAt, &hold.ExpiresAt, &hold.Status, &resolvedAt, &transactionID)
+	hold.ResolvedAt = resolvedAt.Time
+	hold.TransactionID = transactionID.Int64
+	return hold, err
+}
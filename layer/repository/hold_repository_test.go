@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"storichallenge_layer/models"
+)
+
+func TestExpiredHoldsAreNotHeld(t *testing.T) {
+	store := newTestStore(t)
+	now := time.Now().UTC()
+	from := store.newAccount(t, 1000_00, now)
+	to := store.newAccount(t, 0, now)
+
+	// Expired yesterday, but ExpireDue has not run yet.
+	expired, err := models.NewHold(from, 600_00, models.TransactionDetails{}, now.AddDate(0, 0, -2), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expired, err = store.Holds.Create(expired); err != nil {
+		t.Fatal(err)
+	}
+	pending, err := models.NewHold(from, 300_00, models.TransactionDetails{}, now, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Holds.Create(pending); err != nil {
+		t.Fatal(err)
+	}
+
+	account, err := store.Accounts.GetByID(from, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.HeldAmount != 300_00 || account.AvailableFunds() != 700_00 {
+		t.Errorf("held %d with %d available, want 300_00 with 700_00", account.HeldAmount, account.AvailableFunds())
+	}
+	accounts, err := store.Accounts.GetByCustomerID(store.customerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if accounts[0].HeldAmount != 300_00 {
+		t.Errorf("listed account holds %d, want 300_00", accounts[0].HeldAmount)
+	}
+
+	// Posting sees the same funds as the account does.
+	if _, err := store.Transfers.Create(newTestTransfer(t, from, to, 700_00, "ref-1", now)); err != nil {
+		t.Fatal(err)
+	}
+	store.checkBalance(t, from, 300_00)
+	if _, err := store.Transfers.Create(newTestTransfer(t, from, to, 1, "ref-2", now)); err != nil {
+		checkErr(t, err, models.ErrInsufficientFunds)
+	} else {
+		t.Error("transferred past the pending hold")
+	}
+
+	_, err = store.Holds.Capture(expired.ID, 0, now)
+	checkErr(t, err, models.ErrHoldExpired)
+	store.checkLedger(t)
+}
+
+// TestHoldExpiryAcrossZones checks holds authorized in zones far from UTC
+// against times given in yet another zone: clock readings differ, instants
+// do not.
+func TestHoldExpiryAcrossZones(t *testing.T) {
+	store := newTestStore(t)
+	kiribati := time.FixedZone("LINT", 14*60*60)
+	cst := time.FixedZone("CST", -6*60*60)
+	now := time.Now().Truncate(time.Second)
+	accountID := store.newAccount(t, 1000_00, now)
+
+	// Expired an hour ago, though its clock reads later than UTC now.
+	expired, err := models.NewHold(accountID, 400_00, models.TransactionDetails{}, now.Add(-25*time.Hour).In(kiribati), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Holds.Create(expired); err != nil {
+		t.Fatal(err)
+	}
+	// Expires in an hour, though its clock reads earlier than UTC now.
+	pending, err := models.NewHold(accountID, 100_00, models.TransactionDetails{}, now.Add(-23*time.Hour).In(cst), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Holds.Create(pending); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		at   time.Time
+		want int64
+	}{
+		{now.In(kiribati), 100_00},
+		{now.In(cst), 100_00},
+		{now.Add(-2 * time.Hour).In(cst), 500_00},
+		{now.Add(2 * time.Hour).In(kiribati), 0},
+	}
+	for _, test := range tests {
+		account, err := store.Accounts.forPosting(store.DB, accountID, test.at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if account.HeldAmount != test.want {
+			t.Errorf("held at %s = %d, want %d", test.at, account.HeldAmount, test.want)
+		}
+	}
+}
@@ -0,0 +1,320 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"storichallenge_layer/models"
+	"storichallenge_layer/storage"
+	"time"
+)
+
+const installmentColumns = "id, plan_id, account_id, number, due_dt, month, amt, status, transaction_id"
+
+type InstallmentRepository struct {
+	DB              *storage.DB
+	AccountRepo     *AccountRepository
+	TransactionRepo *TransactionRepository
+}
+
+// Create stores a plan with its schedule and charges the installments already
+// due, the first one at least. The whole purchase must fit in the available
+// credit of the account, otherwise nothing is stored.
+func (repo *InstallmentRepository) Create(plan models.InstallmentPlan) (models.InstallmentPlan, error) {
+	err := repo.DB.WithTx(func(tx *storage.Tx) error {
+		var err error
+		query := "INSERT INTO installment_plan (account_id, purchase_dt, amt, months, status) VALUES (?,?,?,?,?)"
+		plan.ID, err = tx.InsertID(query, plan.AccountID, plan.PurchaseDate, plan.Amount, plan.Months, plan.Status)
+		if err != nil {
+			return fmt.Errorf("error while creating installment plan: %v", err)
+		}
+
+		for i := range plan.Installments {
+			installment := &plan.Installments[i]
+			installment.PlanID = plan.ID
+			query := "INSERT INTO installment (plan_id, account_id, number, due_dt, month, amt, status) VALUES (?,?,?,?,?,?,?)"
+			installment.ID, err = tx.InsertID(query, installment.PlanID, installment.AccountID, installment.Number, installment.DueDate,
+				installment.Month, installment.Amount, installment.Status)
+			if err != nil {
+				return fmt.Errorf("error while creating installment: %v", err)
+			}
+		}
+
+		for i := range plan.Installments {
+			if plan.Installments[i].DueDate.After(plan.PurchaseDate) {
+				break
+			}
+			if err := repo.post(tx, &plan.Installments[i]); err != nil {
+				return err
+			}
+		}
+
+		// Charging the first installment locked the account row, see
+		// AccountRepository.forPosting.
+		account, err := repo.AccountRepo.forPosting(tx, plan.AccountID, time.Now())
+		if err != nil {
+			return err
+		}
+		if err := account.Allows(models.Transaction{AccountID: plan.AccountID, Amount: -plan.Amount}); err != nil {
+			return fmt.Errorf("error while creating installment plan on account %d: %w", plan.AccountID, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.InstallmentPlan{}, err
+	}
+	return plan, nil
+}
+
+// post charges a pending installment inside tx.
+func (repo *InstallmentRepository) post(tx *storage.Tx, installment *models.Installment) error {
+	transaction, err := installment.Transaction()
+	if err != nil {
+		return err
+	}
+	transactionID, err := repo.TransactionRepo.create(tx, transaction)
+	if err != nil {
+		return err
+	}
+	return repo.markPosted(tx, installment, transactionID)
+}
+
+// markPosted records that transactionID charged installment. It fails when the
+// installment is no longer pending, e.g. another run charged it first.
+func (repo *InstallmentRepository) markPosted(tx *storage.Tx, installment *models.Installment, transactionID int64) error {
+	query := "UPDATE installment SET status = ?, transaction_id = ? WHERE id = ? AND status = ?"
+	result, err := tx.Exec(query, models.INSTALLMENT_STATUS_POSTED, transactionID, installment.ID, models.INSTALLMENT_STATUS_PENDING)
+	if err != nil {
+		return fmt.Errorf("error while updating installment: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error while updating installment: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("installment %d of plan %d is no longer pending", installment.Number, installment.PlanID)
+	}
+	installment.Status = models.INSTALLMENT_STATUS_POSTED
+	installment.TransactionID = transactionID
+	return nil
+}
+
+// PostDue charges every pending installment due up to the end of date, each
+// in its own database transaction, and returns the ones charged.
+func (repo *InstallmentRepository) PostDue(date time.Time) ([]models.Installment, error) {
+	end := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+	query := "SELECT " + installmentColumns + " FROM installment WHERE status = ? AND due_dt < ? ORDER BY due_dt, id"
+	due, err := repo.list(repo.DB, query, models.INSTALLMENT_STATUS_PENDING, end)
+	if err != nil {
+		return nil, err
+	}
+
+	var posted []models.Installment
+	for _, installment := range due {
+		err := repo.DB.WithTx(func(tx *storage.Tx) error {
+			return repo.post(tx, &installment)
+		})
+		if err != nil {
+			return posted, fmt.Errorf("error while charging installment %d of plan %d: %w", installment.Number, installment.PlanID, err)
+		}
+		posted = append(posted, installment)
+	}
+	return posted, nil
+}
+
+// PayOff charges every pending installment of an active plan at once, in a
+// single transaction dated at, and marks the plan paid off.
+func (repo *InstallmentRepository) PayOff(planID int64, at time.Time) (models.InstallmentPlan, error) {
+	var plan models.InstallmentPlan
+	err := repo.DB.WithTx(func(tx *storage.Tx) error {
+		var err error
+		plan, err = repo.close(tx, planID, models.INSTALLMENT_PLAN_STATUS_PAID_OFF, at)
+		if err != nil {
+			return err
+		}
+
+		outstanding := plan.Outstanding()
+		if outstanding == 0 {
+			return nil
+		}
+		transaction, err := models.NewSystemTransaction(plan.AccountID, models.TRANSACTION_TYPE_INSTALLMENT, outstanding, at, "Installment plan payoff")
+		if err != nil {
+			return err
+		}
+		transactionID, err := repo.TransactionRepo.create(tx, transaction)
+		if err != nil {
+			return err
+		}
+		for i := range plan.Installments {
+			if plan.Installments[i].Status != models.INSTALLMENT_STATUS_PENDING {
+				continue
+			}
+			if err := repo.markPosted(tx, &plan.Installments[i], transactionID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return models.InstallmentPlan{}, err
+	}
+	return plan, nil
+}
+
+// Cancel drops the pending installments of an active plan and credits back
+// the ones already charged, in a single transaction dated at.
+func (repo *InstallmentRepository) Cancel(planID int64, at time.Time) (models.InstallmentPlan, error) {
+	var plan models.InstallmentPlan
+	err := repo.DB.WithTx(func(tx *storage.Tx) error {
+		var err error
+		plan, err = repo.close(tx, planID, models.INSTALLMENT_PLAN_STATUS_CANCELLED, at)
+		if err != nil {
+			return err
+		}
+
+		query := "UPDATE installment SET status = ? WHERE plan_id = ? AND status = ?"
+		_, err = tx.Exec(query, models.INSTALLMENT_STATUS_CANCELLED, planID, models.INSTALLMENT_STATUS_PENDING)
+		if err != nil {
+			return fmt.Errorf("error while cancelling installments: %v", err)
+		}
+
+		var charged int64
+		for i := range plan.Installments {
+			switch plan.Installments[i].Status {
+			case models.INSTALLMENT_STATUS_PENDING:
+				plan.Installments[i].Status = models.INSTALLMENT_STATUS_CANCELLED
+			case models.INSTALLMENT_STATUS_POSTED:
+				charged += plan.Installments[i].Amount
+			}
+		}
+		if charged == 0 {
+			return nil
+		}
+		refund, err := models.NewTransactionWithDetails(charged, at, plan.AccountID, models.TransactionDetails{
+			Type:        models.TRANSACTION_TYPE_INSTALLMENT,
+			Description: "Installment plan cancellation",
+			Channel:     models.CHANNEL_SYSTEM,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = repo.TransactionRepo.create(tx, refund)
+		return err
+	})
+	if err != nil {
+		return models.InstallmentPlan{}, err
+	}
+	return plan, nil
+}
+
+// close moves an active plan to status and returns it with its installments
+// as they were before.
+func (repo *InstallmentRepository) close(tx *storage.Tx, planID int64, status models.InstallmentPlanStatus, at time.Time) (models.InstallmentPlan, error) {
+	query := "UPDATE installment_plan SET status = ?, closed_at = ? WHERE id = ? AND status = ?"
+	result, err := tx.Exec(query, status, at, planID, models.INSTALLMENT_PLAN_STATUS_ACTIVE)
+	if err != nil {
+		return models.InstallmentPlan{}, fmt.Errorf("error while updating installment plan: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return models.InstallmentPlan{}, fmt.Errorf("error while updating installment plan: %v", err)
+	}
+	if rowsAffected == 0 {
+		if _, err := repo.getByID(tx, planID); err != nil {
+			return models.InstallmentPlan{}, err
+		}
+		return models.InstallmentPlan{}, models.ErrInstallmentPlanInactive
+	}
+
+	plan, err := repo.getByID(tx, planID)
+	if err != nil {
+		return models.InstallmentPlan{}, err
+	}
+	plan.ClosedAt = at
+	return plan, nil
+}
+
+// GetByID returns a plan with its installments.
+func (repo *InstallmentRepository) GetByID(planID int64) (models.InstallmentPlan, error) {
+	return repo.getByID(repo.DB, planID)
+}
+
+func (repo *InstallmentRepository) getByID(q storage.Querier, planID int64) (models.InstallmentPlan, error) {
+	query := "SELECT id, account_id, purchase_dt, amt, months, status, closed_at FROM installment_plan WHERE id = ?"
+	plan, err := scanInstallmentPlan(q.QueryRow(query, planID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.InstallmentPlan{}, models.ErrInstallmentPlanNotFound
+		}
+		return models.InstallmentPlan{}, fmt.Errorf("error while reading installment plan: %v", err)
+	}
+
+	query = "SELECT " + installmentColumns + " FROM installment WHERE plan_id = ? ORDER BY number"
+	plan.Installments, err = repo.list(q, query, planID)
+	if err != nil {
+		return models.InstallmentPlan{}, err
+	}
+	return plan, nil
+}
+
+// GetActiveByAccountID returns the active plans of an account with their
+// installments, oldest purchase first.
+func (repo *InstallmentRepository) GetActiveByAccountID(accountID int64) ([]models.InstallmentPlan, error) {
+	query := "SELECT id FROM installment_plan WHERE account_id = ? AND status = ? ORDER BY purchase_dt, id"
+	rows, err := repo.DB.Query(query, accountID, models.INSTALLMENT_PLAN_STATUS_ACTIVE)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading installment plans: %v", err)
+	}
+	var planIDs []int64
+	for rows.Next() {
+		var planID int64
+		if err := rows.Scan(&planID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		planIDs = append(planIDs, planID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var plans []models.InstallmentPlan
+	for _, planID := range planIDs {
+		plan, err := repo.GetByID(planID)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+func (repo *InstallmentRepository) list(q storage.Querier, query string, args ...any) ([]models.Installment, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading installments: %v", err)
+	}
+	defer rows.Close()
+
+	var installments []models.Installment
+	for rows.Next() {
+		var installment models.Installment
+		var transactionID sql.NullInt64
+		err := rows.Scan(&installment.ID, &installment.PlanID, &installment.AccountID, &installment.Number, &installment.DueDate,
+			&installment.Month, &installment.Amount, &installment.Status, &transactionID)
+		if err != nil {
+			return nil, err
+		}
+		installment.TransactionID = transactionID.Int64
+		installments = append(installments, installment)
+	}
+	return installments, rows.Err()
+}
+
+func scanInstallmentPlan(row interface{ Scan(dest ...any) error }) (models.InstallmentPlan, error) {
+	var plan models.InstallmentPlan
+	var closedAt sql.NullTime
+	err := row.Scan(&plan.ID, &plan.AccountID, &plan.PurchaseDate, &plan.Amount, &plan.Months, &plan.Status, &closedAt)
+	plan.ClosedAt = closedAt.Time
+	return plan, err
+}
@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"storichallenge_layer/models"
+)
+
+// buyInInstallments stores a plan of amount cents over months on a credit
+// account, purchased at at.
+func (store *testStore) buyInInstallments(tb testing.TB, account models.Account, amount int64, months int, at time.Time) (models.InstallmentPlan, error) {
+	tb.Helper()
+	plan, err := models.NewInstallmentPlan(account, amount, months, at)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return store.Installments.Create(plan)
+}
+
+// checkAvailableCredit checks the credit an account has left, pending
+// installments included.
+func (store *testStore) checkAvailableCredit(tb testing.TB, accountID int64, want int64) {
+	tb.Helper()
+	account, err := store.Accounts.GetByID(accountID, false, false)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if available := account.AvailableCredit(); available != want {
+		tb.Errorf("account %d available credit = %d, want %d", accountID, available, want)
+	}
+}
+
+// checkInstallments checks the status of every installment of a plan as
+// stored, in order.
+func (store *testStore) checkInstallments(tb testing.TB, planID int64, want ...models.InstallmentStatus) models.InstallmentPlan {
+	tb.Helper()
+	plan, err := store.Installments.GetByID(planID)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if len(plan.Installments) != len(want) {
+		tb.Fatalf("plan %d has %d installments, want %d", planID, len(plan.Installments), len(want))
+	}
+	for i, installment := range plan.Installments {
+		if installment.Status != want[i] {
+			tb.Errorf("installment %d of plan %d is %s, want %s", installment.Number, planID, installment.Status, want[i])
+		}
+		if (installment.Status == models.INSTALLMENT_STATUS_POSTED) != (installment.TransactionID != 0) {
+			tb.Errorf("installment %d of plan %d is %s with transaction %d", installment.Number, planID, installment.Status, installment.TransactionID)
+		}
+	}
+	return plan
+}
+
+func TestInstallmentSchedule(t *testing.T) {
+	store := newTestStore(t)
+	account := store.newCreditAccount(t, 2000_00)
+	purchase := time.Date(2026, 1, 31, 18, 0, 0, 0, time.UTC)
+
+	plan, err := store.buyInInstallments(t, account, 1000_01, 3, purchase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		due    time.Time
+		month  string
+		amount int64
+	}{
+		{purchase, "2026/01", 333_35},
+		{time.Date(2026, 2, 28, 18, 0, 0, 0, time.UTC), "2026/02", 333_33},
+		{time.Date(2026, 3, 31, 18, 0, 0, 0, time.UTC), "2026/03", 333_33},
+	}
+	stored := store.checkInstallments(t, plan.ID, models.INSTALLMENT_STATUS_POSTED, models.INSTALLMENT_STATUS_PENDING, models.INSTALLMENT_STATUS_PENDING)
+	for i, installment := range stored.Installments {
+		if !installment.DueDate.Equal(want[i].due) || installment.Month != want[i].month || installment.Amount != want[i].amount {
+			t.Errorf("installment %d due %s in %s for %d, want %s in %s for %d", installment.Number,
+				installment.DueDate, installment.Month, installment.Amount, want[i].due, want[i].month, want[i].amount)
+		}
+	}
+	if stored.Installments[0].TransactionID != plan.Installments[0].TransactionID {
+		t.Errorf("first installment charged by transaction %d, Create returned %d",
+			stored.Installments[0].TransactionID, plan.Installments[0].TransactionID)
+	}
+	store.checkBalance(t, account.ID, -333_35)
+	store.checkAvailableCredit(t, account.ID, 999_99)
+
+	// Due dates are whole days: the second installment is due all of Feb 28.
+	posted, err := store.Installments.PostDue(time.Date(2026, 2, 27, 23, 0, 0, 0, time.UTC))
+	if err != nil || len(posted) != 0 {
+		t.Fatalf("PostDue before the due date charged %v, %v", posted, err)
+	}
+	posted, err = store.Installments.PostDue(time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posted) != 1 || posted[0].Number != 2 {
+		t.Fatalf("PostDue charged %v, want installment 2", posted)
+	}
+	posted, err = store.Installments.PostDue(time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC))
+	if err != nil || len(posted) != 0 {
+		t.Errorf("PostDue run twice charged %v, %v", posted, err)
+	}
+	store.checkInstallments(t, plan.ID, models.INSTALLMENT_STATUS_POSTED, models.INSTALLMENT_STATUS_POSTED, models.INSTALLMENT_STATUS_PENDING)
+	store.checkBalance(t, account.ID, -666_68)
+	store.checkAvailableCredit(t, account.ID, 999_99)
+
+	active, err := store.Installments.GetActiveByAccountID(account.ID)
+	if err != nil || len(active) != 1 || active[0].ID != plan.ID || active[0].Outstanding() != 333_33 {
+		t.Errorf("active plans %v, %v, want plan %d with 333_33 outstanding", active, err, plan.ID)
+	}
+	store.checkLedger(t)
+}
+
+func TestInstallmentCreditCheck(t *testing.T) {
+	store := newTestStore(t)
+	account := store.newCreditAccount(t, 1000_00)
+	at := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	// The whole purchase must fit, not only the first installment.
+	_, err := store.buyInInstallments(t, account, 1200_00, 12, at)
+	checkErr(t, err, models.ErrCreditLimitExceeded)
+	store.checkBalance(t, account.ID, 0)
+	active, err := store.Installments.GetActiveByAccountID(account.ID)
+	if err != nil || len(active) != 0 {
+		t.Errorf("rejected purchase left plans %v, %v", active, err)
+	}
+
+	if _, err := store.buyInInstallments(t, account, 900_00, 3, at); err != nil {
+		t.Fatal(err)
+	}
+	store.checkAvailableCredit(t, account.ID, 100_00)
+
+	// Pending installments keep taking up credit.
+	_, err = store.buyInInstallments(t, account, 300_00, 3, at.Add(time.Hour))
+	checkErr(t, err, models.ErrCreditLimitExceeded)
+	purchase, err := models.NewTransaction(-150_00, at.Add(time.Hour), account.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkErr(t, store.Transactions.Create(purchase), models.ErrCreditLimitExceeded)
+	store.checkBalance(t, account.ID, -300_00)
+
+	store.post(t, account.ID, -100_00, at.Add(time.Hour))
+	store.checkAvailableCredit(t, account.ID, 0)
+	store.checkLedger(t)
+}
+
+func TestInstallmentPayOff(t *testing.T) {
+	store := newTestStore(t)
+	account := store.newCreditAccount(t, 2000_00)
+	at := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	plan, err := store.buyInInstallments(t, account, 1200_00, 6, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paidOffAt := at.AddDate(0, 0, 5)
+	paidOff, err := store.Installments.PayOff(plan.ID, paidOffAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paidOff.Status != models.INSTALLMENT_PLAN_STATUS_PAID_OFF || !paidOff.ClosedAt.Equal(paidOffAt) {
+		t.Errorf("plan is %s since %s, want paid_off since %s", paidOff.Status, paidOff.ClosedAt, paidOffAt)
+	}
+	stored := store.checkInstallments(t, plan.ID, models.INSTALLMENT_STATUS_POSTED, models.INSTALLMENT_STATUS_POSTED,
+		models.INSTALLMENT_STATUS_POSTED, models.INSTALLMENT_STATUS_POSTED, models.INSTALLMENT_STATUS_POSTED, models.INSTALLMENT_STATUS_POSTED)
+	if stored.Status != models.INSTALLMENT_PLAN_STATUS_PAID_OFF {
+		t.Errorf("stored plan is %s, want paid_off", stored.Status)
+	}
+	// The rest is charged by a single transaction.
+	payoffID := stored.Installments[1].TransactionID
+	for _, installment := range stored.Installments[2:] {
+		if installment.TransactionID != payoffID {
+			t.Errorf("installment %d charged by transaction %d, want the payoff %d", installment.Number, installment.TransactionID, payoffID)
+		}
+	}
+	store.checkBalance(t, account.ID, -1200_00)
+	store.checkAvailableCredit(t, account.ID, 800_00)
+
+	_, err = store.Installments.PayOff(plan.ID, paidOffAt)
+	checkErr(t, err, models.ErrInstallmentPlanInactive)
+	_, err = store.Installments.Cancel(plan.ID, paidOffAt)
+	checkErr(t, err, models.ErrInstallmentPlanInactive)
+	_, err = store.Installments.PayOff(plan.ID+1, paidOffAt)
+	checkErr(t, err, models.ErrInstallmentPlanNotFound)
+	if posted, err := store.Installments.PostDue(at.AddDate(1, 0, 0)); err != nil || len(posted) != 0 {
+		t.Errorf("PostDue after the payoff charged %v, %v", posted, err)
+	}
+	store.checkBalance(t, account.ID, -1200_00)
+	store.checkLedger(t)
+}
+
+func TestInstallmentCancel(t *testing.T) {
+	store := newTestStore(t)
+	account := store.newCreditAccount(t, 2000_00)
+	at := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	plan, err := store.buyInInstallments(t, account, 900_00, 3, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Installments.PostDue(at.AddDate(0, 1, 0)); err != nil {
+		t.Fatal(err)
+	}
+	store.checkBalance(t, account.ID, -600_00)
+
+	cancelledAt := at.AddDate(0, 1, 2)
+	cancelled, err := store.Installments.Cancel(plan.ID, cancelledAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cancelled.Status != models.INSTALLMENT_PLAN_STATUS_CANCELLED || !cancelled.ClosedAt.Equal(cancelledAt) {
+		t.Errorf("plan is %s since %s, want cancelled since %s", cancelled.Status, cancelled.ClosedAt, cancelledAt)
+	}
+	store.checkInstallments(t, plan.ID, models.INSTALLMENT_STATUS_POSTED, models.INSTALLMENT_STATUS_POSTED, models.INSTALLMENT_STATUS_CANCELLED)
+
+	// The two installments charged are credited back in one transaction.
+	transactions, err := store.Transactions.GetByAccountID(account.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refund := transactions[0] // newest first
+	if refund.Amount != 600_00 || refund.Type != models.TRANSACTION_TYPE_INSTALLMENT || !refund.DateTime.Equal(cancelledAt) {
+		t.Errorf("refund %+v, want an installment credit of 600_00 at %s", refund, cancelledAt)
+	}
+	store.checkBalance(t, account.ID, 0)
+	store.checkAvailableCredit(t, account.ID, 2000_00)
+
+	if posted, err := store.Installments.PostDue(at.AddDate(1, 0, 0)); err != nil || len(posted) != 0 {
+		t.Errorf("PostDue after the cancellation charged %v, %v", posted, err)
+	}
+	active, err := store.Installments.GetActiveByAccountID(account.ID)
+	if err != nil || len(active) != 0 {
+		t.Errorf("active plans %v, %v, want none", active, err)
+	}
+
+	// A plan cancelled before anything but the first charge refunds just that.
+	plan, err = store.buyInInstallments(t, account, 300_00, 3, cancelledAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Installments.Cancel(plan.ID, cancelledAt.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	store.checkBalance(t, account.ID, 0)
+	store.checkLedger(t)
+}
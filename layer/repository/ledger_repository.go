@@ -0,0 +1,301 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"storichallenge_layer/models"
+	"storichallenge_layer/storage"
+	"strings"
+)
+
+// LedgerRepository keeps the double-entry journal: one entry per posted
+// transaction, with postings on the account and on a system ledger account.
+// TransactionRepository records the entries in the same database transaction
+// that posts the transactions.
+type LedgerRepository struct {
+	DB          *storage.DB
+	AccountRepo *AccountRepository
+}
+
+// journal records the entries of transactions, which must have their IDs,
+// inside q. Every entry is validated before anything is written.
+func (repo *LedgerRepository) journal(q storage.Querier, transactions []models.Transaction) error {
+	entries := make([]models.JournalEntry, len(transactions))
+	for i, transaction := range transactions {
+		entries[i] = models.NewJournalEntry(transaction)
+		if err := entries[i].Validate(); err != nil {
+			return fmt.Errorf("error while journaling transaction %d: %w", transaction.ID, err)
+		}
+	}
+
+	if len(entries) == 1 {
+		var err error
+		query := "INSERT INTO journal_entry (transaction_id, dt, description) VALUES (?,?,?)"
+		entries[0].ID, err = q.InsertID(query, entries[0].TransactionID, entries[0].DateTime, entries[0].Description)
+		if err != nil {
+			return fmt.Errorf("error while creating journal entry: %v", err)
+		}
+		return insertPostings(q, entries)
+	}
+
+	for start := 0; start < len(entries); start += BULK_INSERT_ROWS {
+		end := min(start+BULK_INSERT_ROWS, len(entries))
+		if err := insertEntries(q, entries[start:end]); err != nil {
+			return err
+		}
+		if err := insertPostings(q, entries[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertEntries inserts entries with one statement and then reads back their
+// IDs through their transaction IDs, which are unique.
+func insertEntries(q storage.Querier, entries []models.JournalEntry) error {
+	var sb strings.Builder
+	sb.WriteString("INSERT INTO journal_entry (transaction_id, dt, description) VALUES ")
+	args := make([]any, 0, len(entries)*3)
+	index := make(map[int64]int, len(entries))
+	for i, entry := range entries {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString("(?,?,?)")
+		args = append(args, entry.TransactionID, entry.DateTime, entry.Description)
+		index[entry.TransactionID] = i
+	}
+	if _, err := q.Exec(sb.String(), args...); err != nil {
+		return fmt.Errorf("error while creating journal entries: %v", err)
+	}
+
+	query := "SELECT id, transaction_id FROM journal_entry WHERE transaction_id IN (?" + strings.Repeat(",?", len(entries)-1) + ")"
+	transactionIDs := make([]any, len(entries))
+	for i, entry := range entries {
+		transactionIDs[i] = entry.TransactionID
+	}
+	rows, err := q.Query(query, transactionIDs...)
+	if err != nil {
+		return fmt.Errorf("error while reading journal entries: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var entryID, transactionID int64
+		if err := rows.Scan(&entryID, &transactionID); err != nil {
+			return err
+		}
+		entries[index[transactionID]].ID = entryID
+	}
+	return rows.Err()
+}
+
+func insertPostings(q storage.Querier, entries []models.JournalEntry) error {
+	var sb strings.Builder
+	sb.WriteString("INSERT INTO posting (entry_id, ledger_account, account_id, amt) VALUES ")
+	var args []any
+	for _, entry := range entries {
+		for _, posting := range entry.Postings {
+			if len(args) > 0 {
+				sb.WriteString(",")
+			}
+			sb.WriteString("(?,?,?,?)")
+			args = append(args, entry.ID, posting.LedgerAccount, nullID(posting.AccountID), posting.Amount)
+		}
+	}
+	if _, err := q.Exec(sb.String(), args...); err != nil {
+		return fmt.Errorf("error while creating postings: %v", err)
+	}
+	return nil
+}
+
+// journalUnposted records the entries of the transactions of an account that
+// have none yet, inside q.
+func (repo *LedgerRepository) journalUnposted(q storage.Querier, accountID int64) (int, error) {
+	query := "SELECT " + transactionColumns + " FROM `transaction` WHERE account_id = ? AND NOT EXISTS " +
+		"(SELECT 1 FROM journal_entry WHERE journal_entry.transaction_id = `transaction`.id) ORDER BY id"
+	return repo.journalSelected(q, query, accountID)
+}
+
+// journalInserted records the entries of the transactions of an account with
+// an ID after lastID, inside q.
+func (repo *LedgerRepository) journalInserted(q storage.Querier, accountID int64, lastID int64) (int, error) {
+	query := "SELECT " + transactionColumns + " FROM `transaction` WHERE account_id = ? AND id > ? ORDER BY id"
+	return repo.journalSelected(q, query, accountID, lastID)
+}
+
+// journalSelected records the entries of the transactions query selects.
+func (repo *LedgerRepository) journalSelected(q storage.Querier, query string, args ...any) (int, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("error while reading transactions to journal: %v", err)
+	}
+	var transactions []models.Transaction
+	for rows.Next() {
+		transaction, err := scanTransaction(rows)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		transactions = append(transactions, transaction)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	if len(transactions) == 0 {
+		return 0, nil
+	}
+	return len(transactions), repo.journal(q, transactions)
+}
+
+// Backfill journals the transactions posted before the ledger existed, for
+// one account or for every account when accountID is 0, and returns how many
+// it journaled. Each account is done in its own database transaction, with
+// its row locked so that no batch journals the same transactions meanwhile.
+func (repo *LedgerRepository) Backfill(accountID int64) (int, error) {
+	accountIDs := []int64{accountID}
+	if accountID == 0 {
+		accounts, err := repo.AccountRepo.GetAll()
+		if err != nil {
+			return 0, err
+		}
+		accountIDs = accountIDs[:0]
+		for _, account := range accounts {
+			accountIDs = append(accountIDs, account.ID)
+		}
+	}
+
+	journaled := 0
+	for _, accountID := range accountIDs {
+		var n int
+		err := repo.DB.WithTx(func(tx *storage.Tx) error {
+			if err := repo.AccountRepo.updateCurrentBalanceAmountArithmetrically(tx, accountID, 0); err != nil {
+				return err
+			}
+			var err error
+			n, err = repo.journalUnposted(tx, accountID)
+			return err
+		})
+		if err != nil {
+			return journaled, fmt.Errorf("error while journaling account %d: %w", accountID, err)
+		}
+		journaled += n
+	}
+	return journaled, nil
+}
+
+// GetEntryByTransactionID returns the journal entry of a transaction with its
+// postings.
+func (repo *LedgerRepository) GetEntryByTransactionID(transactionID int64) (models.JournalEntry, error) {
+	var entry models.JournalEntry
+	query := "SELECT id, transaction_id, dt, description FROM journal_entry WHERE transaction_id = ?"
+	err := repo.DB.QueryRow(query, transactionID).Scan(&entry.ID, &entry.TransactionID, &entry.DateTime, &entry.Description)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.JournalEntry{}, fmt.Errorf("transaction %d has no journal entry", transactionID)
+		}
+		return models.JournalEntry{}, fmt.Errorf("error while reading journal entry: %v", err)
+	}
+
+	rows, err := repo.DB.Query("SELECT id, entry_id, ledger_account, account_id, amt FROM posting WHERE entry_id = ? ORDER BY id", entry.ID)
+	if err != nil {
+		return models.JournalEntry{}, fmt.Errorf("error while reading postings: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var posting models.Posting
+		var accountID sql.NullInt64
+		if err := rows.Scan(&posting.ID, &posting.EntryID, &posting.LedgerAccount, &accountID, &posting.Amount); err != nil {
+			return models.JournalEntry{}, err
+		}
+		posting.AccountID = accountID.Int64
+		entry.Postings = append(entry.Postings, posting)
+	}
+	return entry, rows.Err()
+}
+
+// TrialBalance sums the postings of every ledger account.
+func (repo *LedgerRepository) TrialBalance() (models.TrialBalance, error) {
+	query := `SELECT ledger_account, account_id,
+			  COALESCE(SUM(CASE WHEN amt < 0 THEN amt ELSE 0 END), 0), COALESCE(SUM(CASE WHEN amt > 0 THEN amt ELSE 0 END), 0)
+			  FROM posting GROUP BY ledger_account, account_id
+			  ORDER BY CASE WHEN account_id IS NULL THEN 0 ELSE 1 END, ledger_account, account_id`
+	rows, err := repo.DB.Query(query)
+	if err != nil {
+		return models.TrialBalance{}, fmt.Errorf("error while computing trial balance: %v", err)
+	}
+	defer rows.Close()
+
+	var trial models.TrialBalance
+	for rows.Next() {
+		var line models.TrialBalanceLine
+		var accountID sql.NullInt64
+		if err := rows.Scan(&line.LedgerAccount, &accountID, &line.Debits, &line.Credits); err != nil {
+			return models.TrialBalance{}, err
+		}
+		line.AccountID = accountID.Int64
+		trial.Lines = append(trial.Lines, line)
+	}
+	return trial, rows.Err()
+}
+
+// Check verifies the invariants of the ledger: every entry has postings
+// summing to zero, every transaction has an entry, the ledger account of
+// every account agrees with its current balance and no transfer is half
+// posted.
+func (repo *LedgerRepository) Check() (models.LedgerCheck, error) {
+	var check models.LedgerCheck
+
+	query := `SELECT journal_entry.id FROM journal_entry LEFT JOIN posting ON posting.entry_id = journal_entry.id
+			  GROUP BY journal_entry.id HAVING COUNT(posting.id) < 2 OR COALESCE(SUM(posting.amt), 0) <> 0 ORDER BY journal_entry.id`
+	rows, err := repo.DB.Query(query)
+	if err != nil {
+		return models.LedgerCheck{}, fmt.Errorf("error while checking journal entries: %v", err)
+	}
+	for rows.Next() {
+		var entryID int64
+		if err := rows.Scan(&entryID); err != nil {
+			rows.Close()
+			return models.LedgerCheck{}, err
+		}
+		check.UnbalancedEntries = append(check.UnbalancedEntries, entryID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return models.LedgerCheck{}, err
+	}
+
+	query = "SELECT COUNT(*) FROM `transaction` WHERE NOT EXISTS (SELECT 1 FROM journal_entry WHERE journal_entry.transaction_id = `transaction`.id)"
+	if err := repo.DB.QueryRow(query).Scan(&check.UnjournaledTransactions); err != nil {
+		return models.LedgerCheck{}, fmt.Errorf("error while checking journaled transactions: %v", err)
+	}
+
+	query = `SELECT account.id, account.current_balance_amt, COALESCE(ledger.amt, 0) FROM account
+			 LEFT JOIN (SELECT account_id, SUM(amt) AS amt FROM posting WHERE ledger_account = ? GROUP BY account_id) ledger
+			 ON ledger.account_id = account.id
+			 WHERE account.current_balance_amt <> COALESCE(ledger.amt, 0) ORDER BY account.id`
+	rows, err = repo.DB.Query(query, models.LEDGER_CUSTOMER)
+	if err != nil {
+		return models.LedgerCheck{}, fmt.Errorf("error while checking account balances: %v", err)
+	}
+	for rows.Next() {
+		var mismatch models.LedgerMismatch
+		if err := rows.Scan(&mismatch.AccountID, &mismatch.CurrentBalance, &mismatch.LedgerBalance); err != nil {
+			rows.Close()
+			return models.LedgerCheck{}, err
+		}
+		check.Mismatches = append(check.Mismatches, mismatch)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return models.LedgerCheck{}, err
+	}
+
+	query = "SELECT COALESCE(SUM(amt), 0) FROM posting WHERE ledger_account = ?"
+	if err := repo.DB.QueryRow(query, models.LEDGER_TRANSFERS).Scan(&check.TransfersInTransit); err != nil {
+		return models.LedgerCheck{}, fmt.Errorf("error while checking transfers: %v", err)
+	}
+
+	return check, nil
+}
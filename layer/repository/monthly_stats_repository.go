@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"storichallenge_layer/models"
+	"storichallenge_layer/storage"
+)
+
+type MonthlyStatsRepository struct {
+	DB *storage.DB
+}
+
+// Merge adds a delta, built with MonthlyStats.Add, to the stored figures of
+// the same account month, creating the row when it is the first posting. It
+// is a single upsert, so concurrent first postings of a month do not clash.
+func (repo *MonthlyStatsRepository) Merge(q storage.Querier, delta models.MonthlyStats) error {
+	if delta.TransactionCount == 0 {
+		return nil
+	}
+
+	dialect := repo.DB.Dialect
+	inserted := dialect.Inserted
+	set := fmt.Sprintf(`txn_count = txn_count + %[1]s,
+				debit_count = debit_count + %[2]s, debit_sum = debit_sum + %[3]s,
+				credit_count = credit_count + %[4]s, credit_sum = credit_sum + %[5]s,
+				min_amt = CASE WHEN %[6]s < min_amt THEN %[6]s ELSE min_amt END,
+				max_amt = CASE WHEN %[7]s > max_amt THEN %[7]s ELSE max_amt END`,
+		inserted("txn_count"),
+		inserted("debit_count"), inserted("debit_sum"),
+		inserted("credit_count"), inserted("credit_sum"),
+		inserted("min_amt"),
+		inserted("max_amt"),
+	)
+	query := `INSERT INTO monthly_stats
+				(account_id, month, txn_count, debit_count, debit_sum, credit_count, credit_sum, min_amt, max_amt)
+			 VALUES (?,?,?,?,?,?,?,?,?) ` + dialect.Upsert([]string{"account_id", "month"}, set)
+	_, err := q.Exec(query,
+		delta.AccountID, delta.Month, delta.TransactionCount,
+		delta.DebitCount, delta.DebitSum,
+		delta.CreditCount, delta.CreditSum,
+		delta.MinAmount, delta.MaxAmount,
+	)
+	if err != nil {
+		return fmt.Errorf("error while merging monthly stats: %v", err)
+	}
+
+	return nil
+}
+
+// GetByAccountIDMonth returns empty stats for a month without transactions.
+func (repo *MonthlyStatsRepository) GetByAccountIDMonth(accountID int64, month string) (models.MonthlyStats, error) {
+	query := `SELECT account_id, month, txn_count, debit_count, debit_sum, credit_count, credit_sum, min_amt, max_amt
+			  FROM monthly_stats WHERE account_id = ? AND month = ?`
+	var stats models.MonthlyStats
+	err := repo.DB.QueryRow(query, accountID, month).Scan(
+		&stats.AccountID, &stats.Month, &stats.TransactionCount,
+		&stats.DebitCount, &stats.DebitSum, &stats.CreditCount, &stats.CreditSum,
+		&stats.MinAmount, &stats.MaxAmount,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.NewMonthlyStats(accountID, month), nil
+		}
+		return models.MonthlyStats{}, err
+	}
+	return stats, nil
+}
+
+func (repo *MonthlyStatsRepository) GetByAccountID(accountID int64) ([]models.MonthlyStats, error) {
+	query := `SELECT account_id, month, txn_count, debit_count, debit_sum, credit_count, credit_sum, min_amt, max_amt
+			  FROM monthly_stats WHERE account_id = ? ORDER BY month DESC`
+	rows, err := repo.DB.Query(query, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var allStats []models.MonthlyStats
+	for rows.Next() {
+		var stats models.MonthlyStats
+		err := rows.Scan(
+			&stats.AccountID, &stats.Month, &stats.TransactionCount,
+			&stats.DebitCount, &stats.DebitSum, &stats.CreditCount, &stats.CreditSum,
+			&stats.MinAmount, &stats.MaxAmount,
+		)
+		if err != nil {
+			return nil, err
+		}
+		allStats = append(allStats, stats)
+	}
+	return allStats, rows.Err()
+}
+
+// Rebuild recomputes the stats of an account, or of every account when
+// accountID is 0, from the transaction table. It is used to backfill data
+// posted before the table existed and to repair drift.
+func (repo *MonthlyStatsRepository) Rebuild(accountID int64) error {
+	return repo.DB.WithTx(func(tx *storage.Tx) error {
+		deleteQuery := "DELETE FROM monthly_stats"
+		insertQuery := `INSERT INTO monthly_stats
+							(account_id, month, txn_count, debit_count, debit_sum, credit_count, credit_sum, min_amt, max_amt)
+						SELECT account_id, month, COUNT(*),
+							SUM(CASE WHEN amt < 0 THEN 1 ELSE 0 END), COALESCE(SUM(CASE WHEN amt < 0 THEN amt END), 0),
+							SUM(CASE WHEN amt >= 0 THEN 1 ELSE 0 END), COALESCE(SUM(CASE WHEN amt >= 0 THEN amt END), 0),
+							MIN(amt), MAX(amt)
+						FROM ` + "`transaction`"
+		var args []any
+		if accountID != 0 {
+			deleteQuery += " WHERE account_id = ?"
+			insertQuery += " WHERE account_id = ?"
+			args = append(args, accountID)
+		}
+		insertQuery += " GROUP BY account_id, month"
+
+		if _, err := tx.Exec(deleteQuery, args...); err != nil {
+			return fmt.Errorf("error while clearing monthly stats: %v", err)
+		}
+		if _, err := tx.Exec(insertQuery, args...); err != nil {
+			return fmt.Errorf("error while rebuilding monthly stats: %v", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"storichallenge_layer/models"
+)
+
+// checkStats checks the stored stats of an account month.
+func (store *testStore) checkStats(tb testing.TB, want models.MonthlyStats) {
+	tb.Helper()
+	stats, err := store.Transactions.MonthlyStatsRepo.GetByAccountIDMonth(want.AccountID, want.Month)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if stats != want {
+		tb.Errorf("stats of account %d in %s = %+v, want %+v", want.AccountID, want.Month, stats, want)
+	}
+}
+
+// monthlyStats are the stats of amounts.
+func monthlyStats(accountID int64, month string, amounts ...int64) models.MonthlyStats {
+	stats := models.NewMonthlyStats(accountID, month)
+	for _, amount := range amounts {
+		stats.Add(amount)
+	}
+	return stats
+}
+
+func TestMonthlyStatsMerge(t *testing.T) {
+	store := newTestStore(t)
+	march := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	accountID := store.newAccount(t, 1000_00, march)
+	store.checkStats(t, monthlyStats(accountID, "2026/02", 1000_00))
+	store.checkStats(t, monthlyStats(accountID, "2026/03"))
+
+	// The first posting of a month inserts its row and the next ones update it.
+	store.post(t, accountID, -200_00, march)
+	store.post(t, accountID, 300_00, march.Add(time.Hour))
+	store.post(t, accountID, -50_00, march.Add(2*time.Hour))
+	store.checkStats(t, monthlyStats(accountID, "2026/03", -200_00, 300_00, -50_00))
+
+	var batch []models.Transaction
+	for _, amount := range []int64{-500_00, 10_00, 700_00} {
+		transaction, err := models.NewTransaction(amount, march.AddDate(0, 0, 1), accountID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		batch = append(batch, transaction)
+	}
+	if err := store.Transactions.CreateBatch(batch); err != nil {
+		t.Fatal(err)
+	}
+	store.checkStats(t, monthlyStats(accountID, "2026/03", -200_00, 300_00, -50_00, -500_00, 10_00, 700_00))
+
+	// Merging into a stored month keeps the extremes of both.
+	delta := monthlyStats(accountID, "2026/02", 5_00, 2000_00)
+	if err := store.Transactions.MonthlyStatsRepo.Merge(store.DB, delta); err != nil {
+		t.Fatal(err)
+	}
+	store.checkStats(t, monthlyStats(accountID, "2026/02", 1000_00, 5_00, 2000_00))
+	if err := store.Transactions.MonthlyStatsRepo.Merge(store.DB, models.NewMonthlyStats(accountID, "2026/02")); err != nil {
+		t.Fatal(err)
+	}
+	store.checkStats(t, monthlyStats(accountID, "2026/02", 1000_00, 5_00, 2000_00))
+}
+
+func TestMonthlyStatsRebuild(t *testing.T) {
+	store := newTestStore(t)
+	march := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	first := store.newAccount(t, 1000_00, march)
+	second := store.newAccount(t, 500_00, march)
+	for _, accountID := range []int64{first, second} {
+		store.post(t, accountID, -200_00, march)
+		store.post(t, accountID, 40_00, march)
+	}
+	want := []models.MonthlyStats{
+		monthlyStats(first, "2026/02", 1000_00),
+		monthlyStats(first, "2026/03", -200_00, 40_00),
+		monthlyStats(second, "2026/02", 500_00),
+		monthlyStats(second, "2026/03", -200_00, 40_00),
+	}
+	drift := func() {
+		t.Helper()
+		if _, err := store.DB.Exec("UPDATE monthly_stats SET txn_count = 99, min_amt = 0 WHERE month = ?", "2026/03"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.DB.Exec("DELETE FROM monthly_stats WHERE month = ?", "2026/02"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	drift()
+	if err := store.Transactions.MonthlyStatsRepo.Rebuild(first); err != nil {
+		t.Fatal(err)
+	}
+	store.checkStats(t, want[0])
+	store.checkStats(t, want[1])
+	// The other account is left as it was.
+	store.checkStats(t, models.NewMonthlyStats(second, "2026/02"))
+	stats, err := store.Transactions.MonthlyStatsRepo.GetByAccountIDMonth(second, "2026/03")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.TransactionCount != 99 {
+		t.Errorf("rebuilding account %d changed the stats of account %d", first, second)
+	}
+
+	drift()
+	if err := store.Transactions.MonthlyStatsRepo.Rebuild(0); err != nil {
+		t.Fatal(err)
+	}
+	for _, stats := range want {
+		store.checkStats(t, stats)
+	}
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"storichallenge_layer/models"
+)
+
+// checkMonth checks the opening and closing balances of an account month.
+func (store *testStore) checkMonth(tb testing.TB, accountID int64, month string, opening int64, closing int64) {
+	tb.Helper()
+	balance, err := store.Balances.GetMonth(accountID, month)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if balance.OpeningAmount != opening || balance.ClosingAmount() != closing {
+		tb.Errorf("%s opens with %d and closes with %d, want %d and %d", month, balance.OpeningAmount, balance.ClosingAmount(), opening, closing)
+	}
+}
+
+func TestBackdatedPostingMovesOpenings(t *testing.T) {
+	store := newTestStore(t)
+	accountID := store.newAccount(t, 0, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	store.post(t, accountID, 1000_00, time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC))
+	store.post(t, accountID, -100_00, time.Date(2026, 2, 5, 12, 0, 0, 0, time.UTC))
+	store.post(t, accountID, -50_00, time.Date(2026, 3, 5, 12, 0, 0, 0, time.UTC))
+
+	store.post(t, accountID, -30_00, time.Date(2026, 1, 20, 12, 0, 0, 0, time.UTC))
+	store.checkMonth(t, accountID, "2026/01", 0, 970_00)
+	store.checkMonth(t, accountID, "2026/02", 970_00, 870_00)
+	store.checkMonth(t, accountID, "2026/03", 870_00, 820_00)
+	store.checkBalance(t, accountID, 820_00)
+	store.checkLedger(t)
+}
+
+func TestPostingIntoClosedMonthRejected(t *testing.T) {
+	store := newTestStore(t)
+	accountID := store.newAccount(t, 0, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	store.post(t, accountID, 1000_00, time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC))
+	if err := store.Accounts.ClosePeriod(accountID, "2026/02"); err != nil {
+		t.Fatal(err)
+	}
+
+	late, err := models.NewTransaction(-10_00, time.Date(2026, 2, 27, 12, 0, 0, 0, time.UTC), accountID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkErr(t, store.Transactions.Create(late), models.ErrPeriodClosed)
+
+	// A batch with a single transaction in a closed month posts nothing.
+	open, err := models.NewTransaction(-20_00, time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC), accountID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkErr(t, store.Transactions.CreateBatch([]models.Transaction{open, late}), models.ErrPeriodClosed)
+	store.checkBalance(t, accountID, 1000_00)
+
+	if err := store.Transactions.CreateBatch([]models.Transaction{open}); err != nil {
+		t.Fatal(err)
+	}
+	store.checkBalance(t, accountID, 980_00)
+
+	// Reopening the month lets corrections into it again.
+	if err := store.Accounts.ReopenPeriod(accountID, "2026/02"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Transactions.Create(late); err != nil {
+		t.Fatal(err)
+	}
+	store.checkMonth(t, accountID, "2026/02", 1000_00, 990_00)
+	store.checkMonth(t, accountID, "2026/03", 990_00, 970_00)
+	store.checkLedger(t)
+}
+
+func TestPostingIntoClosedMonthAdjusted(t *testing.T) {
+	store := newTestStore(t)
+	store.Transactions.Backdating = models.BACKDATE_ADJUST
+	accountID := store.newAccount(t, 0, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	store.post(t, accountID, 1000_00, time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC))
+	if err := store.Accounts.ClosePeriod(accountID, "2026/01"); err != nil {
+		t.Fatal(err)
+	}
+
+	dated := time.Date(2026, 1, 20, 12, 0, 0, 0, time.UTC)
+	late, err := models.NewTransaction(-10_00, dated, accountID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Transactions.Create(late); err != nil {
+		t.Fatal(err)
+	}
+	batchLate, err := models.NewTransaction(-5_00, dated, accountID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Transactions.CreateBatch([]models.Transaction{batchLate}); err != nil {
+		t.Fatal(err)
+	}
+
+	transactions, err := store.Transactions.GetByAccountIDMonth(accountID, "2026/02")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transactions) != 2 {
+		t.Fatalf("2026/02 has %d transactions, want both adjustments", len(transactions))
+	}
+	for _, transaction := range transactions {
+		if !transaction.DateTime.Equal(time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)) || !transaction.ValueDate.Equal(dated) {
+			t.Errorf("adjustment dated %s with value date %s, want 2026-02-01 and %s", transaction.DateTime, transaction.ValueDate, dated)
+		}
+	}
+	store.checkMonth(t, accountID, "2026/01", 0, 1000_00)
+	store.checkMonth(t, accountID, "2026/02", 1000_00, 985_00)
+	store.checkBalance(t, accountID, 985_00)
+	store.checkLedger(t)
+}
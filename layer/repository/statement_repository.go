@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"storichallenge_layer/models"
+	"storichallenge_layer/storage"
+	"time"
+)
+
+const statementColumns = "id, account_id, period_start, period_end, due_date, previous_balance_amt, purchases_amt, charges_amt, payments_amt, statement_balance_amt, minimum_payment_amt, credit_limit_amt, available_credit_amt"
+
+type StatementRepository struct {
+	DB *storage.DB
+}
+
+// Create stores a closed cycle. There is one statement per account and
+// cut-off date, so closing the same cycle twice fails with a unique violation
+// on period_end.
+func (repo *StatementRepository) Create(statement models.Statement) (int64, error) {
+	query := `INSERT INTO statement (account_id, period_start, period_end, due_date, previous_balance_amt, purchases_amt, charges_amt, payments_amt,
+			  statement_balance_amt, minimum_payment_amt, credit_limit_amt, available_credit_amt) VALUES (?,?,?,?,?,?,?,?,?,?,?,?)`
+	statementID, err := repo.DB.InsertID(query, statement.AccountID, statement.PeriodStart, statement.PeriodEnd, statement.DueDate,
+		statement.PreviousBalance, statement.Purchases, statement.Charges, statement.Payments, statement.StatementBalance, statement.MinimumPayment,
+		statement.CreditLimit, statement.AvailableCredit)
+	if err != nil {
+		return 0, fmt.Errorf("error while creating statement: %w", err)
+	}
+	return statementID, nil
+}
+
+// GetLatestByAccountID returns the last closed cycle of an account and false
+// when none was closed yet.
+func (repo *StatementRepository) GetLatestByAccountID(accountID int64) (models.Statement, bool, error) {
+	query := "SELECT " + statementColumns + " FROM statement WHERE account_id = ? ORDER BY period_end DESC LIMIT 1"
+	statement, err := scanStatement(repo.DB.QueryRow(query, accountID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Statement{}, false, nil
+		}
+		return models.Statement{}, false, err
+	}
+	return statement, true, nil
+}
+
+func (repo *StatementRepository) GetByAccountIDPeriodEnd(accountID int64, periodEnd time.Time) (models.Statement, error) {
+	query := "SELECT " + statementColumns + " FROM statement WHERE account_id = ? AND period_end = ?"
+	statement, err := scanStatement(repo.DB.QueryRow(query, accountID, periodEnd))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Statement{}, errors.New("statement not found")
+		}
+		return models.Statement{}, err
+	}
+	return statement, nil
+}
+
+// GetByAccountIDDueDate returns the cycle of an account due on dueDate and
+// false when there is none.
+func (repo *StatementRepository) GetByAccountIDDueDate(accountID int64, dueDate time.Time) (models.Statement, bool, error) {
+	query := "SELECT " + statementColumns + " FROM statement WHERE account_id = ? AND due_date = ?"
+	statement, err := scanStatement(repo.DB.QueryRow(query, accountID, dueDate))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Statement{}, false, nil
+		}
+		return models.Statement{}, false, err
+	}
+	return statement, true, nil
+}
+
+// GetByAccountID returns the statements of an account, newest first.
+func (repo *StatementRepository) GetByAccountID(accountID int64) ([]models.Statement, error) {
+	query := "SELECT " + statementColumns + " FROM statement WHERE account_id = ? ORDER BY period_end DESC"
+	rows, err := repo.DB.Query(query, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var statements []models.Statement
+	for rows.Next() {
+		statement, err := scanStatement(rows)
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, statement)
+	}
+	return statements, rows.Err()
+}
+
+func scanStatement(row interface{ Scan(dest ...any) error }) (models.Statement, error) {
+	var statement models.Statement
+	err := row.Scan(
+		&statement.ID, &statement.AccountID, &statement.PeriodStart, &statement.PeriodEnd, &statement.DueDate,
+		&statement.PreviousBalance, &statement.Purchases, &statement.Charges, &statement.Payments, &statement.StatementBalance,
+		&statement.MinimumPayment, &statement.CreditLimit, &statement.AvailableCredit,
+	)
+	return statement, err
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"storichallenge_layer/config"
+	"storichallenge_layer/models"
+	"storichallenge_layer/storage"
+	"storichallenge_layer/validation"
+)
+
+// testStore holds the repositories wired as services.NewAccountService wires
+// them, on a fresh SQLite database of its own.
+type testStore struct {
+	DB           *storage.DB
+	Customers    *CustomerRepository
+	Accounts     *AccountRepository
+	Balances     *BalanceRepository
+	Transactions *TransactionRepository
+	Ledger       *LedgerRepository
+	Transfers    *TransferRepository
+	Accruals     *AccrualRepository
+	Holds        *HoldRepository
+	Installments *InstallmentRepository
+
+	customerID int64
+	accounts   int
+}
+
+func newTestStore(tb testing.TB) *testStore {
+	tb.Helper()
+	db, err := config.ConnectToDB(config.DBConfig{
+		Driver:       storage.DRIVER_SQLITE,
+		Path:         filepath.Join(tb.TempDir(), "stori.db"),
+		MaxOpenConns: 1,
+		MaxIdleConns: 1,
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { db.DB.Close() })
+
+	store := &testStore{DB: db}
+	store.Customers = &CustomerRepository{DB: db}
+	store.Accounts = &AccountRepository{DB: db}
+	store.Balances = &BalanceRepository{DB: db, AccountRepo: store.Accounts}
+	store.Ledger = &LedgerRepository{DB: db, AccountRepo: store.Accounts}
+	store.Transactions = &TransactionRepository{
+		DB:               db,
+		AccountRepo:      store.Accounts,
+		BalanceRepo:      store.Balances,
+		MonthlyStatsRepo: &MonthlyStatsRepository{DB: db},
+		LedgerRepo:       store.Ledger,
+		DailyBalanceRepo: &DailyBalanceRepository{DB: db, AccountRepo: store.Accounts},
+	}
+	store.Accounts.BalanceRepo = store.Balances
+	store.Balances.TransactionRepo = store.Transactions
+	store.Transfers = &TransferRepository{DB: db, AccountRepo: store.Accounts, TransactionRepo: store.Transactions}
+	store.Accruals = &AccrualRepository{DB: db, TransactionRepo: store.Transactions}
+	store.Holds = &HoldRepository{DB: db, AccountRepo: store.Accounts, TransactionRepo: store.Transactions}
+	store.Installments = &InstallmentRepository{DB: db, AccountRepo: store.Accounts, TransactionRepo: store.Transactions}
+
+	customer, err := models.NewCustomer("Gloria", "Hernandez", "Garcia", time.Date(1956, 4, 27, 0, 0, 0, 0, time.UTC),
+		"HEGG560427MVZRRL04", "", "", "gloria@example.com")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	store.customerID, err = store.Customers.Create(customer)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return store
+}
+
+// newAccount opens a debit account funded with balance cents, deposited on
+// the first day of the month before at.
+func (store *testStore) newAccount(tb testing.TB, balance int64, at time.Time) int64 {
+	tb.Helper()
+	store.accounts++
+	account := models.NewAccount(store.customerID)
+	account.AccountNumber = fmt.Sprintf("%018d", store.accounts)
+	accountID, err := store.Accounts.Create(account)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if balance != 0 {
+		store.post(tb, accountID, balance, time.Date(at.Year(), at.Month()-1, 1, 12, 0, 0, 0, time.UTC))
+	}
+	return accountID
+}
+
+// newCreditAccount opens a credit card with a limit of limit cents.
+func (store *testStore) newCreditAccount(tb testing.TB, limit int64) models.Account {
+	tb.Helper()
+	store.accounts++
+	account, err := models.NewCreditAccount(store.customerID, limit, 15, 20)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	account.AccountNumber = fmt.Sprintf("%018d", store.accounts)
+	account.ID, err = store.Accounts.Create(account)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return account
+}
+
+// post posts a transaction of amount cents at at and returns its ID.
+func (store *testStore) post(tb testing.TB, accountID int64, amount int64, at time.Time) int64 {
+	tb.Helper()
+	transaction, err := models.NewTransaction(amount, at, accountID)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	var transactionID int64
+	err = store.DB.WithTx(func(tx *storage.Tx) error {
+		transactionID, err = store.Transactions.create(tx, transaction)
+		return err
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return transactionID
+}
+
+// checkBalance checks the current balance of an account and that it agrees
+// with the sum of its transactions.
+func (store *testStore) checkBalance(tb testing.TB, accountID int64, want int64) {
+	tb.Helper()
+	account, err := store.Accounts.GetByID(accountID, false, false)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if account.CurrentBalanceAmount != want {
+		tb.Errorf("account %d balance = %d, want %d", accountID, account.CurrentBalanceAmount, want)
+	}
+	sum, err := store.Transactions.BalanceBefore(accountID, time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if sum != want {
+		tb.Errorf("account %d transactions sum %d, want %d", accountID, sum, want)
+	}
+}
+
+// checkLedger checks every invariant of the ledger.
+func (store *testStore) checkLedger(tb testing.TB) {
+	tb.Helper()
+	check, err := store.Ledger.Check()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if !check.OK() {
+		tb.Errorf("ledger check failed: %+v", check)
+	}
+}
+
+func checkErr(tb testing.TB, err error, want error) {
+	tb.Helper()
+	if !errors.Is(err, want) {
+		tb.Errorf("error = %v, want %v", err, want)
+	}
+}
+
+// checkFieldError checks that err is a validation error with code.
+func checkFieldError(tb testing.TB, err error, code string) {
+	tb.Helper()
+	validationErr, ok := validation.AsValidationError(err)
+	if !ok {
+		tb.Errorf("error = %v, want a validation error %s", err, code)
+		return
+	}
+	for _, fieldError := range validationErr.Errors {
+		if fieldError.Code == code {
+			return
+		}
+	}
+	tb.Errorf("error = %v, want a validation error %s", err, code)
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"fmt"
+	"storichallenge_layer/models"
+	"storichallenge_layer/storage"
+	"time"
+)
+
+// Refund credits back amount cents of a debit at at. Refunds and reversals of
+// a transaction cannot add up to more than the transaction itself.
+func (repo *TransactionRepository) Refund(originalID int64, amount int64, at time.Time) (models.Transaction, error) {
+	return repo.adjust(originalID, func(original models.Transaction, remaining int64) (models.Transaction, error) {
+		return models.NewRefund(original, remaining, amount, at)
+	})
+}
+
+// Reverse undoes at at whatever part of a transaction was not refunded or
+// reversed yet.
+func (repo *TransactionRepository) Reverse(originalID int64, at time.Time) (models.Transaction, error) {
+	return repo.adjust(originalID, func(original models.Transaction, remaining int64) (models.Transaction, error) {
+		return models.NewReversal(original, remaining, at)
+	})
+}
+
+// adjust posts the transaction built by build from the original and its
+// remaining cents. The account row is locked first, so that concurrent
+// adjustments of the same transaction see each other.
+func (repo *TransactionRepository) adjust(originalID int64, build func(models.Transaction, int64) (models.Transaction, error)) (models.Transaction, error) {
+	var adjustment models.Transaction
+	err := repo.DB.WithTx(func(tx *storage.Tx) error {
+		original, err := repo.getByID(tx, originalID)
+		if err != nil {
+			return err
+		}
+		if err := repo.AccountRepo.updateCurrentBalanceAmountArithmetrically(tx, original.AccountID, 0); err != nil {
+			return err
+		}
+
+		var adjusted int64
+		query := "SELECT COALESCE(SUM(amt), 0) FROM `transaction` WHERE original_transaction_id = ?"
+		if err := tx.QueryRow(query, originalID).Scan(&adjusted); err != nil {
+			return fmt.Errorf("error while reading adjustments of transaction %d: %v", originalID, err)
+		}
+
+		adjustment, err = build(original, models.Remaining(original, adjusted))
+		if err != nil {
+			return err
+		}
+		adjustment.ID, err = repo.create(tx, adjustment)
+		return err
+	})
+	if err != nil {
+		return models.Transaction{}, err
+	}
+	return adjustment, nil
+}
+
+func (repo *TransactionRepository) GetByID(transactionID int64) (models.Transaction, error) {
+	return repo.getByID(repo.DB, transactionID)
+}
+
+func (repo *TransactionRepository) getByID(q storage.Querier, transactionID int64) (models.Transaction, error) {
+	query := "SELECT " + transactionColumns + " FROM `transaction` WHERE id = ?"
+	rows, err := q.Query(query, transactionID)
+	if err != nil {
+		return models.Transaction{}, fmt.Errorf("error while reading transaction: %v", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return models.Transaction{}, fmt.Errorf("error while reading transaction: %v", err)
+		}
+		return models.Transaction{}, models.ErrTransactionNotFound
+	}
+	transaction, err := scanTransaction(rows)
+	if err != nil {
+		return models.Transaction{}, fmt.Errorf("error while reading transaction: %v", err)
+	}
+	return transaction, nil
+}
+
+// GetAdjustments returns the refunds and reversals of the account
+// transactions dated from from to to, exclusive, keyed by the ID of the
+// transaction they undo and sorted by date. They are returned whatever their
+// own date, which may fall in a later period.
+func (repo *TransactionRepository) GetAdjustments(accountID int64, from time.Time, to time.Time) (map[int64][]models.Transaction, error) {
+	query := "SELECT " + transactionColumns + " FROM `transaction` WHERE original_transaction_id IN " +
+		"(SELECT id FROM `transaction` WHERE account_id = ? AND dt >= ? AND dt < ?) ORDER BY dt, id"
+	rows, err := repo.DB.Query(query, accountID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading adjustments: %v", err)
+	}
+	defer rows.Close()
+
+	adjustments := map[int64][]models.Transaction{}
+	for rows.Next() {
+		transaction, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		adjustments[transaction.OriginalTransactionID] = append(adjustments[transaction.OriginalTransactionID], transaction)
+	}
+	return adjustments, rows.Err()
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"storichallenge_layer/models"
+	"storichallenge_layer/validation"
+)
+
+func TestRefund(t *testing.T) {
+	store := newTestStore(t)
+	at := time.Date(2026, 3, 10, 9, 30, 0, 0, time.UTC)
+	accountID := store.newAccount(t, 1000_00, at)
+	purchaseID := store.post(t, accountID, -100_00, at)
+
+	refund, err := store.Transactions.Refund(purchaseID, 30_00, at.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refund.Amount != 30_00 || refund.OriginalTransactionID != purchaseID || refund.Type != models.TRANSACTION_TYPE_REFUND {
+		t.Errorf("refund = %+v, want a refund of 30.00 linked to %d", refund, purchaseID)
+	}
+	store.checkBalance(t, accountID, 930_00)
+
+	// Only 70.00 are left to refund.
+	_, err = store.Transactions.Refund(purchaseID, 70_01, at.Add(time.Hour))
+	checkFieldError(t, err, validation.CodeMax)
+	store.checkBalance(t, accountID, 930_00)
+
+	_, err = store.Transactions.Refund(purchaseID, 10_00, at.Add(-time.Hour))
+	checkFieldError(t, err, validation.CodeNotBefore)
+
+	_, err = store.Transactions.Refund(refund.ID, 10_00, at.Add(time.Hour))
+	checkErr(t, err, models.ErrAdjustmentOfAdjustment)
+	_, err = store.Transactions.Reverse(refund.ID, at.Add(time.Hour))
+	checkErr(t, err, models.ErrAdjustmentOfAdjustment)
+
+	if _, err := store.Transactions.Refund(purchaseID, 70_00, at.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	store.checkBalance(t, accountID, 1000_00)
+	_, err = store.Transactions.Refund(purchaseID, 1, at.Add(time.Hour))
+	checkErr(t, err, models.ErrAlreadyReversed)
+	_, err = store.Transactions.Reverse(purchaseID, at.Add(time.Hour))
+	checkErr(t, err, models.ErrAlreadyReversed)
+	store.checkBalance(t, accountID, 1000_00)
+	store.checkLedger(t)
+}
+
+func TestRefundOfCredit(t *testing.T) {
+	store := newTestStore(t)
+	at := time.Date(2026, 3, 10, 9, 30, 0, 0, time.UTC)
+	accountID := store.newAccount(t, 0, at)
+	depositID := store.post(t, accountID, 100_00, at)
+
+	_, err := store.Transactions.Refund(depositID, 10_00, at.Add(time.Hour))
+	checkErr(t, err, models.ErrNotRefundable)
+	_, err = store.Transactions.Refund(depositID+100, 10_00, at.Add(time.Hour))
+	checkErr(t, err, models.ErrTransactionNotFound)
+	store.checkBalance(t, accountID, 100_00)
+	store.checkLedger(t)
+}
+
+func TestReverse(t *testing.T) {
+	store := newTestStore(t)
+	at := time.Date(2026, 3, 10, 9, 30, 0, 0, time.UTC)
+	accountID := store.newAccount(t, 0, at)
+	depositID := store.post(t, accountID, 500_00, at)
+	purchaseID := store.post(t, accountID, -100_00, at.Add(time.Minute))
+
+	if _, err := store.Transactions.Refund(purchaseID, 40_00, at.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	// The reversal undoes the 60.00 not refunded.
+	reversal, err := store.Transactions.Reverse(purchaseID, at.Add(2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reversal.Amount != 60_00 || reversal.Type != models.TRANSACTION_TYPE_REVERSAL {
+		t.Errorf("reversal = %+v, want a reversal of 60.00", reversal)
+	}
+	store.checkBalance(t, accountID, 500_00)
+	_, err = store.Transactions.Reverse(purchaseID, at.Add(3*time.Hour))
+	checkErr(t, err, models.ErrAlreadyReversed)
+
+	// Reversing a credit debits it back.
+	reversal, err = store.Transactions.Reverse(depositID, at.Add(3*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reversal.Amount != -500_00 {
+		t.Errorf("reversal amount = %d, want -500.00", reversal.Amount)
+	}
+	store.checkBalance(t, accountID, 0)
+	store.checkLedger(t)
+}
+
+func TestReverseTransferTransaction(t *testing.T) {
+	store := newTestStore(t)
+	at := time.Date(2026, 3, 10, 9, 30, 0, 0, time.UTC)
+	from := store.newAccount(t, 1000_00, at)
+	to := store.newAccount(t, 0, at)
+	if _, err := store.Transfers.Create(newTestTransfer(t, from, to, 300_00, "ref-1", at)); err != nil {
+		t.Fatal(err)
+	}
+	transactions, err := store.Transactions.GetByAccountID(to)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.Transactions.Reverse(transactions[0].ID, at.Add(time.Hour))
+	checkErr(t, err, models.ErrTransferTransaction)
+	store.checkBalance(t, to, 300_00)
+	store.checkLedger(t)
+}
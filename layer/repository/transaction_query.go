@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"iter"
+	"strconv"
+	"strings"
+	"time"
+
+	"storichallenge_layer/models"
+)
+
+// transactionCursor is the position of the last row of a page. Pages are
+// walked by (dt, id), so rows sharing a timestamp are never skipped or
+// repeated. Its time is decoded in UTC and bound like any other time, see
+// storage.Dialect, so it compares with dt however the row was dated.
+type transactionCursor struct {
+	DateTime time.Time
+	ID       int64
+}
+
+func encodeTransactionCursor(transaction models.Transaction) string {
+	raw := strconv.FormatInt(transaction.DateTime.UnixNano(), 10) + ":" + strconv.FormatInt(transaction.ID, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeTransactionCursor(cursor string) (transactionCursor, error) {
+	invalid := errors.New("invalid transaction cursor")
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return transactionCursor{}, invalid
+	}
+	nanos, id, found := strings.Cut(string(raw), ":")
+	if !found {
+		return transactionCursor{}, invalid
+	}
+	unixNano, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return transactionCursor{}, invalid
+	}
+	transactionID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return transactionCursor{}, invalid
+	}
+
+	return transactionCursor{DateTime: time.Unix(0, unixNano).UTC(), ID: transactionID}, nil
+}
+
+// buildTransactionQuery turns the filters into SQL. limit is appended as a
+// LIMIT clause when greater than zero.
+func buildTransactionQuery(q models.TransactionQuery, limit int) (string, []any, error) {
+	if q.AccountID == 0 {
+		return "", nil, errors.New("account ID must be provided")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("SELECT " + transactionColumns + " FROM `transaction` WHERE account_id = ?")
+	args := []any{q.AccountID}
+
+	if !q.From.IsZero() {
+		sb.WriteString(" AND dt >= ?")
+		args = append(args, q.From)
+	}
+	if !q.To.IsZero() {
+		sb.WriteString(" AND dt < ?")
+		args = append(args, q.To)
+	}
+	if q.MinAmount != nil {
+		sb.WriteString(" AND amt >= ?")
+		args = append(args, *q.MinAmount)
+	}
+	if q.MaxAmount != nil {
+		sb.WriteString(" AND amt <= ?")
+		args = append(args, *q.MaxAmount)
+	}
+
+	switch q.Kind {
+	case models.TRANSACTION_KIND_ANY:
+	case models.TRANSACTION_KIND_DEBIT:
+		sb.WriteString(" AND amt < 0")
+	case models.TRANSACTION_KIND_CREDIT:
+		sb.WriteString(" AND amt > 0")
+	default:
+		return "", nil, fmt.Errorf("unknown transaction kind %q", q.Kind)
+	}
+
+	var comparison, direction string
+	switch q.Order {
+	case models.SORT_DESC, "":
+		comparison, direction = "<", "DESC"
+	case models.SORT_ASC:
+		comparison, direction = ">", "ASC"
+	default:
+		return "", nil, fmt.Errorf("unknown sort order %q", q.Order)
+	}
+
+	if q.Cursor != "" {
+		cursor, err := decodeTransactionCursor(q.Cursor)
+		if err != nil {
+			return "", nil, err
+		}
+		sb.WriteString(fmt.Sprintf(" AND (dt %s ? OR (dt = ? AND id %s ?))", comparison, comparison))
+		args = append(args, cursor.DateTime, cursor.DateTime, cursor.ID)
+	}
+
+	sb.WriteString(fmt.Sprintf(" ORDER BY dt %s, id %s", direction, direction))
+
+	if limit > 0 {
+		sb.WriteString(" LIMIT ?")
+		args = append(args, limit)
+	}
+
+	return sb.String(), args, nil
+}
+
+// Query returns one page of the account transactions matching q.
+func (repo *TransactionRepository) Query(q models.TransactionQuery) (models.TransactionPage, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = models.DEFAULT_TRANSACTION_PAGE_SIZE
+	}
+	if limit > models.MAX_TRANSACTION_PAGE_SIZE {
+		limit = models.MAX_TRANSACTION_PAGE_SIZE
+	}
+
+	// One extra row tells whether there is a next page.
+	query, args, err := buildTransactionQuery(q, limit+1)
+	if err != nil {
+		return models.TransactionPage{}, err
+	}
+
+	rows, err := repo.DB.Query(query, args...)
+	if err != nil {
+		return models.TransactionPage{}, err
+	}
+	defer rows.Close()
+
+	var page models.TransactionPage
+	for rows.Next() {
+		transaction, err := scanTransaction(rows)
+		if err != nil {
+			return models.TransactionPage{}, err
+		}
+		page.Transactions = append(page.Transactions, transaction)
+	}
+	if err := rows.Err(); err != nil {
+		return models.TransactionPage{}, err
+	}
+
+	if len(page.Transactions) > limit {
+		page.Transactions = page.Transactions[:limit]
+		page.NextCursor = encodeTransactionCursor(page.Transactions[limit-1])
+	}
+
+	return page, nil
+}
+
+// Stream yields every transaction matching q, ignoring Limit, reading rows as
+// they arrive instead of loading them all in memory. Stopping the range early
+// releases the underlying rows.
+func (repo *TransactionRepository) Stream(q models.TransactionQuery) iter.Seq2[models.Transaction, error] {
+	return func(yield func(models.Transaction, error) bool) {
+		query, args, err := buildTransactionQuery(q, 0)
+		if err != nil {
+			yield(models.Transaction{}, err)
+			return
+		}
+
+		rows, err := repo.DB.Query(query, args...)
+		if err != nil {
+			yield(models.Transaction{}, err)
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			transaction, err := scanTransaction(rows)
+			if err != nil {
+				yield(models.Transaction{}, err)
+				return
+			}
+			if !yield(transaction, nil) {
+				return
+			}
+		}
+		if err := rows.Err(); err != nil {
+			yield(models.Transaction{}, err)
+		}
+	}
+}
+
+func scanTransaction(rows *sql.Rows) (models.Transaction, error) {
+	var transaction models.Transaction
+	var transferID, originalTransactionID sql.NullInt64
+	var valueDate sql.NullTime
+	err := rows.Scan(&transaction.ID, &transaction.AccountID, &transaction.Month, &transaction.DateTime, &transaction.Amount, &transaction.Type,
+		&transaction.Description, &transaction.Merchant, &transaction.MCC, &transaction.Channel, &transaction.Location,
+		&transaction.Category, &transaction.CategoryOverride, &transferID, &originalTransactionID, &valueDate)
+	transaction.TransferID = transferID.Int64
+	transaction.OriginalTransactionID = originalTransactionID.Int64
+	transaction.ValueDate = valueDate.Time
+	return transaction, err
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"storichallenge_layer/models"
+	"storichallenge_layer/storage"
+)
+
+// newPagedAccount posts transactions in two zones, three of them at the same
+// instant, and returns the account and their IDs oldest first, ties by ID.
+func newPagedAccount(t *testing.T, store *testStore) (int64, []int64) {
+	t.Helper()
+	cst := time.FixedZone("CST", -6*60*60)
+	accountID := store.newAccount(t, 0, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+
+	var ids []int64
+	ids = append(ids, store.post(t, accountID, 1000_00, time.Date(2026, 3, 10, 8, 0, 0, 0, cst)))
+	// 2026-03-10 20:00 CST is 2026-03-11 02:00 UTC, after the UTC evening.
+	tie := time.Date(2026, 3, 10, 20, 0, 0, 0, cst)
+	ids = append(ids, store.post(t, accountID, -10_00, time.Date(2026, 3, 10, 23, 0, 0, 0, time.UTC)))
+	ids = append(ids, store.post(t, accountID, -20_00, tie))
+	ids = append(ids, store.post(t, accountID, -30_00, tie.UTC()))
+	ids = append(ids, store.post(t, accountID, -40_00, tie.In(time.FixedZone("PST", -8*60*60))))
+	ids = append(ids, store.post(t, accountID, -50_00, time.Date(2026, 3, 11, 2, 0, 1, 0, time.UTC)))
+
+	// A transaction built by hand, still in its own zone and with a fraction
+	// of a second, falls between the others all the same.
+	transaction, err := models.NewTransaction(-5_00, tie, accountID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transaction.DateTime = time.Date(2026, 3, 10, 20, 0, 0, 500_000_000, cst)
+	var id int64
+	err = store.DB.WithTx(func(tx *storage.Tx) error {
+		id, err = store.Transactions.create(tx, transaction)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids = slices.Insert(ids, 5, id)
+	return accountID, ids
+}
+
+// walk reads every page of q and returns the IDs in the order they came.
+func walk(t *testing.T, store *testStore, q models.TransactionQuery) []int64 {
+	t.Helper()
+	var ids []int64
+	for pages := 0; ; pages++ {
+		if pages > 20 {
+			t.Fatalf("still paging after %d pages, read %v", pages, ids)
+		}
+		page, err := store.Transactions.Query(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Transactions) > q.Limit {
+			t.Fatalf("page of %d transactions, want at most %d", len(page.Transactions), q.Limit)
+		}
+		for _, transaction := range page.Transactions {
+			ids = append(ids, transaction.ID)
+		}
+		if page.NextCursor == "" {
+			return ids
+		}
+		q.Cursor = page.NextCursor
+	}
+}
+
+func TestQueryPages(t *testing.T) {
+	store := newTestStore(t)
+	accountID, want := newPagedAccount(t, store)
+
+	for _, limit := range []int{1, 2, 3, len(want), 100} {
+		got := walk(t, store, models.TransactionQuery{AccountID: accountID, Order: models.SORT_ASC, Limit: limit})
+		if !slices.Equal(got, want) {
+			t.Errorf("ascending pages of %d = %v, want %v", limit, got, want)
+		}
+		got = walk(t, store, models.TransactionQuery{AccountID: accountID, Limit: limit})
+		if !slices.Equal(got, reversed(want)) {
+			t.Errorf("descending pages of %d = %v, want %v", limit, got, reversed(want))
+		}
+	}
+}
+
+func TestQueryFilters(t *testing.T) {
+	store := newTestStore(t)
+	accountID, ids := newPagedAccount(t, store)
+	cst := time.FixedZone("CST", -6*60*60)
+	minAmount, maxAmount := int64(-40_00), int64(-10_00)
+
+	tests := []struct {
+		name string
+		q    models.TransactionQuery
+		want []int64
+	}{
+		{
+			name: "from and to in another zone",
+			q: models.TransactionQuery{
+				From: time.Date(2026, 3, 10, 17, 0, 0, 0, cst),
+				To:   time.Date(2026, 3, 11, 2, 0, 1, 0, time.UTC),
+			},
+			want: ids[1:6],
+		},
+		{name: "credits", q: models.TransactionQuery{Kind: models.TRANSACTION_KIND_CREDIT}, want: ids[:1]},
+		{name: "amounts", q: models.TransactionQuery{MinAmount: &minAmount, MaxAmount: &maxAmount}, want: ids[1:5]},
+	}
+	for _, test := range tests {
+		test.q.AccountID, test.q.Order, test.q.Limit = accountID, models.SORT_ASC, 2
+		if got := walk(t, store, test.q); !slices.Equal(got, test.want) {
+			t.Errorf("%s: pages = %v, want %v", test.name, got, test.want)
+		}
+
+		var streamed []int64
+		for transaction, err := range store.Transactions.Stream(test.q) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			streamed = append(streamed, transaction.ID)
+		}
+		if !slices.Equal(streamed, test.want) {
+			t.Errorf("%s: streamed %v, want %v", test.name, streamed, test.want)
+		}
+	}
+}
+
+func TestQueryInvalidCursor(t *testing.T) {
+	store := newTestStore(t)
+	for _, cursor := range []string{"not base64!", "MTIz", "YTpi"} {
+		_, err := store.Transactions.Query(models.TransactionQuery{AccountID: 1, Cursor: cursor})
+		if err == nil {
+			t.Errorf("Query with cursor %q succeeded, want an error", cursor)
+		}
+	}
+}
+
+func reversed(ids []int64) []int64 {
+	ids = slices.Clone(ids)
+	slices.Reverse(ids)
+	return ids
+}
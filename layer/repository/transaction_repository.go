@@ -2,31 +2,305 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"math"
+	"slices"
+	"sort"
 	"storichallenge_layer/models"
+	"storichallenge_layer/storage"
+	"strings"
+	"time"
+)
+
+// insertTransactionColumns are written from transactionValues and
+// transactionColumns read by scanTransaction.
+const (
+	insertTransactionColumns = "account_id, month, dt, amt, txn_type, description, merchant, mcc, channel, location, category, category_override, transfer_id, original_transaction_id, value_dt"
+	transactionColumns       = "id, " + insertTransactionColumns
 )
 
 type TransactionRepository struct {
-	DB          *sql.DB
-	BalanceRepo *BalanceRepository
+	DB               *storage.DB
+	AccountRepo      *AccountRepository
+	BalanceRepo      *BalanceRepository
+	MonthlyStatsRepo *MonthlyStatsRepository
+	LedgerRepo       *LedgerRepository
+	DailyBalanceRepo *DailyBalanceRepository
+	// Categorize, when set, assigns the category of every transaction posted
+	// without one.
+	Categorize func(models.Transaction) string
+	// Backdating decides what happens to transactions dated in a closed
+	// balance month; they are rejected unless it is models.BACKDATE_ADJUST.
+	Backdating models.BackdatePolicy
 }
 
+// Create posts a transaction: it updates the balance month, the account
+// current balance and the monthly stats, and inserts the row and its journal
+// entry, atomically.
+// Debits on frozen accounts and every transaction on closed ones are
+// rejected with models.ErrAccountFrozen and models.ErrAccountClosed, and
+// purchases beyond the credit limit with models.ErrCreditLimitExceeded.
+// Transactions dated in a closed balance month are rejected with
+// models.ErrPeriodClosed or posted as adjustment entries, see Backdating.
 func (repo *TransactionRepository) Create(transaction models.Transaction) error {
-	query := "INSERT INTO transaction (account_id, month, dt, amt) VALUES (?,?,?,?)"
-	_, err := repo.DB.Exec(query, transaction.AccountID, transaction.Month, transaction.DateTime, transaction.Amount)
+	return repo.DB.WithTx(func(tx *storage.Tx) error {
+		_, err := repo.create(tx, transaction)
+		return err
+	})
+}
+
+// create posts transaction inside tx and returns its ID.
+func (repo *TransactionRepository) create(tx *storage.Tx, transaction models.Transaction) (int64, error) {
+	repo.categorize(&transaction)
+	closedThrough, err := repo.AccountRepo.closedThrough(tx, transaction.AccountID)
+	if err != nil {
+		return 0, err
+	}
+	transaction, err = repo.openPeriod(transaction, closedThrough)
 	if err != nil {
-		return fmt.Errorf("error while creating transaction: %v", err)
+		return 0, err
+	}
+	// The balance month must exist before the insert, as transaction
+	// references it through a foreign key.
+	err = repo.BalanceRepo.updateAmountArithmetically(tx, transaction.AccountID, transaction.Month, transaction.Amount)
+	if err != nil {
+		return 0, err
 	}
-	err = repo.BalanceRepo.UpdateAmountArithmetically(transaction.AccountID, transaction.Month, transaction.Amount)
+	err = repo.DailyBalanceRepo.shift(tx, transaction.AccountID, transaction.DateTime, transaction.Amount)
+	if err != nil {
+		return 0, err
+	}
+	err = repo.checkAccount(tx, transaction)
+	if err != nil {
+		return 0, err
+	}
+	query := "INSERT INTO `transaction` (" + insertTransactionColumns + ") VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)"
+	transactionID, err := tx.InsertID(query, transactionValues(transaction)...)
+	if err != nil {
+		return 0, fmt.Errorf("error while creating transaction: %v", err)
+	}
+	transaction.ID = transactionID
+	if err := repo.LedgerRepo.journal(tx, []models.Transaction{transaction}); err != nil {
+		return 0, err
+	}
+
+	stats := models.NewMonthlyStats(transaction.AccountID, transaction.Month)
+	stats.Add(transaction.Amount)
+	if err := repo.MonthlyStatsRepo.Merge(tx, stats); err != nil {
+		return 0, err
+	}
+	return transactionID, nil
+}
+
+// BULK_INSERT_ROWS caps the rows of each multi-row INSERT, keeping the
+// statement under the bind parameter limits of every supported database.
+const BULK_INSERT_ROWS = 1000
+
+// CreateBatch posts many transactions in one database transaction. Rows are
+// inserted with multi-row statements and balances, account current balances
+// and monthly stats are updated once per account month with the aggregated
+// delta, instead of once per transaction. Each transaction is checked as
+// Create would check it, in batch order, and either every transaction is
+// posted or none is.
+func (repo *TransactionRepository) CreateBatch(transactions []models.Transaction) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+	var accountIDs []int64
+	for _, transaction := range transactions {
+		if transaction.AccountID == 0 || transaction.Month == "" {
+			return errors.New("every transaction of a batch must have an account ID and a month")
+		}
+		accountIDs = append(accountIDs, transaction.AccountID)
+	}
+	// A stable order makes concurrent batches lock rows in the same order.
+	slices.Sort(accountIDs)
+	accountIDs = slices.Compact(accountIDs)
+	// Categories and periods are set on a copy to leave the caller's slice
+	// as given.
+	transactions = slices.Clone(transactions)
+
+	return repo.DB.WithTx(func(tx *storage.Tx) error {
+		// The accounts are locked before they are read, so their status,
+		// balance and closed months cannot change until the batch is posted.
+		accounts := map[int64]models.Account{}
+		lastIDs := map[int64]int64{}
+		for _, accountID := range accountIDs {
+			if err := repo.AccountRepo.updateCurrentBalanceAmountArithmetrically(tx, accountID, 0); err != nil {
+				return err
+			}
+			account, err := repo.AccountRepo.forPosting(tx, accountID, time.Now())
+			if err != nil {
+				return err
+			}
+			accounts[accountID] = account
+			lastIDs[accountID], err = repo.lastID(tx, accountID)
+			if err != nil {
+				return err
+			}
+		}
+		// Each transaction is checked on the balance the ones before it
+		// leave, as if they were posted one by one.
+		for i := range transactions {
+			repo.categorize(&transactions[i])
+			account := accounts[transactions[i].AccountID]
+			var err error
+			transactions[i], err = repo.openPeriod(transactions[i], account.ClosedThrough)
+			if err != nil {
+				return err
+			}
+			account.CurrentBalanceAmount += transactions[i].Amount
+			if err := account.Allows(transactions[i]); err != nil {
+				return fmt.Errorf("error while posting transaction to account %d: %w", account.ID, err)
+			}
+			accounts[account.ID] = account
+		}
+
+		type accountMonth struct {
+			accountID int64
+			month     string
+		}
+		deltas := map[accountMonth]*models.MonthlyStats{}
+		var keys []accountMonth
+		days := map[int64]map[time.Time]int64{}
+		for _, transaction := range transactions {
+			key := accountMonth{transaction.AccountID, transaction.Month}
+			delta, ok := deltas[key]
+			if !ok {
+				stats := models.NewMonthlyStats(key.accountID, key.month)
+				delta = &stats
+				deltas[key] = delta
+				keys = append(keys, key)
+			}
+			delta.Add(transaction.Amount)
+			if days[transaction.AccountID] == nil {
+				days[transaction.AccountID] = map[time.Time]int64{}
+			}
+			days[transaction.AccountID][models.Day(transaction.DateTime)] += transaction.Amount
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if keys[i].accountID != keys[j].accountID {
+				return keys[i].accountID < keys[j].accountID
+			}
+			return keys[i].month < keys[j].month
+		})
+
+		for _, key := range keys {
+			delta := deltas[key]
+			err := repo.BalanceRepo.updateAmountArithmetically(tx, key.accountID, key.month, delta.CreditSum+delta.DebitSum)
+			if err != nil {
+				return err
+			}
+		}
+		for _, accountID := range accountIDs {
+			if err := repo.DailyBalanceRepo.shiftDays(tx, accountID, days[accountID]); err != nil {
+				return err
+			}
+		}
+
+		for start := 0; start < len(transactions); start += BULK_INSERT_ROWS {
+			end := min(start+BULK_INSERT_ROWS, len(transactions))
+			if err := insertTransactions(tx, transactions[start:end]); err != nil {
+				return err
+			}
+		}
+		// The rows of a multi-row insert do not return their IDs. The accounts
+		// are locked, so their rows are the ones after their last ID before
+		// the batch.
+		for _, accountID := range accountIDs {
+			if _, err := repo.LedgerRepo.journalInserted(tx, accountID, lastIDs[accountID]); err != nil {
+				return err
+			}
+		}
+
+		for _, key := range keys {
+			if err := repo.MonthlyStatsRepo.Merge(tx, *deltas[key]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// lastID returns the ID of the last transaction of an account, or 0 when it
+// has none.
+func (repo *TransactionRepository) lastID(q storage.Querier, accountID int64) (int64, error) {
+	var id int64
+	err := q.QueryRow("SELECT COALESCE(MAX(id), 0) FROM `transaction` WHERE account_id = ?", accountID).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("error while reading last transaction: %v", err)
+	}
+	return id, nil
+}
+
+// openPeriod returns transaction as it must be posted given the last closed
+// month of its account: as it is when its month is open, and as an adjustment
+// entry or an error otherwise.
+func (repo *TransactionRepository) openPeriod(transaction models.Transaction, closedThrough string) (models.Transaction, error) {
+	if !(models.Account{ClosedThrough: closedThrough}).IsClosedMonth(transaction.Month) {
+		return transaction, nil
+	}
+	if repo.Backdating != models.BACKDATE_ADJUST {
+		return models.Transaction{}, fmt.Errorf("error while posting transaction into %s on account %d: %w",
+			transaction.Month, transaction.AccountID, models.ErrPeriodClosed)
+	}
+	return transaction.AsAdjustment(closedThrough)
+}
+
+// checkAccount must run after the account current balance was updated in q,
+// see AccountRepository.forPosting. The funds are those of now, whatever the
+// date of the transaction, so holds are held now too.
+func (repo *TransactionRepository) checkAccount(q storage.Querier, transaction models.Transaction) error {
+	account, err := repo.AccountRepo.forPosting(q, transaction.AccountID, time.Now())
 	if err != nil {
 		return err
 	}
+	if err := account.Allows(transaction); err != nil {
+		return fmt.Errorf("error while posting transaction to account %d: %w", transaction.AccountID, err)
+	}
+	return nil
+}
+
+func insertTransactions(q storage.Querier, transactions []models.Transaction) error {
+	var sb strings.Builder
+	sb.WriteString("INSERT INTO `transaction` (" + insertTransactionColumns + ") VALUES ")
+	args := make([]any, 0, len(transactions)*15)
+	for i, transaction := range transactions {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString("(?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)")
+		args = append(args, transactionValues(transaction)...)
+	}
+
+	_, err := q.Exec(sb.String(), args...)
+	if err != nil {
+		return fmt.Errorf("error while creating transactions: %v", err)
+	}
 	return nil
 }
 
+func transactionValues(transaction models.Transaction) []any {
+	return []any{transaction.AccountID, transaction.Month, transaction.DateTime, transaction.Amount, transaction.Type,
+		transaction.Description, transaction.Merchant, transaction.MCC, transaction.Channel, transaction.Location,
+		transaction.Category, transaction.CategoryOverride, nullID(transaction.TransferID), nullID(transaction.OriginalTransactionID),
+		sql.NullTime{Time: transaction.ValueDate, Valid: !transaction.ValueDate.IsZero()}}
+}
+
+// nullID stores an unset reference to another row as NULL.
+func nullID(id int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: id, Valid: id != 0}
+}
+
+func (repo *TransactionRepository) categorize(transaction *models.Transaction) {
+	if transaction.Category == "" && repo.Categorize != nil {
+		transaction.Category = repo.Categorize(*transaction)
+	}
+}
+
 func (repo *TransactionRepository) GetByAccountID(accountID int64) ([]models.Transaction, error) {
-	query := "SELECT id, account_id, month, dt, amt FROM transaction WHERE account_id = ? ORDER BY dt DESC"
+	query := "SELECT " + transactionColumns + " FROM `transaction` WHERE account_id = ? ORDER BY dt DESC"
 	rows, err := repo.DB.Query(query, accountID)
 	if err != nil {
 		return nil, err
@@ -35,8 +309,7 @@ func (repo *TransactionRepository) GetByAccountID(accountID int64) ([]models.Tra
 
 	var transactions []models.Transaction
 	for rows.Next() {
-		var transaction models.Transaction
-		err := rows.Scan(&transaction.ID, &transaction.AccountID, &transaction.Month, &transaction.DateTime, &transaction.Amount)
+		transaction, err := scanTransaction(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -46,7 +319,7 @@ func (repo *TransactionRepository) GetByAccountID(accountID int64) ([]models.Tra
 }
 
 func (repo *TransactionRepository) GetByAccountIDMonth(accountID int64, month string) ([]models.Transaction, error) {
-	query := "SELECT id, account_id, month, dt, amt FROM transaction WHERE account_id = ? AND month = ? ORDER BY dt DESC"
+	query := "SELECT " + transactionColumns + " FROM `transaction` WHERE account_id = ? AND month = ? ORDER BY dt DESC"
 	rows, err := repo.DB.Query(query, accountID, month)
 	if err != nil {
 		return nil, err
@@ -55,8 +328,7 @@ func (repo *TransactionRepository) GetByAccountIDMonth(accountID int64, month st
 
 	var transactions []models.Transaction
 	for rows.Next() {
-		var transaction models.Transaction
-		err := rows.Scan(&transaction.ID, &transaction.AccountID, &transaction.Month, &transaction.DateTime, &transaction.Amount)
+		transaction, err := scanTransaction(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -66,7 +338,7 @@ func (repo *TransactionRepository) GetByAccountIDMonth(accountID int64, month st
 }
 
 func (repo *TransactionRepository) GetNumberOfTransactions(accountID int64, month string) (int64, error) {
-	query := " SELECT SUM(amt) FROM transaction WHERE account_id = ? AND month = ? "
+	query := "SELECT COUNT(*) FROM `transaction` WHERE account_id = ? AND month = ?"
 
 	var transactionAmount int64
 	err := repo.DB.QueryRow(query, accountID, month).Scan(&transactionAmount)
@@ -81,7 +353,7 @@ func (repo *TransactionRepository) GetNumberOfTransactions(accountID int64, mont
 }
 
 func (repo *TransactionRepository) GetAverageDebitAmount(accountID int64, month string) (float64, error) {
-	query := "SELECT AVG(amt) FROM transaction WHERE account_id = ? AND month = ? AND amt < 0"
+	query := "SELECT COALESCE(AVG(amt), 0) FROM `transaction` WHERE account_id = ? AND month = ? AND amt < 0"
 
 	var avgDebit float64
 	err := repo.DB.QueryRow(query, accountID, month).Scan(&avgDebit)
@@ -98,7 +370,7 @@ func (repo *TransactionRepository) GetAverageDebitAmount(accountID int64, month
 }
 
 func (repo *TransactionRepository) GetAverageCreditAmount(accountID int64, month string) (float64, error) {
-	query := "SELECT AVG(amt) FROM transaction WHERE account_id = ? AND month = ? AND amt > 0"
+	query := "SELECT COALESCE(AVG(amt), 0) FROM `transaction` WHERE account_id = ? AND month = ? AND amt > 0"
 
 	var avgCredit float64
 	err := repo.DB.QueryRow(query, accountID, month).Scan(&avgCredit)
@@ -113,3 +385,166 @@ func (repo *TransactionRepository) GetAverageCreditAmount(accountID int64, month
 
 	return avgCredit, nil
 }
+
+// BalanceBefore is the sum of the account transactions dated before t.
+func (repo *TransactionRepository) BalanceBefore(accountID int64, t time.Time) (int64, error) {
+	query := "SELECT COALESCE(SUM(amt), 0) FROM `transaction` WHERE account_id = ? AND dt < ?"
+	var balance int64
+	err := repo.DB.QueryRow(query, accountID, t).Scan(&balance)
+	if err != nil {
+		return 0, fmt.Errorf("error while computing balance before %s: %v", t.Format(time.DateOnly), err)
+	}
+	return balance, nil
+}
+
+// Totals returns the sums of the debits and credits of an account dated from
+// from, inclusive, to to, exclusive. Debits are negative.
+func (repo *TransactionRepository) Totals(accountID int64, from time.Time, to time.Time) (int64, int64, error) {
+	query := `SELECT COALESCE(SUM(CASE WHEN amt < 0 THEN amt ELSE 0 END), 0), COALESCE(SUM(CASE WHEN amt > 0 THEN amt ELSE 0 END), 0)
+			  FROM ` + "`transaction`" + ` WHERE account_id = ? AND dt >= ? AND dt < ?`
+	var debits, credits int64
+	err := repo.DB.QueryRow(query, accountID, from, to).Scan(&debits, &credits)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error while computing transaction totals: %v", err)
+	}
+	return debits, credits, nil
+}
+
+// CycleTotals sums the transactions of an account dated from from,
+// inclusive, to to, exclusive, into purchases, bank charges and credits, see
+// models.CycleTotals.
+func (repo *TransactionRepository) CycleTotals(accountID int64, from time.Time, to time.Time) (models.CycleTotals, error) {
+	system := strings.TrimSuffix(strings.Repeat("?,", len(models.SYSTEM_TRANSACTION_TYPES)), ",")
+	query := `SELECT COALESCE(SUM(CASE WHEN amt < 0 AND txn_type NOT IN (` + system + `) THEN amt ELSE 0 END), 0),
+			  COALESCE(SUM(CASE WHEN amt < 0 AND txn_type IN (` + system + `) THEN amt ELSE 0 END), 0),
+			  COALESCE(SUM(CASE WHEN amt > 0 THEN amt ELSE 0 END), 0)
+			  FROM ` + "`transaction`" + ` WHERE account_id = ? AND dt >= ? AND dt < ?`
+	var args []any
+	for range 2 {
+		for _, txnType := range models.SYSTEM_TRANSACTION_TYPES {
+			args = append(args, txnType)
+		}
+	}
+	args = append(args, accountID, from, to)
+
+	var totals models.CycleTotals
+	err := repo.DB.QueryRow(query, args...).Scan(&totals.Purchases, &totals.Charges, &totals.Credits)
+	if err != nil {
+		return models.CycleTotals{}, fmt.Errorf("error while computing cycle totals: %v", err)
+	}
+	return totals, nil
+}
+
+// CategoryTotals sums the transactions of an account dated from from to to,
+// exclusive, per effective category.
+func (repo *TransactionRepository) CategoryTotals(accountID int64, from time.Time, to time.Time) ([]models.CategoryTotal, error) {
+	query := `SELECT CASE WHEN category_override <> '' THEN category_override ELSE category END AS effective_category, COUNT(*),
+			  COALESCE(SUM(CASE WHEN amt < 0 THEN amt ELSE 0 END), 0), COALESCE(SUM(CASE WHEN amt > 0 THEN amt ELSE 0 END), 0)
+			  FROM ` + "`transaction`" + ` WHERE account_id = ? AND dt >= ? AND dt < ?
+			  GROUP BY effective_category ORDER BY effective_category`
+	rows, err := repo.DB.Query(query, accountID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("error while computing category totals: %v", err)
+	}
+	defer rows.Close()
+
+	var totals []models.CategoryTotal
+	for rows.Next() {
+		var total models.CategoryTotal
+		if err := rows.Scan(&total.Category, &total.Count, &total.Debits, &total.Credits); err != nil {
+			return nil, err
+		}
+		if total.Category == "" {
+			total.Category = models.CATEGORY_UNCATEGORIZED
+		}
+		totals = append(totals, total)
+	}
+	return totals, rows.Err()
+}
+
+// SetCategoryOverride stores the category chosen by hand for a transaction;
+// an empty category removes the override.
+func (repo *TransactionRepository) SetCategoryOverride(transactionID int64, category string) error {
+	query := "UPDATE `transaction` SET category_override = ? WHERE id = ?"
+	result, err := repo.DB.Exec(query, category, transactionID)
+	if err != nil {
+		return fmt.Errorf("error while overriding transaction category: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error while overriding transaction category: %v", err)
+	}
+	if rowsAffected == 0 {
+		return errors.New("transaction not found")
+	}
+	return nil
+}
+
+// RECATEGORIZE_PAGE_SIZE is the number of transactions read, and updated in
+// one database transaction, at a time by Recategorize.
+const RECATEGORIZE_PAGE_SIZE = 1000
+
+// Recategorize runs Categorize again on the transactions of an account, or
+// of every account when accountID is 0, and returns how many changed
+// category. Manual overrides are kept.
+func (repo *TransactionRepository) Recategorize(accountID int64) (int, error) {
+	if repo.Categorize == nil {
+		return 0, errors.New("no categorization rules configured")
+	}
+
+	changed := 0
+	var lastID int64
+	for {
+		query := "SELECT " + transactionColumns + " FROM `transaction` WHERE id > ?"
+		args := []any{lastID}
+		if accountID != 0 {
+			query += " AND account_id = ?"
+			args = append(args, accountID)
+		}
+		query += " ORDER BY id LIMIT ?"
+		args = append(args, RECATEGORIZE_PAGE_SIZE)
+
+		rows, err := repo.DB.Query(query, args...)
+		if err != nil {
+			return changed, fmt.Errorf("error while reading transactions: %v", err)
+		}
+		var page []models.Transaction
+		for rows.Next() {
+			transaction, err := scanTransaction(rows)
+			if err != nil {
+				rows.Close()
+				return changed, err
+			}
+			page = append(page, transaction)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return changed, err
+		}
+		if len(page) == 0 {
+			return changed, nil
+		}
+		lastID = page[len(page)-1].ID
+
+		var updates []models.Transaction
+		for _, transaction := range page {
+			if category := repo.Categorize(transaction); category != transaction.Category {
+				transaction.Category = category
+				updates = append(updates, transaction)
+			}
+		}
+		err = repo.DB.WithTx(func(tx *storage.Tx) error {
+			for _, transaction := range updates {
+				_, err := tx.Exec("UPDATE `transaction` SET category = ? WHERE id = ?", transaction.Category, transaction.ID)
+				if err != nil {
+					return fmt.Errorf("error while recategorizing transaction %d: %v", transaction.ID, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return changed, err
+		}
+		changed += len(updates)
+	}
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"storichallenge_layer/models"
+)
+
+// BENCH_ROWS is the size of a bulk load measured by the benchmarks.
+const BENCH_ROWS = 100_000
+
+const (
+	BENCH_ACCOUNTS   = 10
+	BENCH_BATCH_SIZE = 5_000
+)
+
+// benchTransactions returns BENCH_ROWS transactions spread over the accounts
+// and the months of a year, which the accounts can always pay.
+func benchTransactions(b *testing.B, store *testStore) []models.Transaction {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	accountIDs := make([]int64, BENCH_ACCOUNTS)
+	for i := range accountIDs {
+		accountIDs[i] = store.newAccount(b, 1_000_000_00, start)
+	}
+
+	transactions := make([]models.Transaction, BENCH_ROWS)
+	for i := range transactions {
+		amount := int64(100 + i%5_000)
+		if i%3 == 0 {
+			amount = -amount
+		}
+		dateTime := start.Add(time.Duration(i) * 5 * time.Minute)
+		transaction, err := models.NewTransaction(amount, dateTime, accountIDs[i%BENCH_ACCOUNTS])
+		if err != nil {
+			b.Fatal(err)
+		}
+		transactions[i] = transaction
+	}
+	return transactions
+}
+
+func BenchmarkCreateBatch(b *testing.B) {
+	for b.Loop() {
+		b.StopTimer()
+		store := newTestStore(b)
+		transactions := benchTransactions(b, store)
+		b.StartTimer()
+
+		for start := 0; start < len(transactions); start += BENCH_BATCH_SIZE {
+			end := min(start+BENCH_BATCH_SIZE, len(transactions))
+			if err := store.Transactions.CreateBatch(transactions[start:end]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	b.ReportMetric(float64(BENCH_ROWS*b.N)/b.Elapsed().Seconds(), "rows/s")
+}
+
+func BenchmarkCreate(b *testing.B) {
+	for b.Loop() {
+		b.StopTimer()
+		store := newTestStore(b)
+		transactions := benchTransactions(b, store)
+		b.StartTimer()
+
+		for _, transaction := range transactions {
+			if err := store.Transactions.Create(transaction); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	b.ReportMetric(float64(BENCH_ROWS*b.N)/b.Elapsed().Seconds(), "rows/s")
+}
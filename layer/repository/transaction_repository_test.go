@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"storichallenge_layer/models"
+)
+
+func TestGetNumberOfTransactions(t *testing.T) {
+	store := newTestStore(t)
+	accountID := store.newAccount(t, 0, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	store.post(t, accountID, 1000_00, time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC))
+	store.post(t, accountID, -250_00, time.Date(2026, 3, 9, 12, 0, 0, 0, time.UTC))
+	store.post(t, accountID, -50_00, time.Date(2026, 3, 30, 12, 0, 0, 0, time.UTC))
+	store.post(t, accountID, -10_00, time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		month string
+		want  int64
+	}{
+		{"2026/02", 0},
+		{"2026/03", 3},
+		{"2026/04", 1},
+	}
+	for _, test := range tests {
+		got, err := store.Transactions.GetNumberOfTransactions(accountID, test.month)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("GetNumberOfTransactions(%s) = %d, want %d", test.month, got, test.want)
+		}
+	}
+}
+
+// batch builds transactions of the given types and amounts on an account.
+func batch(tb testing.TB, accountID int64, at time.Time, postings ...any) []models.Transaction {
+	tb.Helper()
+	var transactions []models.Transaction
+	for i := 0; i < len(postings); i += 2 {
+		details := models.TransactionDetails{Type: postings[i].(models.TransactionType)}
+		transaction, err := models.NewTransactionWithDetails(int64(postings[i+1].(int)), at, accountID, details)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		transactions = append(transactions, transaction)
+	}
+	return transactions
+}
+
+func TestCreateBatchChecksEachTransaction(t *testing.T) {
+	store := newTestStore(t)
+	at := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	creditID := store.newCreditAccount(t, 1000_00).ID
+
+	// The purchase is beyond the limit until the payment after it is posted.
+	overLimit := batch(t, creditID, at, models.TRANSACTION_TYPE_PURCHASE, -1200_00, models.TRANSACTION_TYPE_PAYMENT, 500_00)
+	checkErr(t, store.Transactions.CreateBatch(overLimit), models.ErrCreditLimitExceeded)
+	store.checkBalance(t, creditID, 0)
+	slices.Reverse(overLimit)
+	if err := store.Transactions.CreateBatch(overLimit); err != nil {
+		t.Fatal(err)
+	}
+	store.checkBalance(t, creditID, -700_00)
+
+	// A frozen account is charged its fees but rejects purchases, even when
+	// a fee is the largest debit of the batch.
+	debitID := store.newAccount(t, 1000_00, at)
+	if _, err := store.DB.Exec("UPDATE account SET status = ? WHERE id = ?", models.ACCOUNT_STATUS_FROZEN, debitID); err != nil {
+		t.Fatal(err)
+	}
+	frozen := batch(t, debitID, at, models.TRANSACTION_TYPE_FEE, -100_00, models.TRANSACTION_TYPE_PURCHASE, -5_00)
+	checkErr(t, store.Transactions.CreateBatch(frozen), models.ErrAccountFrozen)
+	if err := store.Transactions.CreateBatch(frozen[:1]); err != nil {
+		t.Fatal(err)
+	}
+	store.checkBalance(t, debitID, 900_00)
+	store.checkLedger(t)
+}
+
+func TestCreateBatchJournalsItsTransactionsOnly(t *testing.T) {
+	store := newTestStore(t)
+	at := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	accountID := store.newAccount(t, 1000_00, at)
+	// A transaction posted before the ledger existed has no journal entry.
+	unposted := store.post(t, accountID, -100_00, at)
+	if _, err := store.DB.Exec("DELETE FROM journal_entry WHERE transaction_id = ?", unposted); err != nil {
+		t.Fatal(err)
+	}
+
+	transactions := batch(t, accountID, at, models.TRANSACTION_TYPE_PURCHASE, -10_00, models.TRANSACTION_TYPE_PAYMENT, 20_00)
+	if err := store.Transactions.CreateBatch(transactions); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Ledger.GetEntryByTransactionID(unposted); err == nil {
+		t.Error("the batch journaled a transaction it did not post")
+	}
+	journaled, err := store.Ledger.Backfill(accountID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if journaled != 1 {
+		t.Errorf("backfill journaled %d transactions, want 1: the batch left its own unjournaled", journaled)
+	}
+	store.checkLedger(t)
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"storichallenge_layer/models"
+	"storichallenge_layer/storage"
+	"time"
+)
+
+const transferColumns = "id, client_reference, from_account_id, to_account_id, amt, description, dt, status, reversed_at"
+
+type TransferRepository struct {
+	DB              *storage.DB
+	AccountRepo     *AccountRepository
+	TransactionRepo *TransactionRepository
+}
+
+// Create stores a transfer and posts its debit and credit atomically. The
+// source account must have the funds, otherwise nothing is stored and
+// models.ErrInsufficientFunds is returned. When the client reference was
+// already used for the same transfer, that transfer is returned and nothing
+// is posted again; when it was used for another one, the error is
+// models.ErrTransferReferenceConflict.
+func (repo *TransferRepository) Create(transfer models.Transfer) (models.Transfer, error) {
+	err := repo.DB.WithTx(func(tx *storage.Tx) error {
+		var err error
+		query := "INSERT INTO transfer (client_reference, from_account_id, to_account_id, amt, description, dt, status) VALUES (?,?,?,?,?,?,?)"
+		transfer.ID, err = tx.InsertID(query, transfer.ClientReference, transfer.FromAccountID, transfer.ToAccountID, transfer.Amount,
+			transfer.Description, transfer.DateTime, transfer.Status)
+		if err != nil {
+			return fmt.Errorf("error while creating transfer: %w", err)
+		}
+
+		debit, credit, err := transfer.Transactions()
+		if err != nil {
+			return err
+		}
+		return repo.post(tx, debit, credit)
+	})
+	if storage.IsUniqueViolation(err, "client_reference") {
+		existing, err := repo.GetByClientReference(transfer.ClientReference)
+		if err != nil {
+			return models.Transfer{}, err
+		}
+		if !existing.Matches(transfer) {
+			return models.Transfer{}, models.ErrTransferReferenceConflict
+		}
+		return existing, nil
+	}
+	if err != nil {
+		return models.Transfer{}, err
+	}
+	return transfer, nil
+}
+
+// Reverse moves the money of a posted transfer back with a pair of reversal
+// transactions dated at. The destination account must still have the funds.
+func (repo *TransferRepository) Reverse(transferID int64, at time.Time) (models.Transfer, error) {
+	var transfer models.Transfer
+	err := repo.DB.WithTx(func(tx *storage.Tx) error {
+		query := "UPDATE transfer SET status = ?, reversed_at = ? WHERE id = ? AND status = ?"
+		result, err := tx.Exec(query, models.TRANSFER_STATUS_REVERSED, at, transferID, models.TRANSFER_STATUS_POSTED)
+		if err != nil {
+			return fmt.Errorf("error while reversing transfer: %v", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("error while reversing transfer: %v", err)
+		}
+		if rowsAffected == 0 {
+			if _, err := repo.getByID(tx, transferID); err != nil {
+				return err
+			}
+			return models.ErrTransferReversed
+		}
+
+		transfer, err = repo.getByID(tx, transferID)
+		if err != nil {
+			return err
+		}
+		credit, debit, err := transfer.ReversalTransactions(at)
+		if err != nil {
+			return err
+		}
+		return repo.post(tx, debit, credit)
+	})
+	if err != nil {
+		return models.Transfer{}, err
+	}
+	return transfer, nil
+}
+
+// post posts both transactions inside tx in the order of their account IDs,
+// so that concurrent transfers between the same accounts lock them in the
+// same order, and then checks the funds of the debited account.
+func (repo *TransferRepository) post(tx *storage.Tx, debit models.Transaction, credit models.Transaction) error {
+	first, second := debit, credit
+	if second.AccountID < first.AccountID {
+		first, second = second, first
+	}
+	if _, err := repo.TransactionRepo.create(tx, first); err != nil {
+		return err
+	}
+	if _, err := repo.TransactionRepo.create(tx, second); err != nil {
+		return err
+	}
+
+	account, err := repo.AccountRepo.forPosting(tx, debit.AccountID, time.Now())
+	if err != nil {
+		return err
+	}
+	if account.AvailableFunds() < 0 {
+		return fmt.Errorf("error while transferring from account %d: %w", debit.AccountID, models.ErrInsufficientFunds)
+	}
+	return nil
+}
+
+func (repo *TransferRepository) GetByID(transferID int64) (models.Transfer, error) {
+	return repo.getByID(repo.DB, transferID)
+}
+
+func (repo *TransferRepository) getByID(q storage.Querier, transferID int64) (models.Transfer, error) {
+	query := "SELECT " + transferColumns + " FROM transfer WHERE id = ?"
+	return scanTransferRow(q.QueryRow(query, transferID))
+}
+
+func (repo *TransferRepository) GetByClientReference(clientReference string) (models.Transfer, error) {
+	query := "SELECT " + transferColumns + " FROM transfer WHERE client_reference = ?"
+	return scanTransferRow(repo.DB.QueryRow(query, clientReference))
+}
+
+// GetByAccountID returns the transfers from or to an account, newest first.
+func (repo *TransferRepository) GetByAccountID(accountID int64) ([]models.Transfer, error) {
+	query := "SELECT " + transferColumns + " FROM transfer WHERE from_account_id = ? OR to_account_id = ? ORDER BY dt DESC, id DESC"
+	rows, err := repo.DB.Query(query, accountID, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading transfers: %v", err)
+	}
+	defer rows.Close()
+
+	var transfers []models.Transfer
+	for rows.Next() {
+		transfer, err := scanTransfer(rows)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, transfer)
+	}
+	return transfers, rows.Err()
+}
+
+func scanTransferRow(row *sql.Row) (models.Transfer, error) {
+	transfer, err := scanTransfer(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Transfer{}, models.ErrTransferNotFound
+		}
+		return models.Transfer{}, fmt.Errorf("error while reading transfer: %v", err)
+	}
+	return transfer, nil
+}
+
+func scanTransfer(row interface{ Scan(dest ...any) error }) (models.Transfer, error) {
+	var transfer models.Transfer
+	var reversedAt sql.NullTime
+	err := row.Scan(&transfer.ID, &transfer.ClientReference, &transfer.FromAccountID, &transfer.ToAccountID, &transfer.Amount,
+		&transfer.Description, &transfer.DateTime, &transfer.Status, &reversedAt)
+	transfer.ReversedAt = reversedAt.Time
+	return transfer, err
+}
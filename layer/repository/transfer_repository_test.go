@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"storichallenge_layer/models"
+)
+
+func newTestTransfer(t *testing.T, from int64, to int64, amount int64, reference string, at time.Time) models.Transfer {
+	t.Helper()
+	transfer, err := models.NewTransfer(from, to, amount, reference, "Rent", at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return transfer
+}
+
+func TestTransferCreate(t *testing.T) {
+	store := newTestStore(t)
+	at := time.Date(2026, 3, 10, 9, 30, 0, 0, time.UTC)
+	from := store.newAccount(t, 1000_00, at)
+	to := store.newAccount(t, 50_00, at)
+
+	transfer, err := store.Transfers.Create(newTestTransfer(t, from, to, 300_00, "ref-1", at))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transfer.ID == 0 || transfer.Status != models.TRANSFER_STATUS_POSTED {
+		t.Errorf("transfer = %+v, want a posted transfer with an ID", transfer)
+	}
+	store.checkBalance(t, from, 700_00)
+	store.checkBalance(t, to, 350_00)
+	store.checkLedger(t)
+
+	// A retry with the same client reference returns the transfer and posts
+	// nothing.
+	replay, err := store.Transfers.Create(newTestTransfer(t, from, to, 300_00, "ref-1", at.Add(time.Minute)))
+	if err != nil {
+		t.Fatalf("replaying the transfer: %v", err)
+	}
+	if replay.ID != transfer.ID {
+		t.Errorf("replay ID = %d, want %d", replay.ID, transfer.ID)
+	}
+	store.checkBalance(t, from, 700_00)
+	store.checkBalance(t, to, 350_00)
+
+	_, err = store.Transfers.Create(newTestTransfer(t, from, to, 200_00, "ref-1", at))
+	checkErr(t, err, models.ErrTransferReferenceConflict)
+	store.checkBalance(t, from, 700_00)
+	store.checkBalance(t, to, 350_00)
+	store.checkLedger(t)
+}
+
+func TestTransferCreateInsufficientFunds(t *testing.T) {
+	store := newTestStore(t)
+	at := time.Date(2026, 3, 10, 9, 30, 0, 0, time.UTC)
+	from := store.newAccount(t, 100_00, at)
+	to := store.newAccount(t, 0, at)
+
+	_, err := store.Transfers.Create(newTestTransfer(t, from, to, 100_01, "ref-1", at))
+	checkErr(t, err, models.ErrInsufficientFunds)
+	_, err = store.Transfers.GetByClientReference("ref-1")
+	checkErr(t, err, models.ErrTransferNotFound)
+	store.checkBalance(t, from, 100_00)
+	store.checkBalance(t, to, 0)
+	store.checkLedger(t)
+
+	// The reference was not used up by the failed attempt.
+	if _, err := store.Transfers.Create(newTestTransfer(t, from, to, 100_00, "ref-1", at)); err != nil {
+		t.Fatal(err)
+	}
+	store.checkBalance(t, from, 0)
+	store.checkBalance(t, to, 100_00)
+	store.checkLedger(t)
+}
+
+func TestTransferReverse(t *testing.T) {
+	store := newTestStore(t)
+	at := time.Date(2026, 3, 10, 9, 30, 0, 0, time.UTC)
+	from := store.newAccount(t, 1000_00, at)
+	to := store.newAccount(t, 0, at)
+
+	transfer, err := store.Transfers.Create(newTestTransfer(t, from, to, 300_00, "ref-1", at))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reversed, err := store.Transfers.Reverse(transfer.ID, at.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reversed.Status != models.TRANSFER_STATUS_REVERSED || reversed.ReversedAt.IsZero() {
+		t.Errorf("transfer = %+v, want it reversed", reversed)
+	}
+	store.checkBalance(t, from, 1000_00)
+	store.checkBalance(t, to, 0)
+	store.checkLedger(t)
+
+	_, err = store.Transfers.Reverse(transfer.ID, at.Add(2*time.Hour))
+	checkErr(t, err, models.ErrTransferReversed)
+	store.checkBalance(t, from, 1000_00)
+	store.checkBalance(t, to, 0)
+
+	_, err = store.Transfers.Reverse(transfer.ID+100, at.Add(2*time.Hour))
+	checkErr(t, err, models.ErrTransferNotFound)
+	store.checkLedger(t)
+}
+
+func TestTransferReverseInsufficientFunds(t *testing.T) {
+	store := newTestStore(t)
+	at := time.Date(2026, 3, 10, 9, 30, 0, 0, time.UTC)
+	from := store.newAccount(t, 1000_00, at)
+	to := store.newAccount(t, 0, at)
+
+	transfer, err := store.Transfers.Create(newTestTransfer(t, from, to, 300_00, "ref-1", at))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The destination spends part of the money before the reversal.
+	store.post(t, to, -200_00, at.Add(time.Minute))
+
+	_, err = store.Transfers.Reverse(transfer.ID, at.Add(time.Hour))
+	checkErr(t, err, models.ErrInsufficientFunds)
+	stored, err := store.Transfers.GetByID(transfer.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != models.TRANSFER_STATUS_POSTED {
+		t.Errorf("transfer status = %s, want it still posted", stored.Status)
+	}
+	store.checkBalance(t, from, 700_00)
+	store.checkBalance(t, to, 100_00)
+	store.checkLedger(t)
+}
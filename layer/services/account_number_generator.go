@@ -0,0 +1,32 @@
+package services
+
+import (
+	"crypto/rand"
+	"math/big"
+	"storichallenge_layer/models"
+)
+
+// MAX_ACCOUNT_NUMBER_ATTEMPTS bounds how many fresh numbers CreateAccount
+// tries when the generated one is already taken.
+const MAX_ACCOUNT_NUMBER_ATTEMPTS = 5
+
+// AccountNumberGenerator issues CLABE account numbers under a fixed bank and
+// branch prefix. The 11 digit account part is random, so concurrent
+// generators do not need to coordinate; the UNIQUE constraint on
+// account_number catches the rare collision and the caller retries.
+type AccountNumberGenerator struct {
+	BankCode   string
+	BranchCode string
+}
+
+func NewAccountNumberGenerator(bankCode string, branchCode string) *AccountNumberGenerator {
+	return &AccountNumberGenerator{BankCode: bankCode, BranchCode: branchCode}
+}
+
+func (g *AccountNumberGenerator) Generate() (string, error) {
+	serial, err := rand.Int(rand.Reader, big.NewInt(models.MAX_ACCOUNT_SERIAL))
+	if err != nil {
+		return "", err
+	}
+	return models.NewAccountNumber(g.BankCode, g.BranchCode, serial.Int64())
+}
@@ -1,42 +1,163 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"iter"
 	"storichallenge_layer/config"
 	"storichallenge_layer/models"
 	"storichallenge_layer/repository"
+	"storichallenge_layer/storage"
+	"storichallenge_layer/utils"
+	"storichallenge_layer/validation"
+	"strings"
+	"time"
 )
 
 type AccountService struct {
-	AccountRepo     *repository.AccountRepository
-	BalanceRepo     *repository.BalanceRepository
-	TransactionRepo *repository.TransactionRepository
+	AccountNumbers   *AccountNumberGenerator
+	Categorizer      *Categorizer
+	CustomerRepo     *repository.CustomerRepository
+	AccountRepo      *repository.AccountRepository
+	BalanceRepo      *repository.BalanceRepository
+	TransactionRepo  *repository.TransactionRepository
+	MonthlyStatsRepo *repository.MonthlyStatsRepository
+	StatementRepo    *repository.StatementRepository
+	AccrualRepo      *repository.AccrualRepository
+	InstallmentRepo  *repository.InstallmentRepository
+	TransferRepo     *repository.TransferRepository
+	HoldRepo         *repository.HoldRepository
+	LedgerRepo       *repository.LedgerRepository
+	DailyBalanceRepo *repository.DailyBalanceRepository
 }
 
-func NewAccountService() (*AccountService, error) {
-	db, err := config.ConnectToDB()
+// NewAccountService builds the service on top of the shared connection pool,
+// so it is cheap to call once per Lambda invocation.
+func NewAccountService(cfg config.Config) (*AccountService, error) {
+	db, err := config.GetDB(cfg.DB)
 	if err != nil {
 		return nil, err
 	}
+	return newAccountService(db, cfg)
+}
+
+// newAccountService wires the repositories of the service on db.
+func newAccountService(db *storage.DB, cfg config.Config) (*AccountService, error) {
+	categorizer, err := NewCategorizer(cfg.Categories.Rules)
+	if err != nil {
+		return nil, err
+	}
+	customerRepo := &repository.CustomerRepository{DB: db}
 	accountRepo := &repository.AccountRepository{DB: db}
 	balanceRepo := &repository.BalanceRepository{DB: db}
 	transactionRepo := &repository.TransactionRepository{DB: db}
+	monthlyStatsRepo := &repository.MonthlyStatsRepository{DB: db}
+	ledgerRepo := &repository.LedgerRepository{DB: db, AccountRepo: accountRepo}
+	dailyBalanceRepo := &repository.DailyBalanceRepository{DB: db, AccountRepo: accountRepo}
+	accountRepo.BalanceRepo = balanceRepo
+	balanceRepo.AccountRepo = accountRepo
+	balanceRepo.TransactionRepo = transactionRepo
+	transactionRepo.AccountRepo = accountRepo
+	transactionRepo.BalanceRepo = balanceRepo
+	transactionRepo.MonthlyStatsRepo = monthlyStatsRepo
+	transactionRepo.LedgerRepo = ledgerRepo
+	transactionRepo.DailyBalanceRepo = dailyBalanceRepo
+	transactionRepo.Categorize = categorizer.Categorize
+	transactionRepo.Backdating = models.BackdatePolicy(cfg.Periods.Backdating)
 
 	return &AccountService{
-		AccountRepo:     accountRepo,
-		BalanceRepo:     balanceRepo,
-		TransactionRepo: transactionRepo,
+		AccountNumbers:   NewAccountNumberGenerator(cfg.Accounts.BankCode, cfg.Accounts.BranchCode),
+		Categorizer:      categorizer,
+		CustomerRepo:     customerRepo,
+		AccountRepo:      accountRepo,
+		BalanceRepo:      balanceRepo,
+		TransactionRepo:  transactionRepo,
+		MonthlyStatsRepo: monthlyStatsRepo,
+		StatementRepo:    &repository.StatementRepository{DB: db},
+		AccrualRepo:      &repository.AccrualRepository{DB: db, TransactionRepo: transactionRepo},
+		InstallmentRepo:  &repository.InstallmentRepository{DB: db, AccountRepo: accountRepo, TransactionRepo: transactionRepo},
+		TransferRepo:     &repository.TransferRepository{DB: db, AccountRepo: accountRepo, TransactionRepo: transactionRepo},
+		HoldRepo:         &repository.HoldRepository{DB: db, AccountRepo: accountRepo, TransactionRepo: transactionRepo},
+		LedgerRepo:       ledgerRepo,
+		DailyBalanceRepo: dailyBalanceRepo,
 	}, nil
 }
 
-func (svc *AccountService) CreateAccount(account models.Account) (int64, error) {
-	accountID, err := svc.AccountRepo.Create(account)
+// CreateCustomer stores a customer validated with models.NewCustomer. Their
+// accounts are opened afterwards with CreateAccount.
+func (svc *AccountService) CreateCustomer(customer models.Customer) (int64, error) {
+	customerID, err := svc.CustomerRepo.Create(customer)
 	if err != nil {
 		return 0, err
 	}
-	return accountID, nil
+	return customerID, nil
+}
+
+func (svc *AccountService) GetCustomerByID(customerID int64) (models.Customer, error) {
+	customer, err := svc.CustomerRepo.GetByID(customerID)
+	if err != nil {
+		return models.Customer{}, err
+	}
+	return customer, nil
+}
+
+func (svc *AccountService) GetCustomerByCURP(curp string) (models.Customer, error) {
+	curp = strings.ToUpper(strings.TrimSpace(curp))
+	if !validation.IsCURPFormatOK(curp) {
+		return models.Customer{}, validation.NewFieldError("curp", validation.CodeCURPFormat, curp)
+	}
+	customer, err := svc.CustomerRepo.GetByCURP(curp)
+	if err != nil {
+		return models.Customer{}, err
+	}
+	return customer, nil
+}
+
+// GetCustomerAccounts returns every account of a customer.
+func (svc *AccountService) GetCustomerAccounts(customerID int64) ([]models.Account, error) {
+	accounts, err := svc.AccountRepo.GetByCustomerID(customerID)
+	if err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// CreateAccount stores a new account of an existing customer. When it has no
+// account number a CLABE is issued for it, retrying with a new one if the
+// number is already taken; a given account number must carry a valid check
+// digit.
+func (svc *AccountService) CreateAccount(account models.Account) (int64, error) {
+	if account.CustomerID == 0 {
+		return 0, validation.NewFieldError("customerID", validation.CodeRequired, "customerID")
+	}
+	if account.AccountNumber != "" {
+		if !validation.IsCLABEFormatOK(account.AccountNumber) {
+			return 0, validation.NewFieldError("accountNumber", validation.CodeAccountNumber, account.AccountNumber)
+		}
+		return svc.AccountRepo.Create(account)
+	}
+
+	for attempt := 1; ; attempt++ {
+		accountNumber, err := svc.AccountNumbers.Generate()
+		if err != nil {
+			return 0, err
+		}
+		account.AccountNumber = accountNumber
+
+		accountID, err := svc.AccountRepo.Create(account)
+		if err == nil {
+			return accountID, nil
+		}
+		if attempt >= MAX_ACCOUNT_NUMBER_ATTEMPTS || !storage.IsUniqueViolation(err, "account_number") {
+			return 0, err
+		}
+	}
 }
 
 func (svc *AccountService) GetAccountByAccountNumber(accountNumber string, includeBalances, includeTransactions bool) (models.Account, error) {
+	if !validation.IsCLABEFormatOK(accountNumber) {
+		return models.Account{}, validation.NewFieldError("accountNumber", validation.CodeAccountNumber, accountNumber)
+	}
 	account, err := svc.AccountRepo.GetByAccountNumber(accountNumber, includeBalances, includeTransactions)
 	if err != nil {
 		return models.Account{}, err
@@ -60,26 +181,587 @@ func (svc *AccountService) CreateTransaction(transaction models.Transaction) err
 	return nil
 }
 
+// CreateTransactions posts a batch of transactions atomically, with far fewer
+// statements than calling CreateTransaction for each of them.
+func (svc *AccountService) CreateTransactions(transactions []models.Transaction) error {
+	err := svc.TransactionRepo.CreateBatch(transactions)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// QueryTransactions returns one page of an account's transactions filtered by
+// date, amount and kind. Pass the returned NextCursor to get the next page.
+func (svc *AccountService) QueryTransactions(query models.TransactionQuery) (models.TransactionPage, error) {
+	page, err := svc.TransactionRepo.Query(query)
+	if err != nil {
+		return models.TransactionPage{}, err
+	}
+	return page, nil
+}
+
+// StreamTransactions iterates over every transaction matching query without
+// paging, for exporters that walk a whole history.
+func (svc *AccountService) StreamTransactions(query models.TransactionQuery) iter.Seq2[models.Transaction, error] {
+	return svc.TransactionRepo.Stream(query)
+}
+
+// GetCategoryTotals sums the transactions of an account month per category.
+func (svc *AccountService) GetCategoryTotals(accountID int64, month string) ([]models.CategoryTotal, error) {
+	from, err := utils.ParseMonthTime(month)
+	if err != nil {
+		return nil, validation.NewFieldError("month", validation.CodeMonthFormat, month)
+	}
+	totals, err := svc.TransactionRepo.CategoryTotals(accountID, from, from.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, err
+	}
+	return totals, nil
+}
+
+// SetTransactionCategory overrides the category of a transaction by hand.
+// The override survives recategorizations; an empty category removes it.
+func (svc *AccountService) SetTransactionCategory(transactionID int64, category string) error {
+	if category != "" && !svc.Categorizer.IsCategory(category) {
+		return validation.NewFieldError("category", validation.CodeCategory, category)
+	}
+	err := svc.TransactionRepo.SetCategoryOverride(transactionID, category)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// RecategorizeTransactions applies the current rules to the transactions
+// already posted to an account, or to every account when accountID is 0, and
+// returns how many changed category.
+func (svc *AccountService) RecategorizeTransactions(accountID int64) (int, error) {
+	changed, err := svc.TransactionRepo.Recategorize(accountID)
+	if err != nil {
+		return changed, err
+	}
+	return changed, nil
+}
+
+// GetMonthlyStats returns the precomputed figures of an account month.
+func (svc *AccountService) GetMonthlyStats(accountID int64, month string) (models.MonthlyStats, error) {
+	stats, err := svc.MonthlyStatsRepo.GetByAccountIDMonth(accountID, month)
+	if err != nil {
+		return models.MonthlyStats{}, err
+	}
+	return stats, nil
+}
+
+// RebuildMonthlyStats recomputes the monthly stats of an account, or of every
+// account when accountID is 0, from its transactions.
+func (svc *AccountService) RebuildMonthlyStats(accountID int64) error {
+	return svc.MonthlyStatsRepo.Rebuild(accountID)
+}
+
+// RebuildOpeningBalances recomputes the opening balance of every month of an
+// account, or of every account when accountID is 0, from the earlier months.
+func (svc *AccountService) RebuildOpeningBalances(accountID int64) error {
+	return svc.BalanceRepo.RebuildOpenings(accountID)
+}
+
+// GetMonthBalance returns an account month with its opening balance; its
+// closing balance is models.Balance.ClosingAmount. Backdated postings into
+// earlier months are already reflected in both.
+func (svc *AccountService) GetMonthBalance(accountID int64, month string) (models.Balance, error) {
+	if _, err := utils.ParseMonthTime(month); err != nil {
+		return models.Balance{}, validation.NewFieldError("month", validation.CodeMonthFormat, month)
+	}
+	balance, err := svc.BalanceRepo.GetMonth(accountID, month)
+	if err != nil {
+		return models.Balance{}, err
+	}
+	return balance, nil
+}
+
+// RecordDailyBalances stores the end-of-day balances of every account from
+// from to to, both inclusive, computed from their transactions, and returns
+// how many were stored. Days already recorded are recorded again, so it also
+// back-fills and repairs them. Transactions posted later into recorded days
+// update them as they are posted. An account that fails does not stop the
+// others: their errors are joined, and running again records them.
+func (svc *AccountService) RecordDailyBalances(from time.Time, to time.Time) (int, error) {
+	if err := checkDateRange(from, to); err != nil {
+		return 0, err
+	}
+	accounts, err := svc.AccountRepo.GetAll()
+	if err != nil {
+		return 0, err
+	}
+
+	recorded := 0
+	var errs []error
+	for _, account := range accounts {
+		balances, err := svc.DailyBalanceRepo.Record(account.ID, from, to)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error while recording balances of account %s: %w", account.AccountNumber, err))
+			continue
+		}
+		recorded += len(balances)
+	}
+	return recorded, errors.Join(errs...)
+}
+
+// GetDailyBalances returns the end-of-day balances of an account from from to
+// to, both inclusive, oldest first: the recorded ones, or the ones computed
+// from its transactions when some of those days were not recorded.
+func (svc *AccountService) GetDailyBalances(accountID int64, from time.Time, to time.Time) ([]models.DailyBalance, error) {
+	if err := checkDateRange(from, to); err != nil {
+		return nil, err
+	}
+	balances, err := svc.DailyBalanceRepo.GetByAccountID(accountID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if len(balances) == models.Days(from, to) {
+		return balances, nil
+	}
+	balances, err = svc.DailyBalanceRepo.Compute(accountID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+// GetBalanceOnDate returns the balance of an account at the end of date.
+func (svc *AccountService) GetBalanceOnDate(accountID int64, date time.Time) (int64, error) {
+	balances, err := svc.GetDailyBalances(accountID, date, date)
+	if err != nil {
+		return 0, err
+	}
+	return balances[0].Amount, nil
+}
+
+// GetAverageDailyBalance returns the mean of the end-of-day balances of an
+// account from from to to, both inclusive, in cents.
+func (svc *AccountService) GetAverageDailyBalance(accountID int64, from time.Time, to time.Time) (int64, error) {
+	balances, err := svc.GetDailyBalances(accountID, from, to)
+	if err != nil {
+		return 0, err
+	}
+	return models.AverageDailyBalance(balances), nil
+}
+
+// GetMonthAverageDailyBalance returns the average daily balance of an account
+// month, over its days up to today.
+func (svc *AccountService) GetMonthAverageDailyBalance(accountID int64, month string) (int64, error) {
+	from, err := utils.ParseMonthTime(month)
+	if err != nil {
+		return 0, validation.NewFieldError("month", validation.CodeMonthFormat, month)
+	}
+	to := from.AddDate(0, 1, -1)
+	if today := models.Day(time.Now()); today.Before(to) {
+		to = today
+	}
+	if to.Before(from) {
+		return 0, nil
+	}
+	return svc.GetAverageDailyBalance(accountID, from, to)
+}
+
+// checkDateRange checks that to is not before from, comparing days.
+func checkDateRange(from time.Time, to time.Time) error {
+	if models.Day(to).Before(models.Day(from)) {
+		return validation.NewFieldError("to", validation.CodeNotBefore, "to", from.Format(time.DateOnly), to.Format(time.DateOnly))
+	}
+	return nil
+}
+
+// ClosePeriods closes month, and every earlier one, on every account: nothing
+// can be posted into them anymore, see config.PeriodsConfig. It returns how
+// many accounts had the month still open.
+func (svc *AccountService) ClosePeriods(month string) (int64, error) {
+	if _, err := utils.ParseMonthTime(month); err != nil {
+		return 0, validation.NewFieldError("month", validation.CodeMonthFormat, month)
+	}
+	closed, err := svc.AccountRepo.ClosePeriods(month)
+	if err != nil {
+		return 0, err
+	}
+	return closed, nil
+}
+
+// ClosePeriod closes month, and every earlier one, on an account.
+func (svc *AccountService) ClosePeriod(accountID int64, month string) error {
+	if _, err := utils.ParseMonthTime(month); err != nil {
+		return validation.NewFieldError("month", validation.CodeMonthFormat, month)
+	}
+	return svc.AccountRepo.ClosePeriod(accountID, month)
+}
+
+// ReopenPeriod opens month, and every later one, on an account again, e.g.
+// to post a correction the adjustment entries cannot express.
+func (svc *AccountService) ReopenPeriod(accountID int64, month string) error {
+	if _, err := utils.ParseMonthTime(month); err != nil {
+		return validation.NewFieldError("month", validation.CodeMonthFormat, month)
+	}
+	return svc.AccountRepo.ReopenPeriod(accountID, month)
+}
+
 func (svc *AccountService) GetNumberOfTransactions(accountID int64, month string) (int64, error) {
-	transactionNum, err := svc.TransactionRepo.GetNumberOfTransactions(accountID, month)
+	stats, err := svc.MonthlyStatsRepo.GetByAccountIDMonth(accountID, month)
 	if err != nil {
 		return -1, err
 	}
-	return transactionNum, err
+	return stats.TransactionCount, err
 }
 
 func (svc *AccountService) GetAverageDebitAmount(accountID int64, month string) (float64, error) {
-	avgDebitAmount, err := svc.TransactionRepo.GetAverageDebitAmount(accountID, month)
+	stats, err := svc.MonthlyStatsRepo.GetByAccountIDMonth(accountID, month)
 	if err != nil {
 		return -1, err
 	}
-	return avgDebitAmount, nil
+	return stats.AverageDebit(), nil
 }
 
 func (svc *AccountService) GetAverageCreditAmount(accountID int64, month string) (float64, error) {
-	avgCreditAmount, err := svc.TransactionRepo.GetAverageCreditAmount(accountID, month)
+	stats, err := svc.MonthlyStatsRepo.GetByAccountIDMonth(accountID, month)
 	if err != nil {
 		return -1, err
 	}
-	return avgCreditAmount, nil
+	return stats.AverageCredit(), nil
+}
+
+// FreezeAccount blocks debits on an account until it is unfrozen; credits are
+// still accepted.
+func (svc *AccountService) FreezeAccount(accountNumber string, reason string) (models.Account, error) {
+	return svc.changeStatus(accountNumber, models.ACCOUNT_STATUS_FROZEN, reason)
+}
+
+func (svc *AccountService) UnfreezeAccount(accountNumber string, reason string) (models.Account, error) {
+	return svc.changeStatus(accountNumber, models.ACCOUNT_STATUS_ACTIVE, reason)
+}
+
+// CloseAccount closes an account for good and returns its final statement.
+// Closed accounts reject every transaction.
+func (svc *AccountService) CloseAccount(accountNumber string, reason string) (models.FinalStatement, error) {
+	account, err := svc.changeStatus(accountNumber, models.ACCOUNT_STATUS_CLOSED, reason)
+	if err != nil {
+		return models.FinalStatement{}, err
+	}
+
+	months, err := svc.MonthlyStatsRepo.GetByAccountID(account.ID)
+	if err != nil {
+		return models.FinalStatement{}, err
+	}
+
+	return models.FinalStatement{
+		Account:        account,
+		ClosedAt:       account.StatusChangedAt,
+		Reason:         account.StatusReason,
+		ClosingBalance: account.CurrentBalanceAmount,
+		Months:         months,
+	}, nil
+}
+
+// GetAccountStatusHistory returns the status changes of an account, oldest
+// first.
+func (svc *AccountService) GetAccountStatusHistory(accountID int64) ([]models.AccountStatusChange, error) {
+	history, err := svc.AccountRepo.GetStatusHistory(accountID)
+	if err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+func (svc *AccountService) changeStatus(accountNumber string, status models.AccountStatus, reason string) (models.Account, error) {
+	account, err := svc.GetAccountByAccountNumber(accountNumber, false, false)
+	if err != nil {
+		return models.Account{}, err
+	}
+
+	change, err := models.NewAccountStatusChange(account, status, reason, time.Now())
+	if err != nil {
+		return models.Account{}, err
+	}
+
+	err = svc.AccountRepo.ChangeStatus(change)
+	if err != nil {
+		return models.Account{}, err
+	}
+
+	account.Status = change.To
+	account.StatusReason = change.Reason
+	account.StatusChangedAt = change.ChangedAt
+	return account, nil
+}
+
+// CloseStatementCycle closes the cycle of a credit account that ends on
+// cutoff and stores its statement. Closing a cycle again returns the stored
+// statement, so the job can safely be retried.
+func (svc *AccountService) CloseStatementCycle(account models.Account, cutoff time.Time) (models.Statement, error) {
+	if !account.IsCredit() {
+		return models.Statement{}, fmt.Errorf("account %s is not a credit account", account.AccountNumber)
+	}
+
+	cutoff = models.CutoffDate(account, cutoff.Year(), cutoff.Month())
+	start := models.StatementPeriod(account, cutoff)
+	openingBalance, err := svc.TransactionRepo.BalanceBefore(account.ID, start)
+	if err != nil {
+		return models.Statement{}, err
+	}
+	totals, err := svc.TransactionRepo.CycleTotals(account.ID, start, cutoff.AddDate(0, 0, 1))
+	if err != nil {
+		return models.Statement{}, err
+	}
+
+	statement := models.NewStatement(account, cutoff, openingBalance, totals)
+	statement.ID, err = svc.StatementRepo.Create(statement)
+	if storage.IsUniqueViolation(err, "period_end") {
+		return svc.StatementRepo.GetByAccountIDPeriodEnd(account.ID, cutoff)
+	}
+	if err != nil {
+		return models.Statement{}, err
+	}
+	return statement, nil
+}
+
+// CloseStatementCycles closes the cycle of every open credit account whose
+// cut-off day is the day of date. It is meant to run once a day. An account
+// that fails does not stop the others: their errors are joined, and running
+// again for the same date only closes the cycles missing.
+func (svc *AccountService) CloseStatementCycles(date time.Time) ([]models.Statement, error) {
+	accounts, err := svc.AccountRepo.GetCreditByCutoffDay(date.Day())
+	if err != nil {
+		return nil, err
+	}
+
+	var statements []models.Statement
+	var errs []error
+	for _, account := range accounts {
+		statement, err := svc.CloseStatementCycle(account, date)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error while closing the cycle of account %s: %w", account.AccountNumber, err))
+			continue
+		}
+		statements = append(statements, statement)
+	}
+	return statements, errors.Join(errs...)
+}
+
+// GetLatestStatement returns the last closed cycle of a credit account and
+// false when none was closed yet.
+func (svc *AccountService) GetLatestStatement(accountID int64) (models.Statement, bool, error) {
+	statement, ok, err := svc.StatementRepo.GetLatestByAccountID(accountID)
+	if err != nil {
+		return models.Statement{}, false, err
+	}
+	return statement, ok, nil
+}
+
+// CreateInstallmentPlan stores a plan built with models.NewInstallmentPlan
+// and charges its first installment. The whole purchase takes up credit at
+// once; the rest of the installments are charged by PostDueInstallments.
+func (svc *AccountService) CreateInstallmentPlan(plan models.InstallmentPlan) (models.InstallmentPlan, error) {
+	plan, err := svc.InstallmentRepo.Create(plan)
+	if err != nil {
+		return models.InstallmentPlan{}, err
+	}
+	return plan, nil
+}
+
+// PostDueInstallments charges every installment due up to date. It is meant to
+// run once a day; an installment is never charged twice.
+func (svc *AccountService) PostDueInstallments(date time.Time) ([]models.Installment, error) {
+	installments, err := svc.InstallmentRepo.PostDue(date)
+	if err != nil {
+		return installments, err
+	}
+	return installments, nil
+}
+
+// PayOffInstallmentPlan charges the installments left of a plan now.
+func (svc *AccountService) PayOffInstallmentPlan(planID int64) (models.InstallmentPlan, error) {
+	plan, err := svc.InstallmentRepo.PayOff(planID, time.Now())
+	if err != nil {
+		return models.InstallmentPlan{}, err
+	}
+	return plan, nil
+}
+
+// CancelInstallmentPlan undoes a plan, e.g. when the purchase is returned:
+// the installments left are dropped and the charged ones credited back.
+func (svc *AccountService) CancelInstallmentPlan(planID int64) (models.InstallmentPlan, error) {
+	plan, err := svc.InstallmentRepo.Cancel(planID, time.Now())
+	if err != nil {
+		return models.InstallmentPlan{}, err
+	}
+	return plan, nil
+}
+
+// GetInstallmentPlans returns the active plans of an account.
+func (svc *AccountService) GetInstallmentPlans(accountID int64) ([]models.InstallmentPlan, error) {
+	plans, err := svc.InstallmentRepo.GetActiveByAccountID(accountID)
+	if err != nil {
+		return nil, err
+	}
+	return plans, nil
+}
+
+// CreateTransfer moves money between two accounts with a transfer built with
+// models.NewTransfer. Its debit and credit are posted together or not at all;
+// retrying with the same client reference returns the transfer already made.
+func (svc *AccountService) CreateTransfer(transfer models.Transfer) (models.Transfer, error) {
+	transfer, err := svc.TransferRepo.Create(transfer)
+	if err != nil {
+		return models.Transfer{}, err
+	}
+	return transfer, nil
+}
+
+// ReverseTransfer moves the money of a transfer back now, undoing both its
+// transactions together.
+func (svc *AccountService) ReverseTransfer(transferID int64) (models.Transfer, error) {
+	transfer, err := svc.TransferRepo.Reverse(transferID, time.Now())
+	if err != nil {
+		return models.Transfer{}, err
+	}
+	return transfer, nil
+}
+
+func (svc *AccountService) GetTransfer(transferID int64) (models.Transfer, error) {
+	transfer, err := svc.TransferRepo.GetByID(transferID)
+	if err != nil {
+		return models.Transfer{}, err
+	}
+	return transfer, nil
+}
+
+// GetAccountTransfers returns the transfers from or to an account, newest
+// first.
+func (svc *AccountService) GetAccountTransfers(accountID int64) ([]models.Transfer, error) {
+	transfers, err := svc.TransferRepo.GetByAccountID(accountID)
+	if err != nil {
+		return nil, err
+	}
+	return transfers, nil
+}
+
+// AuthorizeHold stores a pending hold built with models.NewHold. Nothing is
+// posted, but the hold takes up funds until it is captured, released or
+// expires; it is rejected when the account does not have them.
+func (svc *AccountService) AuthorizeHold(hold models.Hold) (models.Hold, error) {
+	hold, err := svc.HoldRepo.Create(hold)
+	if err != nil {
+		return models.Hold{}, err
+	}
+	return hold, nil
+}
+
+// CaptureHold settles amount cents of a pending hold now, or all of it when
+// amount is 0, posting them as a transaction. The rest is released.
+func (svc *AccountService) CaptureHold(holdID int64, amount int64) (models.Hold, error) {
+	hold, err := svc.HoldRepo.Capture(holdID, amount, time.Now())
+	if err != nil {
+		return models.Hold{}, err
+	}
+	return hold, nil
+}
+
+// ReleaseHold drops a pending hold, e.g. when the purchase is cancelled
+// before it settles.
+func (svc *AccountService) ReleaseHold(holdID int64) (models.Hold, error) {
+	hold, err := svc.HoldRepo.Release(holdID, time.Now())
+	if err != nil {
+		return models.Hold{}, err
+	}
+	return hold, nil
+}
+
+// ExpireHolds marks expired the pending holds whose expiry is not after at,
+// giving their funds back. It is meant to run periodically.
+func (svc *AccountService) ExpireHolds(at time.Time) (int64, error) {
+	expired, err := svc.HoldRepo.ExpireDue(at)
+	if err != nil {
+		return 0, err
+	}
+	return expired, nil
+}
+
+func (svc *AccountService) GetHold(holdID int64) (models.Hold, error) {
+	hold, err := svc.HoldRepo.GetByID(holdID)
+	if err != nil {
+		return models.Hold{}, err
+	}
+	return hold, nil
+}
+
+// GetPendingHolds returns the pending holds of an account, oldest first.
+func (svc *AccountService) GetPendingHolds(accountID int64) ([]models.Hold, error) {
+	holds, err := svc.HoldRepo.GetPendingByAccountID(accountID)
+	if err != nil {
+		return nil, err
+	}
+	return holds, nil
+}
+
+// RefundTransaction credits back amount cents of a debit now. The refund is
+// linked to the original and posted into the balance month of today, and
+// refunds and reversals of a transaction cannot exceed it.
+func (svc *AccountService) RefundTransaction(transactionID int64, amount int64) (models.Transaction, error) {
+	refund, err := svc.TransactionRepo.Refund(transactionID, amount, time.Now())
+	if err != nil {
+		return models.Transaction{}, err
+	}
+	return refund, nil
+}
+
+// ReverseTransaction undoes now whatever part of a transaction was not
+// refunded or reversed yet. Transactions of a transfer are reversed with
+// ReverseTransfer instead.
+func (svc *AccountService) ReverseTransaction(transactionID int64) (models.Transaction, error) {
+	reversal, err := svc.TransactionRepo.Reverse(transactionID, time.Now())
+	if err != nil {
+		return models.Transaction{}, err
+	}
+	return reversal, nil
+}
+
+// GetTransactionAdjustments returns the refunds and reversals of the account
+// transactions dated from from to to, exclusive, keyed by the ID of the
+// transaction they undo.
+func (svc *AccountService) GetTransactionAdjustments(accountID int64, from time.Time, to time.Time) (map[int64][]models.Transaction, error) {
+	adjustments, err := svc.TransactionRepo.GetAdjustments(accountID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return adjustments, nil
+}
+
+// GetJournalEntry returns the ledger entry recording a transaction.
+func (svc *AccountService) GetJournalEntry(transactionID int64) (models.JournalEntry, error) {
+	entry, err := svc.LedgerRepo.GetEntryByTransactionID(transactionID)
+	if err != nil {
+		return models.JournalEntry{}, err
+	}
+	return entry, nil
+}
+
+// GetTrialBalance sums the ledger per account, system accounts first.
+func (svc *AccountService) GetTrialBalance() (models.TrialBalance, error) {
+	trial, err := svc.LedgerRepo.TrialBalance()
+	if err != nil {
+		return models.TrialBalance{}, err
+	}
+	return trial, nil
+}
+
+// CheckLedger verifies the invariants of the ledger; see models.LedgerCheck.
+func (svc *AccountService) CheckLedger() (models.LedgerCheck, error) {
+	check, err := svc.LedgerRepo.Check()
+	if err != nil {
+		return models.LedgerCheck{}, err
+	}
+	return check, nil
+}
+
+// BackfillLedger journals the transactions posted before the ledger existed,
+// for one account or for every account when accountID is 0.
+func (svc *AccountService) BackfillLedger(accountID int64) (int, error) {
+	return svc.LedgerRepo.Backfill(accountID)
 }
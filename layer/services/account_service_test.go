@@ -0,0 +1,163 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"storichallenge_layer/models"
+	"storichallenge_layer/validation"
+)
+
+func TestCloseStatementCycles(t *testing.T) {
+	svc := newTestService(t)
+	first := svc.newCreditAccount(t, 20_000_00, 10)
+	second := svc.newCreditAccount(t, 10_000_00, 10)
+	other := svc.newCreditAccount(t, 10_000_00, 15)
+
+	for _, account := range []models.Account{first, second, other} {
+		svc.post(t, account.ID, models.TRANSACTION_TYPE_PURCHASE, -1_000_00, time.Date(2026, 2, 20, 12, 0, 0, 0, time.UTC))
+		svc.post(t, account.ID, models.TRANSACTION_TYPE_INTEREST, -30_00, time.Date(2026, 3, 1, 23, 59, 59, 0, time.UTC))
+		svc.post(t, account.ID, models.TRANSACTION_TYPE_FEE, -20_00, time.Date(2026, 3, 10, 23, 59, 59, 0, time.UTC))
+		svc.post(t, account.ID, models.TRANSACTION_TYPE_PAYMENT, 400_00, time.Date(2026, 3, 5, 12, 0, 0, 0, time.UTC))
+		// After the cut-off, in the next cycle.
+		svc.post(t, account.ID, models.TRANSACTION_TYPE_PURCHASE, -70_00, time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC))
+	}
+
+	// The first account fails; the second is closed all the same.
+	restore := svc.failInserts(t, "statement", first.ID)
+	cutoff := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	statements, err := svc.CloseStatementCycles(cutoff)
+	if err == nil {
+		t.Error("closing with a failing account succeeded")
+	}
+	if len(statements) != 1 || statements[0].AccountID != second.ID {
+		t.Fatalf("closed %+v, want the cycle of account %d only", statements, second.ID)
+	}
+	statement := statements[0]
+	if statement.Purchases != 1_000_00 || statement.Charges != 50_00 || statement.Payments != 400_00 {
+		t.Errorf("purchases %d, charges %d and payments %d, want 1_000_00, 50_00 and 400_00",
+			statement.Purchases, statement.Charges, statement.Payments)
+	}
+	if statement.StatementBalance != 650_00 {
+		t.Errorf("StatementBalance = %d, want 650_00", statement.StatementBalance)
+	}
+
+	// Running again closes what is missing and returns what was closed.
+	restore()
+	statements, err = svc.CloseStatementCycles(cutoff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statements) != 2 {
+		t.Fatalf("closed %d cycles, want 2", len(statements))
+	}
+	for _, closed := range statements {
+		stored, ok, err := svc.GetLatestStatement(closed.AccountID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok || stored != closed {
+			t.Errorf("stored statement %+v, want %+v", stored, closed)
+		}
+	}
+	if _, ok, err := svc.GetLatestStatement(other.ID); err != nil || ok {
+		t.Errorf("account with another cut-off day has a statement: %v", err)
+	}
+}
+
+func TestRecordDailyBalances(t *testing.T) {
+	svc := newTestService(t)
+	first := svc.newCreditAccount(t, 20_000_00, 10)
+	second := svc.newCreditAccount(t, 10_000_00, 10)
+	for _, account := range []models.Account{first, second} {
+		svc.post(t, account.ID, models.TRANSACTION_TYPE_PURCHASE, -300_00, time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC))
+		svc.post(t, account.ID, models.TRANSACTION_TYPE_PAYMENT, 100_00, time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC))
+	}
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)
+
+	// The first account fails; the second is recorded all the same.
+	restore := svc.failInserts(t, "daily_balance", first.ID)
+	recorded, err := svc.RecordDailyBalances(from, to)
+	if recorded != 5 {
+		t.Errorf("recorded %d balances, want 5", recorded)
+	}
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok || len(joined.Unwrap()) != 1 || !strings.Contains(err.Error(), first.AccountNumber) {
+		t.Errorf("error %v, want one for account %s", err, first.AccountNumber)
+	}
+	for _, account := range []models.Account{first, second} {
+		balances, err := svc.DailyBalanceRepo.GetByAccountID(account.ID, from, to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := map[int64]int{first.ID: 0, second.ID: 5}[account.ID]; len(balances) != want {
+			t.Errorf("account %d has %d balances recorded, want %d", account.ID, len(balances), want)
+		}
+	}
+
+	// Averages are the same whether the days were recorded or not.
+	for _, account := range []models.Account{first, second} {
+		average, err := svc.GetAverageDailyBalance(account.ID, from, to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := int64(-200_00); average != want {
+			t.Errorf("average daily balance of account %d = %d, want %d", account.ID, average, want)
+		}
+		average, err = svc.GetMonthAverageDailyBalance(account.ID, "2026/02")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if average != 0 {
+			t.Errorf("average daily balance of account %d in February = %d, want 0", account.ID, average)
+		}
+	}
+
+	// Running again records what is missing.
+	restore()
+	recorded, err = svc.RecordDailyBalances(from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recorded != 10 {
+		t.Errorf("recorded %d balances, want 10", recorded)
+	}
+}
+
+func TestChangeAccountStatus(t *testing.T) {
+	svc := newTestService(t)
+	account := svc.newAccount(t, models.NewAccount(svc.customerID))
+
+	frozen, err := svc.FreezeAccount(account.AccountNumber, "fraud report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if frozen.Status != models.ACCOUNT_STATUS_FROZEN || frozen.StatusReason != "fraud report" {
+		t.Errorf("account is %s (%q), want frozen (fraud report)", frozen.Status, frozen.StatusReason)
+	}
+
+	_, err = svc.FreezeAccount(account.AccountNumber, "again")
+	checkFieldError(t, err, validation.CodeTransition)
+	_, err = svc.UnfreezeAccount(account.AccountNumber, "")
+	checkFieldError(t, err, validation.CodeRequired)
+
+	final, err := svc.CloseAccount(account.AccountNumber, "customer request")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if final.Account.Status != models.ACCOUNT_STATUS_CLOSED || final.Reason != "customer request" {
+		t.Errorf("final statement of a %s account (%q), want closed (customer request)", final.Account.Status, final.Reason)
+	}
+	_, err = svc.UnfreezeAccount(account.AccountNumber, "reopen")
+	checkFieldError(t, err, validation.CodeTransition)
+
+	history, err := svc.GetAccountStatusHistory(account.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].To != models.ACCOUNT_STATUS_FROZEN || history[1].To != models.ACCOUNT_STATUS_CLOSED {
+		t.Errorf("history %v, want the freeze and the closure", history)
+	}
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"storichallenge_layer/config"
+	"storichallenge_layer/models"
+	"storichallenge_layer/storage"
+	"time"
+)
+
+// AccrualEngine charges interest and fees to credit accounts. It is run once
+// per day, and can be run again for any past date: every charge is posted at
+// most once, so reruns and back-fills never duplicate them.
+type AccrualEngine struct {
+	Accounts *AccountService
+	Config   config.AccrualConfig
+}
+
+func NewAccrualEngine(accounts *AccountService, cfg config.AccrualConfig) *AccrualEngine {
+	return &AccrualEngine{Accounts: accounts, Config: cfg}
+}
+
+// Run charges every open credit account for date and returns the charges
+// posted by this run. Run it before closing the statement cycles of the same
+// date, so the monthly fee lands in the closing cycle. An account that fails
+// does not stop the others: their errors are joined, and running again for
+// the same date only posts what is missing.
+func (engine *AccrualEngine) Run(date time.Time) ([]models.Accrual, error) {
+	accounts, err := engine.Accounts.AccountRepo.GetOpenCredit()
+	if err != nil {
+		return nil, err
+	}
+
+	var posted []models.Accrual
+	var errs []error
+	for _, account := range accounts {
+		accruals, err := engine.AccrueAccount(account, date)
+		posted = append(posted, accruals...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error while accruing account %s on %s: %w", account.AccountNumber, date.Format(time.DateOnly), err))
+		}
+	}
+	return posted, errors.Join(errs...)
+}
+
+// RunRange runs the engine for every day from from to to, both inclusive,
+// oldest first, going on past the days that fail.
+func (engine *AccrualEngine) RunRange(from time.Time, to time.Time) ([]models.Accrual, error) {
+	var posted []models.Accrual
+	var errs []error
+	for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+		accruals, err := engine.Run(date)
+		posted = append(posted, accruals...)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return posted, errors.Join(errs...)
+}
+
+// AccrueAccount charges one credit account for date:
+//   - the interest of the day on the balance owed, per the configured APR;
+//   - the monthly fee on its cut-off day;
+//   - the late fee on the day after a due date, when the payments since the
+//     cut-off did not cover the minimum payment.
+//
+// IVA is charged on top of each of them.
+func (engine *AccrualEngine) AccrueAccount(account models.Account, date time.Time) ([]models.Accrual, error) {
+	if !account.IsCredit() {
+		return nil, fmt.Errorf("account %s is not a credit account", account.AccountNumber)
+	}
+	date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+
+	var posted []models.Accrual
+	charge := func(kind models.AccrualKind, amount int64, ivaKind models.AccrualKind) error {
+		accruals, err := engine.post(account, date, kind, amount, ivaKind)
+		posted = append(posted, accruals...)
+		return err
+	}
+
+	// Charges of the day are posted at models.AccrualTime, so the balance
+	// before it holds every transaction of the customer and no charge.
+	balance, err := engine.Accounts.TransactionRepo.BalanceBefore(account.ID, models.AccrualTime(date))
+	if err != nil {
+		return posted, err
+	}
+	err = charge(models.ACCRUAL_KIND_INTEREST, models.DailyInterest(balance, engine.Config.APR), models.ACCRUAL_KIND_INTEREST_IVA)
+	if err != nil {
+		return posted, err
+	}
+
+	if date.Day() == account.CutoffDay {
+		err = charge(models.ACCRUAL_KIND_MONTHLY_FEE, int64(engine.Config.MonthlyFee), models.ACCRUAL_KIND_MONTHLY_FEE_IVA)
+		if err != nil {
+			return posted, err
+		}
+	}
+
+	late, err := engine.isLate(account, date)
+	if err != nil {
+		return posted, err
+	}
+	if late {
+		err = charge(models.ACCRUAL_KIND_LATE_FEE, int64(engine.Config.LateFee), models.ACCRUAL_KIND_LATE_FEE_IVA)
+		if err != nil {
+			return posted, err
+		}
+	}
+
+	return posted, nil
+}
+
+// post charges amount and its IVA together. A charge already posted for the
+// day is skipped.
+func (engine *AccrualEngine) post(account models.Account, date time.Time, kind models.AccrualKind, amount int64, ivaKind models.AccrualKind) ([]models.Accrual, error) {
+	if amount <= 0 {
+		return nil, nil
+	}
+	accruals := []models.Accrual{models.NewAccrual(account.ID, date, kind, amount)}
+	if iva := models.IVA(amount, engine.Config.IVARate); iva > 0 {
+		accruals = append(accruals, models.NewAccrual(account.ID, date, ivaKind, iva))
+	}
+
+	posted, err := engine.Accounts.AccrualRepo.Post(accruals)
+	if storage.IsUniqueViolation(err, "accrual_date") {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return posted, nil
+}
+
+// isLate reports whether date is the day after the due date of a cycle whose
+// minimum payment was not covered by the payments made since its cut-off.
+func (engine *AccrualEngine) isLate(account models.Account, date time.Time) (bool, error) {
+	statement, ok, err := engine.Accounts.StatementRepo.GetByAccountIDDueDate(account.ID, date.AddDate(0, 0, -1))
+	if err != nil || !ok || statement.MinimumPayment == 0 {
+		return false, err
+	}
+	_, payments, err := engine.Accounts.TransactionRepo.Totals(account.ID, statement.PeriodEnd.AddDate(0, 0, 1), date)
+	if err != nil {
+		return false, err
+	}
+	return payments < statement.MinimumPayment, nil
+}
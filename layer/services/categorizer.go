@@ -0,0 +1,130 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"storichallenge_layer/config"
+	"storichallenge_layer/models"
+	"strings"
+)
+
+// Categorizer assigns categories to transactions with the configured rules.
+type Categorizer struct {
+	rules      []categoryRule
+	categories []string
+}
+
+// categoryRule is a config.CategoryRule ready to match transactions.
+type categoryRule struct {
+	config.CategoryRule
+	mccRanges   [][2]string
+	merchant    *regexp.Regexp
+	description *regexp.Regexp
+}
+
+// NewCategorizer compiles rules, which config.Load already validated.
+func NewCategorizer(rules []config.CategoryRule) (*Categorizer, error) {
+	categorizer := &Categorizer{categories: []string{models.CATEGORY_UNCATEGORIZED}}
+	for _, rule := range rules {
+		compiled := categoryRule{CategoryRule: rule}
+		for _, mcc := range rule.MCC {
+			from, to, found := strings.Cut(mcc, "-")
+			if !found {
+				to = from
+			}
+			compiled.mccRanges = append(compiled.mccRanges, [2]string{from, to})
+		}
+		var err error
+		if compiled.merchant, err = compilePattern(rule.Merchant); err != nil {
+			return nil, err
+		}
+		if compiled.description, err = compilePattern(rule.Description); err != nil {
+			return nil, err
+		}
+		categorizer.rules = append(categorizer.rules, compiled)
+		if !slices.Contains(categorizer.categories, rule.Category) {
+			categorizer.categories = append(categorizer.categories, rule.Category)
+		}
+	}
+	sort.SliceStable(categorizer.rules, func(i, j int) bool {
+		return categorizer.rules[i].Priority > categorizer.rules[j].Priority
+	})
+	return categorizer, nil
+}
+
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid category pattern %q: %v", pattern, err)
+	}
+	return re, nil
+}
+
+// Categorize returns the category of the first rule transaction matches, or
+// models.CATEGORY_UNCATEGORIZED.
+func (categorizer *Categorizer) Categorize(transaction models.Transaction) string {
+	for _, rule := range categorizer.rules {
+		if rule.matches(transaction) {
+			return rule.Category
+		}
+	}
+	return models.CATEGORY_UNCATEGORIZED
+}
+
+// IsCategory reports whether category is one that the rules assign.
+func (categorizer *Categorizer) IsCategory(category string) bool {
+	return slices.Contains(categorizer.categories, category)
+}
+
+func (rule categoryRule) matches(transaction models.Transaction) bool {
+	if len(rule.mccRanges) > 0 && !rule.matchesMCC(transaction.MCC) {
+		return false
+	}
+	if rule.merchant != nil && !rule.merchant.MatchString(transaction.Merchant) {
+		return false
+	}
+	if rule.description != nil && !rule.description.MatchString(transaction.Description) {
+		return false
+	}
+	if len(rule.Types) > 0 && !slices.Contains(rule.Types, string(transaction.Type)) {
+		return false
+	}
+	switch rule.Kind {
+	case "debit":
+		if transaction.Amount >= 0 {
+			return false
+		}
+	case "credit":
+		if transaction.Amount <= 0 {
+			return false
+		}
+	}
+	amount := math.Abs(float64(transaction.Amount)) / 100
+	if rule.MinAmount != nil && amount < *rule.MinAmount {
+		return false
+	}
+	if rule.MaxAmount != nil && amount > *rule.MaxAmount {
+		return false
+	}
+	return true
+}
+
+// matchesMCC compares codes as strings, which works as they all have 4
+// digits.
+func (rule categoryRule) matchesMCC(mcc string) bool {
+	if mcc == "" {
+		return false
+	}
+	for _, mccRange := range rule.mccRanges {
+		if mcc >= mccRange[0] && mcc <= mccRange[1] {
+			return true
+		}
+	}
+	return false
+}
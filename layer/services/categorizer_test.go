@@ -0,0 +1,101 @@
+package services
+
+import (
+	"testing"
+
+	"storichallenge_layer/config"
+	"storichallenge_layer/models"
+)
+
+func units(amount float64) *float64 {
+	return &amount
+}
+
+func TestCategorize(t *testing.T) {
+	categorizer, err := NewCategorizer([]config.CategoryRule{
+		{Category: "shopping", Priority: 40, MCC: []string{"5300-5399"}},
+		{Category: "groceries", Priority: 50, MCC: []string{"5411", "5499"}},
+		{Category: "coffee", Priority: 60, MCC: []string{"5811-5814"}, Merchant: `starbucks|cielito`},
+		{Category: "restaurants", Priority: 50, MCC: []string{"5811-5814"}},
+		{Category: "big tickets", Priority: 50, MCC: []string{"5811-5814"}, MinAmount: units(1000)},
+		{Category: "income", Priority: 90, Kind: "credit", Description: `salary|nomina`},
+		{Category: "fees", Priority: 100, Types: []string{"fee", "interest"}},
+		{Category: "small transfers", Priority: 10, Types: []string{"transfer"}, MaxAmount: units(100)},
+		{Category: "refunds", Priority: 5, Kind: "credit"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		transaction models.Transaction
+		want        string
+	}{
+		{"single code", purchase(-50_00, "5411", "La Comer", ""), "groceries"},
+		{"range start", purchase(-50_00, "5300", "Liverpool", ""), "shopping"},
+		{"range end", purchase(-50_00, "5399", "Liverpool", ""), "shopping"},
+		{"outside every range", purchase(-50_00, "5400", "Unknown", ""), models.CATEGORY_UNCATEGORIZED},
+		{"no code", purchase(-50_00, "", "La Comer", ""), models.CATEGORY_UNCATEGORIZED},
+		{"higher priority first", purchase(-50_00, "5814", "STARBUCKS Reforma", ""), "coffee"},
+		{"merchant must match too", purchase(-50_00, "5814", "Sonora Grill", ""), "restaurants"},
+		{"file order on ties", purchase(-1500_00, "5812", "Sonora Grill", ""), "restaurants"},
+		{"description and kind", ofType(25000_00, models.TRANSACTION_TYPE_PAYMENT, "Nomina quincenal"), "income"},
+		{"kind excludes debits", purchase(-100_00, "", "", "Salary advance"), models.CATEGORY_UNCATEGORIZED},
+		{"type", ofType(-35_00, models.TRANSACTION_TYPE_FEE, "Monthly fee"), "fees"},
+		{"type over kind and description", ofType(10_00, models.TRANSACTION_TYPE_INTEREST, "salary"), "fees"},
+		{"max amount inclusive", ofType(-100_00, models.TRANSACTION_TYPE_TRANSFER, ""), "small transfers"},
+		{"above max amount", ofType(-100_01, models.TRANSACTION_TYPE_TRANSFER, ""), models.CATEGORY_UNCATEGORIZED},
+		{"credit fallback", ofType(100_01, models.TRANSACTION_TYPE_TRANSFER, ""), "refunds"},
+	}
+	for _, test := range tests {
+		if got := categorizer.Categorize(test.transaction); got != test.want {
+			t.Errorf("%s: Categorize = %s, want %s", test.name, got, test.want)
+		}
+	}
+
+	for _, category := range []string{"coffee", "refunds", models.CATEGORY_UNCATEGORIZED} {
+		if !categorizer.IsCategory(category) {
+			t.Errorf("IsCategory(%s) = false", category)
+		}
+	}
+	if categorizer.IsCategory("travel") {
+		t.Error("IsCategory(travel) = true without a rule")
+	}
+}
+
+func purchase(amount int64, mcc string, merchant string, description string) models.Transaction {
+	return models.Transaction{Amount: amount, TransactionDetails: models.TransactionDetails{
+		Type: models.TRANSACTION_TYPE_PURCHASE, MCC: mcc, Merchant: merchant, Description: description,
+	}}
+}
+
+func ofType(amount int64, txnType models.TransactionType, description string) models.Transaction {
+	return models.Transaction{Amount: amount, TransactionDetails: models.TransactionDetails{Type: txnType, Description: description}}
+}
+
+func TestNewCategorizer(t *testing.T) {
+	categorizer, err := NewCategorizer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := categorizer.Categorize(purchase(-50_00, "5411", "La Comer", "")); got != models.CATEGORY_UNCATEGORIZED {
+		t.Errorf("Categorize without rules = %s, want %s", got, models.CATEGORY_UNCATEGORIZED)
+	}
+
+	// A rule without conditions catches everything below its priority.
+	categorizer, err = NewCategorizer([]config.CategoryRule{
+		{Category: "groceries", MCC: []string{"5411"}},
+		{Category: "other", Priority: -1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := categorizer.Categorize(purchase(-50_00, "5999", "", "")); got != "other" {
+		t.Errorf("Categorize = %s, want other", got)
+	}
+
+	if _, err := NewCategorizer([]config.CategoryRule{{Category: "broken", Merchant: "("}}); err == nil {
+		t.Error("invalid merchant pattern accepted")
+	}
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"sort"
+
+	"storichallenge_layer/charts"
+	"storichallenge_layer/models"
+	"storichallenge_layer/utils"
+	"storichallenge_layer/validation"
+)
+
+const (
+	CHART_WIDTH  = 480
+	CHART_HEIGHT = 200
+	DONUT_SIZE   = 200
+	// CHART_MAX_CATEGORIES is how many categories the donut shows; the rest
+	// are added up as CHART_OTHER_CATEGORY.
+	CHART_MAX_CATEGORIES = 6
+	CHART_OTHER_CATEGORY = "other"
+)
+
+// AccountCharts are the charts of an account over some months as PNG images,
+// with the figures needed to label them. Amounts are in cents. Emails attach
+// them inline and PDF statements can draw them as they are.
+type AccountCharts struct {
+	// Balance is the balance at the end of each of BalanceMonths, from the
+	// first month of the account to the last month asked for.
+	Balance       []byte
+	BalanceMonths []string
+	Balances      []int64
+	// Flows compares what was spent and received in each of Months, in that
+	// order: Spent is Palette color 0 and Received color 1.
+	Flows    []byte
+	Months   []string
+	Spent    []int64
+	Received []int64
+	// Categories splits the spending of Months between CategoryTotals, each
+	// colored after its position.
+	Categories     []byte
+	CategoryTotals []models.CategoryTotal
+}
+
+// BuildCharts draws the balance over time, the monthly spending against
+// income and the spending per category of an account over months.
+func (svc *AccountService) BuildCharts(accountID int64, months []string) (AccountCharts, error) {
+	months = append([]string(nil), months...)
+	sort.Strings(months)
+	for _, month := range months {
+		if _, err := utils.ParseMonthTime(month); err != nil {
+			return AccountCharts{}, validation.NewFieldError("month", validation.CodeMonthFormat, month)
+		}
+	}
+	result := AccountCharts{Months: months}
+
+	balances, err := svc.BalanceRepo.GetByAccountID(accountID, false)
+	if err != nil {
+		return AccountCharts{}, err
+	}
+	sort.Slice(balances, func(a, b int) bool { return balances[a].Month < balances[b].Month })
+	var balance int64
+	for _, monthBalance := range balances {
+		if len(months) > 0 && monthBalance.Month > months[len(months)-1] {
+			break
+		}
+		balance += monthBalance.Amount
+		result.BalanceMonths = append(result.BalanceMonths, monthBalance.Month)
+		result.Balances = append(result.Balances, balance)
+	}
+
+	spending := make(map[string]int64)
+	var flows [][]float64
+	for _, month := range months {
+		stats, err := svc.MonthlyStatsRepo.GetByAccountIDMonth(accountID, month)
+		if err != nil {
+			return AccountCharts{}, err
+		}
+		result.Spent = append(result.Spent, -stats.DebitSum)
+		result.Received = append(result.Received, stats.CreditSum)
+		flows = append(flows, []float64{float64(-stats.DebitSum), float64(stats.CreditSum)})
+
+		totals, err := svc.GetCategoryTotals(accountID, month)
+		if err != nil {
+			return AccountCharts{}, err
+		}
+		for _, total := range totals {
+			spending[total.Category] += total.Debits
+		}
+	}
+	result.CategoryTotals = topCategories(spending)
+
+	result.Balance, err = charts.PNG(charts.Line(toFloats(result.Balances), CHART_WIDTH, CHART_HEIGHT))
+	if err != nil {
+		return AccountCharts{}, err
+	}
+	result.Flows, err = charts.PNG(charts.Bars(flows, CHART_WIDTH, CHART_HEIGHT))
+	if err != nil {
+		return AccountCharts{}, err
+	}
+	var slices []float64
+	for _, total := range result.CategoryTotals {
+		slices = append(slices, float64(-total.Debits))
+	}
+	result.Categories, err = charts.PNG(charts.Donut(slices, DONUT_SIZE))
+	if err != nil {
+		return AccountCharts{}, err
+	}
+
+	return result, nil
+}
+
+// topCategories returns the categories with spending, largest first, adding
+// up those past CHART_MAX_CATEGORIES.
+func topCategories(spending map[string]int64) []models.CategoryTotal {
+	var totals []models.CategoryTotal
+	for category, debits := range spending {
+		if debits < 0 {
+			totals = append(totals, models.CategoryTotal{Category: category, Debits: debits})
+		}
+	}
+	sort.Slice(totals, func(a, b int) bool {
+		if totals[a].Debits != totals[b].Debits {
+			return totals[a].Debits < totals[b].Debits
+		}
+		return totals[a].Category < totals[b].Category
+	})
+	if len(totals) > CHART_MAX_CATEGORIES {
+		other := models.CategoryTotal{Category: CHART_OTHER_CATEGORY}
+		for _, total := range totals[CHART_MAX_CATEGORIES-1:] {
+			other.Debits += total.Debits
+		}
+		totals = append(totals[:CHART_MAX_CATEGORIES-1], other)
+	}
+	return totals
+}
+
+func toFloats(amounts []int64) []float64 {
+	values := make([]float64, len(amounts))
+	for i, amount := range amounts {
+		values[i] = float64(amount)
+	}
+	return values
+}
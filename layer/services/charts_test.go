@@ -0,0 +1,99 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"slices"
+	"testing"
+	"time"
+
+	"storichallenge_layer/models"
+	"storichallenge_layer/validation"
+)
+
+// checkPNG checks that chart decodes to an image of width by height.
+func checkPNG(tb testing.TB, name string, chart []byte, width, height int) {
+	tb.Helper()
+	img, err := png.Decode(bytes.NewReader(chart))
+	if err != nil {
+		tb.Errorf("%s chart: %v", name, err)
+		return
+	}
+	if size := img.Bounds().Size(); size.X != width || size.Y != height {
+		tb.Errorf("%s chart is %v, want %dx%d", name, size, width, height)
+	}
+}
+
+func checkCharts(tb testing.TB, result AccountCharts) {
+	tb.Helper()
+	checkPNG(tb, "balance", result.Balance, CHART_WIDTH, CHART_HEIGHT)
+	checkPNG(tb, "flows", result.Flows, CHART_WIDTH, CHART_HEIGHT)
+	checkPNG(tb, "categories", result.Categories, DONUT_SIZE, DONUT_SIZE)
+}
+
+func TestBuildChartsEmpty(t *testing.T) {
+	svc := newTestService(t)
+	account := svc.newAccount(t, models.NewAccount(svc.customerID))
+
+	for _, months := range [][]string{nil, {"2026/03"}} {
+		result, err := svc.BuildCharts(account.ID, months)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkCharts(t, result)
+		// Opening the account stored a zero balance for the current month.
+		if slices.ContainsFunc(result.Balances, func(balance int64) bool { return balance != 0 }) || len(result.CategoryTotals) != 0 {
+			t.Errorf("months %v: balances %v and categories %v, want zeros and none", months, result.Balances, result.CategoryTotals)
+		}
+		if len(result.Spent) != len(months) || len(result.Received) != len(months) {
+			t.Errorf("months %v: spent %v and received %v, want one zero per month", months, result.Spent, result.Received)
+		}
+	}
+
+	_, err := svc.BuildCharts(account.ID, []string{"2026-03"})
+	checkFieldError(t, err, validation.CodeMonthFormat)
+}
+
+func TestBuildChartsOneMonth(t *testing.T) {
+	svc := newTestService(t)
+	account := svc.newAccount(t, models.NewAccount(svc.customerID))
+	svc.post(t, account.ID, models.TRANSACTION_TYPE_PAYMENT, 1000_00, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	svc.post(t, account.ID, models.TRANSACTION_TYPE_PURCHASE, -250_00, time.Date(2026, 3, 5, 12, 0, 0, 0, time.UTC))
+	svc.post(t, account.ID, models.TRANSACTION_TYPE_FEE, -20_00, time.Date(2026, 3, 6, 12, 0, 0, 0, time.UTC))
+	// A later month is left out of the balance.
+	svc.post(t, account.ID, models.TRANSACTION_TYPE_PURCHASE, -100_00, time.Date(2026, 4, 2, 12, 0, 0, 0, time.UTC))
+
+	result, err := svc.BuildCharts(account.ID, []string{"2026/03"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCharts(t, result)
+	if !slices.Equal(result.BalanceMonths, []string{"2026/03"}) || !slices.Equal(result.Balances, []int64{730_00}) {
+		t.Errorf("balances %v in %v, want 73000 in 2026/03", result.Balances, result.BalanceMonths)
+	}
+	if !slices.Equal(result.Spent, []int64{270_00}) || !slices.Equal(result.Received, []int64{1000_00}) {
+		t.Errorf("spent %v and received %v, want 27000 and 100000", result.Spent, result.Received)
+	}
+	want := []models.CategoryTotal{{Category: models.CATEGORY_UNCATEGORIZED, Debits: -270_00}}
+	if !slices.Equal(result.CategoryTotals, want) {
+		t.Errorf("categories %+v, want %+v", result.CategoryTotals, want)
+	}
+}
+
+func TestTopCategories(t *testing.T) {
+	spending := map[string]int64{"income": 0, "refunds": 50_00}
+	for i := 1; i <= CHART_MAX_CATEGORIES+1; i++ {
+		spending[fmt.Sprintf("category %d", i)] = -int64(i) * 10_00
+	}
+	totals := topCategories(spending)
+
+	var got []string
+	for _, total := range totals {
+		got = append(got, fmt.Sprintf("%s %d", total.Category, total.Debits))
+	}
+	want := []string{"category 7 -7000", "category 6 -6000", "category 5 -5000", "category 4 -4000", "category 3 -3000", "other -3000"}
+	if !slices.Equal(got, want) {
+		t.Errorf("topCategories = %v, want %v", got, want)
+	}
+}
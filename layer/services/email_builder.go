@@ -4,12 +4,19 @@ import (
 	"bytes"
 	"encoding/base64"
 	"fmt"
+	"html/template"
 	"log"
+	"math"
+	"mime/multipart"
 	"net/smtp"
+	"net/textproto"
 	"os"
 	"path/filepath"
+	"storichallenge_layer/charts"
 	"storichallenge_layer/config"
-	"text/template"
+	"storichallenge_layer/models"
+	"storichallenge_layer/utils"
+	"time"
 )
 
 type EmailBuilder struct {
@@ -21,29 +28,180 @@ type EmailBuilder struct {
 	AssetsPath     string
 }
 
-func NewEmailBuilder(accountService *AccountService) *EmailBuilder {
+func NewEmailBuilder(accountService *AccountService, smtpConfig config.SMTPConfig) *EmailBuilder {
 	return &EmailBuilder{
 		AccountService: accountService,
-		SMTPHost:       config.SMTP_HOST,
-		SMTPPort:       config.SMTP_PORT,
-		SMTPUser:       config.SMTP_USERNAME,
-		SMTPPassword:   config.SMTP_PASSWORD,
+		SMTPHost:       smtpConfig.Host,
+		SMTPPort:       smtpConfig.Port,
+		SMTPUser:       smtpConfig.Username,
+		SMTPPassword:   smtpConfig.Password,
 		AssetsPath:     "../assets",
 	}
 }
 
 type EmailTemplate struct {
 	AccountNumber    string
+	Status           string
 	CurrentBalance   float64
 	TransactionsInfo []TransactionsMonthData
-	LogoBase64       string
+	// Logo is the logo as a data URI.
+	Logo template.URL
+	// Closure is only set for the final statement of a closed account.
+	Closure *ClosureData
+	// Statement is the last closed cycle of a credit account.
+	Statement *StatementData
+	// InstallmentPlans lists the active plans of a credit account.
+	InstallmentPlans []InstallmentPlanData
+	// Pending is only set when the account has pending authorization holds.
+	Pending *PendingData
+	// Charts are only drawn when the summary covers some months.
+	Charts *ChartsData
+}
+
+// ChartsData references the charts of an account, sent as inline images, and
+// labels them. Colors are HTML colors matching the charts.
+type ChartsData struct {
+	BalanceCID    string
+	BalanceFrom   string
+	BalanceTo     string
+	FlowsCID      string
+	SpentColor    string
+	ReceivedColor string
+	Months        []string
+	CategoriesCID string
+	Categories    []ChartLegendData
+	// Images are the charts to attach to the email.
+	Images []InlineImage
+}
+
+type ChartLegendData struct {
+	Color  string
+	Label  string
+	Amount float64
+}
+
+// InlineImage is an image attached to an email and shown in its body through
+// a cid: URL.
+type InlineImage struct {
+	ContentID string
+	Filename  string
+	Data      []byte
+}
+
+type StatementData struct {
+	PeriodStart     string
+	PeriodEnd       string
+	AmountDue       float64
+	MinimumPayment  float64
+	DueDate         string
+	CreditLimit     float64
+	AvailableCredit float64
+	// Transactions are the movements of the cycle.
+	Transactions []TransactionData
+}
+
+// TransactionData is one line of the transactions listed in an email.
+type TransactionData struct {
+	Date     string
+	Type     string
+	Category string
+	Label    string
+	Merchant string
+	Channel  string
+	Location string
+	Amount   float64
+	// ValueDate is only set on adjustment entries, see
+	// models.Transaction.ValueDate.
+	ValueDate string
+	// Adjustments are the refunds and reversals of the transaction, whenever
+	// they were posted.
+	Adjustments []TransactionData
+}
+
+type InstallmentPlanData struct {
+	PurchaseDate    string
+	Amount          float64
+	Months          int
+	Charged         int
+	Outstanding     float64
+	NextDueDate     string
+	NextInstallment float64
+}
+
+// PendingData lists the authorized purchases not settled yet, which are not in
+// the balance but are subtracted from the available balance.
+type PendingData struct {
+	AvailableBalance float64
+	Held             float64
+	Holds            []HoldData
+}
+
+type HoldData struct {
+	AuthorizedAt string
+	ExpiresAt    string
+	Label        string
+	Amount       float64
+}
+
+type ClosureData struct {
+	ClosedAt string
+	Reason   string
+}
+
+// CustomerEmailTemplate holds one EmailTemplate, without logo, per account
+// of the customer. DebitBalance is the money in the debit accounts and
+// CreditOwed what is owed on the credit ones, which are not added up.
+type CustomerEmailTemplate struct {
+	CustomerName string
+	HasDebit     bool
+	DebitBalance float64
+	HasCredit    bool
+	CreditOwed   float64
+	Accounts     []EmailTemplate
+	Logo         template.URL
 }
 
 type TransactionsMonthData struct {
-	Month     string
-	Qty       int64
-	AvgDebit  float64
-	AvgCredit float64
+	Month           string
+	Opening         float64
+	Closing         float64
+	AvgDailyBalance float64
+	Qty             int64
+	AvgDebit        float64
+	AvgCredit       float64
+	Transactions    []TransactionData
+	Categories      []CategoryTotalData
+	Insights        *InsightsData
+}
+
+// InsightsData are the highlights of a month, see models.Insights. Change is
+// the change in spending from PreviousMonth in percent, without sign:
+// Increased tells its direction.
+type InsightsData struct {
+	Spent            float64
+	PreviousMonth    string
+	HasChange        bool
+	Change           float64
+	Increased        bool
+	TopCategories    []CategoryTotalData
+	Largest          *TransactionData
+	TopMerchant      string
+	TopMerchantCount int
+	Spikes           []SpikeData
+}
+
+type SpikeData struct {
+	Category string
+	Spent    float64
+	Average  float64
+}
+
+// CategoryTotalData is what was spent and received in one category.
+type CategoryTotalData struct {
+	Category string
+	Qty      int64
+	Spent    float64
+	Received float64
 }
 
 func (e *EmailBuilder) SendAccountSummaryEmail(accountNumber string, months []string) error {
@@ -53,39 +211,430 @@ func (e *EmailBuilder) SendAccountSummaryEmail(accountNumber string, months []st
 		return err
 	}
 
-	currentBalance := float64(account.CurrentBalanceAmount) / 100
+	emailData, err := e.accountData(account, months)
 
-	var transactionsInfo []TransactionsMonthData
+	if err != nil {
+		return err
+	}
+
+	emailData.Logo, err = e.imageDataURI("stori_logo.png")
+
+	if err != nil {
+		return err
+	}
+
+	body, err := e.buildAccountSummaryEmailBody(emailData)
+
+	if err != nil {
+		return err
+	}
+
+	var images []InlineImage
+	if emailData.Charts != nil {
+		images = emailData.Charts.Images
+	}
+
+	return e.sendEmail(account.Customer.Email, "Stori: Account Summary", body, images)
+
+}
+
+// SendCustomerSummaryEmail sends one email covering every account of the
+// customer with the given CURP, with the money in their debit accounts and
+// what they owe on their credit cards.
+func (e *EmailBuilder) SendCustomerSummaryEmail(curp string, months []string) error {
+	customer, err := e.AccountService.GetCustomerByCURP(curp)
+
+	if err != nil {
+		return err
+	}
+
+	emailData, err := e.customerData(customer, months)
+
+	if err != nil {
+		return err
+	}
+
+	emailData.Logo, err = e.imageDataURI("stori_logo.png")
+
+	if err != nil {
+		return err
+	}
+
+	body, err := e.buildCustomerSummaryEmailBody(emailData)
+
+	if err != nil {
+		return err
+	}
+
+	var images []InlineImage
+	for _, accountData := range emailData.Accounts {
+		if accountData.Charts != nil {
+			images = append(images, accountData.Charts.Images...)
+		}
+	}
+
+	return e.sendEmail(customer.Email, "Stori: Summary of your accounts", body, images)
+}
+
+// customerData gathers the figures of every account of a customer, without
+// logo.
+func (e *EmailBuilder) customerData(customer models.Customer, months []string) (CustomerEmailTemplate, error) {
+	accounts, err := e.AccountService.GetCustomerAccounts(customer.ID)
+
+	if err != nil {
+		return CustomerEmailTemplate{}, err
+	}
+
+	data := CustomerEmailTemplate{CustomerName: customer.Name}
+	for _, account := range accounts {
+		accountData, err := e.accountData(account, months)
+
+		if err != nil {
+			return CustomerEmailTemplate{}, err
+		}
+
+		if account.IsCredit() {
+			data.HasCredit = true
+			data.CreditOwed -= accountData.CurrentBalance
+		} else {
+			data.HasDebit = true
+			data.DebitBalance += accountData.CurrentBalance
+		}
+		data.Accounts = append(data.Accounts, accountData)
+	}
+	return data, nil
+}
+
+// accountData gathers the figures of one account, without logo.
+func (e *EmailBuilder) accountData(account models.Account, months []string) (EmailTemplate, error) {
+	data := EmailTemplate{
+		AccountNumber:  account.AccountNumber,
+		Status:         string(account.Status),
+		CurrentBalance: float64(account.CurrentBalanceAmount) / 100,
+	}
 
 	for _, month := range months {
-		transactionNum, err := e.AccountService.GetNumberOfTransactions(account.ID, month)
+		stats, err := e.AccountService.GetMonthlyStats(account.ID, month)
+
+		if err != nil {
+			return EmailTemplate{}, err
+		}
+
+		from, err := utils.ParseMonthTime(month)
+
+		if err != nil {
+			return EmailTemplate{}, err
+		}
+
+		transactions, err := e.transactionsData(account.ID, from, from.AddDate(0, 1, 0))
+
+		if err != nil {
+			return EmailTemplate{}, err
+		}
+
+		totals, err := e.AccountService.GetCategoryTotals(account.ID, month)
+
+		if err != nil {
+			return EmailTemplate{}, err
+		}
+
+		insights, err := e.AccountService.GetInsights(account.ID, month)
+
+		if err != nil {
+			return EmailTemplate{}, err
+		}
+
+		balance, err := e.AccountService.GetMonthBalance(account.ID, month)
+
+		if err != nil {
+			return EmailTemplate{}, err
+		}
+
+		averageBalance, err := e.AccountService.GetMonthAverageDailyBalance(account.ID, month)
+
+		if err != nil {
+			return EmailTemplate{}, err
+		}
+
+		data.TransactionsInfo = append(data.TransactionsInfo, TransactionsMonthData{
+			Month:           month,
+			Opening:         float64(balance.OpeningAmount) / 100,
+			Closing:         float64(balance.ClosingAmount()) / 100,
+			AvgDailyBalance: float64(averageBalance) / 100,
+			Qty:             stats.TransactionCount,
+			AvgDebit:        stats.AverageDebit(),
+			AvgCredit:       stats.AverageCredit(),
+			Transactions:    transactions,
+			Categories:      categoryTotalsData(totals),
+			Insights:        insightsData(insights),
+		})
+	}
+
+	holds, err := e.AccountService.GetPendingHolds(account.ID)
+
+	if err != nil {
+		return EmailTemplate{}, err
+	}
+
+	data.Pending = pendingData(account, holds)
+
+	if len(months) > 0 {
+		accountCharts, err := e.AccountService.BuildCharts(account.ID, months)
+
+		if err != nil {
+			return EmailTemplate{}, err
+		}
+
+		data.Charts = chartsData(account.AccountNumber, accountCharts)
+	}
+
+	if account.IsCredit() {
+		statement, ok, err := e.AccountService.GetLatestStatement(account.ID)
+
+		if err != nil {
+			return EmailTemplate{}, err
+		}
+
+		if ok {
+			transactions, err := e.transactionsData(account.ID, statement.PeriodStart, statement.PeriodEnd.AddDate(0, 0, 1))
+
+			if err != nil {
+				return EmailTemplate{}, err
+			}
+
+			data.Statement = &StatementData{
+				PeriodStart:     statement.PeriodStart.Format("2006-01-02"),
+				PeriodEnd:       statement.PeriodEnd.Format("2006-01-02"),
+				AmountDue:       float64(statement.StatementBalance) / 100,
+				MinimumPayment:  float64(statement.MinimumPayment) / 100,
+				DueDate:         statement.DueDate.Format("2006-01-02"),
+				CreditLimit:     float64(statement.CreditLimit) / 100,
+				AvailableCredit: float64(account.AvailableCredit()) / 100,
+				Transactions:    transactions,
+			}
+		}
+
+		plans, err := e.AccountService.GetInstallmentPlans(account.ID)
+
+		if err != nil {
+			return EmailTemplate{}, err
+		}
+
+		for _, plan := range plans {
+			planData := InstallmentPlanData{
+				PurchaseDate: plan.PurchaseDate.Format("2006-01-02"),
+				Amount:       float64(plan.Amount) / 100,
+				Months:       plan.Months,
+				Charged:      plan.Charged(),
+				Outstanding:  float64(plan.Outstanding()) / 100,
+			}
+			if next, ok := plan.NextInstallment(); ok {
+				planData.NextDueDate = next.DueDate.Format("2006-01-02")
+				planData.NextInstallment = float64(next.Amount) / 100
+			}
+			data.InstallmentPlans = append(data.InstallmentPlans, planData)
+		}
+	}
+
+	return data, nil
+}
+
+// transactionsData lists the transactions of an account dated from from to
+// to, exclusive, oldest first. Refunds and reversals are listed under the
+// transaction they undo when it is in the period too.
+func (e *EmailBuilder) transactionsData(accountID int64, from time.Time, to time.Time) ([]TransactionData, error) {
+	adjustments, err := e.AccountService.GetTransactionAdjustments(accountID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	query := models.TransactionQuery{AccountID: accountID, From: from, To: to, Order: models.SORT_ASC}
+	var transactions []TransactionData
+	for transaction, err := range e.AccountService.StreamTransactions(query) {
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := adjustments[transaction.OriginalTransactionID]; ok {
+			continue
+		}
+		data := transactionData(transaction)
+		for _, adjustment := range adjustments[transaction.ID] {
+			data.Adjustments = append(data.Adjustments, transactionData(adjustment))
+		}
+		transactions = append(transactions, data)
+	}
+	return transactions, nil
+}
+
+func transactionData(transaction models.Transaction) TransactionData {
+	var valueDate string
+	if !transaction.ValueDate.IsZero() {
+		valueDate = transaction.ValueDate.Format("2006-01-02")
+	}
+	return TransactionData{
+		Date:      transaction.DateTime.Format("2006-01-02"),
+		Type:      string(transaction.Type),
+		Category:  transaction.EffectiveCategory(),
+		Label:     transaction.Label(),
+		Merchant:  transaction.Merchant,
+		Channel:   string(transaction.Channel),
+		Location:  transaction.Location,
+		Amount:    float64(transaction.Amount) / 100,
+		ValueDate: valueDate,
+	}
+}
+
+func pendingData(account models.Account, holds []models.Hold) *PendingData {
+	if len(holds) == 0 {
+		return nil
+	}
+	pending := &PendingData{
+		AvailableBalance: float64(account.AvailableBalance()) / 100,
+		Held:             float64(account.HeldAmount) / 100,
+	}
+	for _, hold := range holds {
+		pending.Holds = append(pending.Holds, HoldData{
+			AuthorizedAt: hold.AuthorizedAt.Format("2006-01-02"),
+			ExpiresAt:    hold.ExpiresAt.Format("2006-01-02"),
+			Label:        models.Transaction{TransactionDetails: hold.TransactionDetails}.Label(),
+			Amount:       float64(-hold.Amount) / 100,
+		})
+	}
+	return pending
+}
+
+func categoryTotalsData(totals []models.CategoryTotal) []CategoryTotalData {
+	var categories []CategoryTotalData
+	for _, total := range totals {
+		categories = append(categories, CategoryTotalData{
+			Category: total.Category,
+			Qty:      total.Count,
+			Spent:    float64(-total.Debits) / 100,
+			Received: float64(total.Credits) / 100,
+		})
+	}
+	return categories
+}
+
+// chartsData names the images after the account, so that the charts of
+// several accounts can share an email.
+func chartsData(accountNumber string, accountCharts AccountCharts) *ChartsData {
+	data := &ChartsData{
+		FlowsCID:      fmt.Sprintf("flows-%s@stori", accountNumber),
+		SpentColor:    charts.Hex(0),
+		ReceivedColor: charts.Hex(1),
+		Months:        accountCharts.Months,
+		Images: []InlineImage{{
+			ContentID: fmt.Sprintf("flows-%s@stori", accountNumber),
+			Filename:  fmt.Sprintf("flows-%s.png", accountNumber),
+			Data:      accountCharts.Flows,
+		}},
+	}
+	if n := len(accountCharts.BalanceMonths); n > 0 {
+		data.BalanceCID = fmt.Sprintf("balance-%s@stori", accountNumber)
+		data.BalanceFrom = accountCharts.BalanceMonths[0]
+		data.BalanceTo = accountCharts.BalanceMonths[n-1]
+		data.Images = append(data.Images, InlineImage{
+			ContentID: data.BalanceCID,
+			Filename:  fmt.Sprintf("balance-%s.png", accountNumber),
+			Data:      accountCharts.Balance,
+		})
+	}
+	if len(accountCharts.CategoryTotals) > 0 {
+		data.CategoriesCID = fmt.Sprintf("categories-%s@stori", accountNumber)
+		for i, total := range accountCharts.CategoryTotals {
+			data.Categories = append(data.Categories, ChartLegendData{
+				Color:  charts.Hex(i),
+				Label:  total.Category,
+				Amount: float64(-total.Debits) / 100,
+			})
+		}
+		data.Images = append(data.Images, InlineImage{
+			ContentID: data.CategoriesCID,
+			Filename:  fmt.Sprintf("categories-%s.png", accountNumber),
+			Data:      accountCharts.Categories,
+		})
+	}
+	return data
+}
+
+// insightsData returns nil for a month without transactions, as it has
+// nothing to highlight.
+func insightsData(insights models.Insights) *InsightsData {
+	if insights.Spent == 0 && !insights.HasLargest && insights.TopMerchant == "" {
+		return nil
+	}
+
+	data := &InsightsData{
+		Spent:            float64(insights.Spent) / 100,
+		PreviousMonth:    insights.PreviousMonth,
+		TopCategories:    categoryTotalsData(insights.TopCategories),
+		TopMerchant:      insights.TopMerchant,
+		TopMerchantCount: insights.TopMerchantCount,
+	}
+	if change, ok := insights.SpentChange(); ok {
+		data.HasChange = true
+		data.Change = math.Abs(change)
+		data.Increased = change > 0
+	}
+	if insights.HasLargest {
+		largest := transactionData(insights.Largest)
+		largest.Amount = -largest.Amount
+		data.Largest = &largest
+	}
+	for _, spike := range insights.Spikes {
+		data.Spikes = append(data.Spikes, SpikeData{
+			Category: spike.Category,
+			Spent:    float64(spike.Spent) / 100,
+			Average:  float64(spike.Average) / 100,
+		})
+	}
+	return data
+}
+
+// SendFinalStatementEmail sends the final statement of a closed account with
+// the figures of every month it was open.
+func (e *EmailBuilder) SendFinalStatementEmail(statement models.FinalStatement) error {
+	var transactionsInfo []TransactionsMonthData
+	for _, stats := range statement.Months {
+		balance, err := e.AccountService.GetMonthBalance(statement.Account.ID, stats.Month)
 
 		if err != nil {
 			return err
 		}
 
-		avgDebit, err := e.AccountService.GetAverageDebitAmount(account.ID, month)
+		averageBalance, err := e.AccountService.GetMonthAverageDailyBalance(statement.Account.ID, stats.Month)
 
 		if err != nil {
-			return nil
+			return err
 		}
 
-		avgCredit, err := e.AccountService.GetAverageCreditAmount(account.ID, month)
 		transactionsInfo = append(transactionsInfo, TransactionsMonthData{
-			Month:     month,
-			Qty:       transactionNum,
-			AvgDebit:  avgDebit,
-			AvgCredit: avgCredit,
+			Month:           stats.Month,
+			Opening:         float64(balance.OpeningAmount) / 100,
+			Closing:         float64(balance.ClosingAmount()) / 100,
+			AvgDailyBalance: float64(averageBalance) / 100,
+			Qty:             stats.TransactionCount,
+			AvgDebit:        stats.AverageDebit(),
+			AvgCredit:       stats.AverageCredit(),
 		})
 	}
 
-	logoBase64, err := e.encodeImageToBase64("stori_logo.png")
+	logo, err := e.imageDataURI("stori_logo.png")
+
+	if err != nil {
+		return err
+	}
 
 	emailData := EmailTemplate{
-		AccountNumber:    accountNumber,
-		CurrentBalance:   currentBalance,
+		AccountNumber:    statement.Account.AccountNumber,
+		CurrentBalance:   float64(statement.ClosingBalance) / 100,
 		TransactionsInfo: transactionsInfo,
-		LogoBase64:       logoBase64,
+		Logo:             logo,
+		Closure: &ClosureData{
+			ClosedAt: statement.ClosedAt.Format("2006-01-02"),
+			Reason:   statement.Reason,
+		},
 	}
 
 	body, err := e.buildAccountSummaryEmailBody(emailData)
@@ -94,19 +643,126 @@ func (e *EmailBuilder) SendAccountSummaryEmail(accountNumber string, months []st
 		return err
 	}
 
-	return e.sendEmail(account.Email, "Stori: Account Summary", body)
-
+	return e.sendEmail(statement.Account.Customer.Email, "Stori: Final Statement", body, nil)
 }
 
-func (e *EmailBuilder) encodeImageToBase64(filename string) (string, error) {
+// imageDataURI embeds a PNG of the assets as a data URI. html/template only
+// lets URLs of its own known safe schemes through, so the URI is typed as
+// trusted.
+func (e *EmailBuilder) imageDataURI(filename string) (template.URL, error) {
 	path := filepath.Join(e.AssetsPath, filename)
 	imageData, err := os.ReadFile(path)
 	if err != nil {
 		return "", err
 	}
-	return base64.StdEncoding.EncodeToString(imageData), nil
+	return template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(imageData)), nil
 }
 
+// statementTemplate shows the amount due of a credit account, if any.
+const statementTemplate = `{{define "statement"}}{{if .}}
+		<p>Statement from {{.PeriodStart}} to {{.PeriodEnd}}</p>
+		<p><b>Amount Due: ${{printf "%.2f" .AmountDue}}</b></p>
+		<p>Minimum Payment: ${{printf "%.2f" .MinimumPayment}}</p>
+		<p>Payment Due Date: {{.DueDate}}</p>
+		<p>Available Credit: ${{printf "%.2f" .AvailableCredit}} of ${{printf "%.2f" .CreditLimit}}</p>
+		{{template "transactions" .Transactions}}
+		{{end}}{{end}}`
+
+// categoriesTemplate is a table of the totals per category, if any.
+const categoriesTemplate = `{{define "categories"}}{{if .}}
+		<table style="border-collapse: collapse;">
+			<tr><th align="left">Category</th><th align="right">Transactions</th><th align="right">Spent</th><th align="right">Received</th></tr>
+			{{range .}}
+			<tr><td>{{.Category}}</td><td align="right">{{.Qty}}</td><td align="right">${{printf "%.2f" .Spent}}</td><td align="right">${{printf "%.2f" .Received}}</td></tr>
+			{{end}}
+		</table>
+		{{end}}{{end}}`
+
+// chartsTemplate shows the charts of an account, if any.
+const chartsTemplate = `{{define "charts"}}{{if .}}
+		{{if .BalanceCID}}
+		<p><b>Balance from {{.BalanceFrom}} to {{.BalanceTo}}</b></p>
+		<img src="cid:{{.BalanceCID}}" alt="Balance at the end of each month" width="480" height="200">
+		{{end}}
+		<p><b>Spent and received in {{range $i, $m := .Months}}{{if $i}}, {{end}}{{$m}}{{end}}</b></p>
+		<img src="cid:{{.FlowsCID}}" alt="Spent and received per month" width="480" height="200">
+		<p><span style="color: {{.SpentColor}};">&#9632;</span> Spent <span style="color: {{.ReceivedColor}};">&#9632;</span> Received</p>
+		{{if .CategoriesCID}}
+		<p><b>Spending per category</b></p>
+		<img src="cid:{{.CategoriesCID}}" alt="Spending per category" width="200" height="200">
+		<table style="border-collapse: collapse;">
+			{{range .Categories}}
+			<tr><td><span style="color: {{.Color}};">&#9632;</span> {{.Label}}</td><td align="right">${{printf "%.2f" .Amount}}</td></tr>
+			{{end}}
+		</table>
+		{{end}}
+		{{end}}{{end}}`
+
+// insightsTemplate highlights the spending of a month, if any.
+const insightsTemplate = `{{define "insights"}}{{if .}}
+		<p><b>Insights</b></p>
+		<ul>
+			<li>You spent ${{printf "%.2f" .Spent}}{{if .HasChange}}, {{printf "%.1f" .Change}}% {{if .Increased}}more{{else}}less{{end}} than in {{.PreviousMonth}}{{end}}.</li>
+			{{if .TopCategories}}<li>Top categories: {{range $i, $c := .TopCategories}}{{if $i}}, {{end}}{{$c.Category}} (${{printf "%.2f" $c.Spent}}){{end}}.</li>{{end}}
+			{{with .Largest}}<li>Largest purchase: ${{printf "%.2f" .Amount}} on {{.Date}}, {{.Label}}{{if and .Merchant (ne .Merchant .Label)}} ({{.Merchant}}){{end}}.</li>{{end}}
+			{{if .TopMerchant}}<li>Most frequent merchant: {{.TopMerchant}}, {{.TopMerchantCount}} {{if eq .TopMerchantCount 1}}transaction{{else}}transactions{{end}}.</li>{{end}}
+			{{range .Spikes}}<li>Unusual spending on {{.Category}}: ${{printf "%.2f" .Spent}} against ${{printf "%.2f" .Average}} in a usual month.</li>{{end}}
+		</ul>
+		{{end}}{{end}}`
+
+// transactionsTemplate is a table of transactions, if any.
+const transactionsTemplate = `{{define "transactions"}}{{if .}}
+		<table style="border-collapse: collapse;">
+			<tr><th align="left">Date</th><th align="left">Description</th><th align="left">Type</th><th align="left">Category</th><th align="left">Channel</th><th align="right">Amount</th></tr>
+			{{range .}}
+			<tr>
+				<td>{{.Date}}</td>
+				<td>{{.Label}}{{if and .Merchant (ne .Merchant .Label)}} ({{.Merchant}}){{end}}{{if .Location}}<br><small>{{.Location}}</small>{{end}}{{if .ValueDate}}<br><small>Adjustment for {{.ValueDate}}</small>{{end}}</td>
+				<td>{{.Type}}</td>
+				<td>{{.Category}}</td>
+				<td>{{.Channel}}</td>
+				<td align="right">${{printf "%.2f" .Amount}}</td>
+			</tr>
+			{{range .Adjustments}}
+			<tr>
+				<td><small>{{.Date}}</small></td>
+				<td><small>&nbsp;&nbsp;&#8627; {{.Label}}</small></td>
+				<td><small>{{.Type}}</small></td>
+				<td><small>{{.Category}}</small></td>
+				<td><small>{{.Channel}}</small></td>
+				<td align="right"><small>${{printf "%.2f" .Amount}}</small></td>
+			</tr>
+			{{end}}
+			{{end}}
+		</table>
+		{{end}}{{end}}`
+
+// pendingTemplate lists the pending authorization holds apart from the
+// posted transactions.
+const pendingTemplate = `{{define "pending"}}{{if .}}
+		<p>Available Balance: ${{printf "%.2f" .AvailableBalance}} (${{printf "%.2f" .Held}} pending)</p>
+		<p><b>Pending</b></p>
+		<table style="border-collapse: collapse;">
+			<tr><th align="left">Authorized</th><th align="left">Description</th><th align="left">Expires</th><th align="right">Amount</th></tr>
+			{{range .Holds}}
+			<tr>
+				<td>{{.AuthorizedAt}}</td>
+				<td>{{.Label}}</td>
+				<td>{{.ExpiresAt}}</td>
+				<td align="right">${{printf "%.2f" .Amount}}</td>
+			</tr>
+			{{end}}
+		</table>
+		{{end}}{{end}}`
+
+// installmentsTemplate lists the installment plans not paid off yet.
+const installmentsTemplate = `{{define "installments"}}{{if .}}
+		<p><b>Installment plans</b></p>
+		{{range .}}
+		<p>Purchase of ${{printf "%.2f" .Amount}} on {{.PurchaseDate}} in {{.Months}} months: {{.Charged}} of {{.Months}} charged, ${{printf "%.2f" .Outstanding}} left{{if .NextDueDate}}, next ${{printf "%.2f" .NextInstallment}} on {{.NextDueDate}}{{end}}</p>
+		{{end}}
+		{{end}}{{end}}`
+
 func (e *EmailBuilder) buildAccountSummaryEmailBody(data EmailTemplate) (string, error) {
 	tmpl := `<html>
 	<head>
@@ -114,17 +770,35 @@ func (e *EmailBuilder) buildAccountSummaryEmailBody(data EmailTemplate) (string,
 	</head>
 	<body>
 		<div style="text-align: center;">
-			<img src="data:image/png;base64,{{.LogoBase64}}" alt="Company Logo" style="width: 150px; height: auto;">
+			<img src="{{.Logo}}" alt="Company Logo" style="width: 150px; height: auto;">
 		</div>
+		{{if .Closure}}
+		<h2>Final Statement for {{.AccountNumber}}</h2>
+		<p>Your account was closed on {{.Closure.ClosedAt}}: {{.Closure.Reason}}</p>
+		<p>Closing Balance: ${{printf "%.2f" .CurrentBalance}}</p>
+		{{else}}
 		<h2>Account Summary for {{.AccountNumber}}</h2>
-		<p>Total Balance: ${{.TotalBalance}}</p>
-		<p>Number of Transactions (last 2 months): {{.NumberOfTransactions}}</p>
-		<p>Average Debit Amount: ${{.AverageDebitAmount}}</p>
-		<p>Average Credit Amount: ${{.AverageCreditAmount}}</p>
+		<p>Total Balance: ${{printf "%.2f" .CurrentBalance}}</p>
+		{{end}}
+		{{template "pending" .Pending}}
+		{{template "statement" .Statement}}
+		{{template "installments" .InstallmentPlans}}
+		{{template "charts" .Charts}}
+		{{range .TransactionsInfo}}
+		<h3>{{.Month}}</h3>
+		<p>Opening Balance: ${{printf "%.2f" .Opening}} &middot; Closing Balance: ${{printf "%.2f" .Closing}}</p>
+		<p>Average Daily Balance: ${{printf "%.2f" .AvgDailyBalance}}</p>
+		<p>Number of Transactions: {{.Qty}}</p>
+		<p>Average Debit Amount: ${{printf "%.2f" .AvgDebit}}</p>
+		<p>Average Credit Amount: ${{printf "%.2f" .AvgCredit}}</p>
+		{{template "insights" .Insights}}
+		{{template "categories" .Categories}}
+		{{template "transactions" .Transactions}}
+		{{end}}
 	</body>
 	</html>`
 
-	t, err := template.New("emailTemplate").Parse(tmpl)
+	t, err := template.New("emailTemplate").Parse(tmpl + pendingTemplate + statementTemplate + installmentsTemplate + chartsTemplate + insightsTemplate + categoriesTemplate + transactionsTemplate)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse email template: %w", err)
 	}
@@ -138,10 +812,64 @@ func (e *EmailBuilder) buildAccountSummaryEmailBody(data EmailTemplate) (string,
 
 }
 
-// sendEmail sends the email using SMTP
-func (e *EmailBuilder) sendEmail(to, subject, body string) error {
+func (e *EmailBuilder) buildCustomerSummaryEmailBody(data CustomerEmailTemplate) (string, error) {
+	tmpl := `<html>
+	<head>
+		<title>Summary of your accounts</title>
+	</head>
+	<body>
+		<div style="text-align: center;">
+			<img src="{{.Logo}}" alt="Company Logo" style="width: 150px; height: auto;">
+		</div>
+		<h2>Hi {{.CustomerName}}, this is the summary of your accounts</h2>
+		{{if .HasDebit}}<p>Total Balance: ${{printf "%.2f" .DebitBalance}}</p>{{end}}
+		{{if .HasCredit}}<p>Owed on Credit Cards: ${{printf "%.2f" .CreditOwed}}</p>{{end}}
+		{{range .Accounts}}
+		<h3>Account {{.AccountNumber}} ({{.Status}})</h3>
+		<p>Balance: ${{printf "%.2f" .CurrentBalance}}</p>
+		{{template "pending" .Pending}}
+		{{template "statement" .Statement}}
+		{{template "installments" .InstallmentPlans}}
+		{{template "charts" .Charts}}
+		{{range .TransactionsInfo}}
+		<h4>{{.Month}}</h4>
+		<p>Opening Balance: ${{printf "%.2f" .Opening}} &middot; Closing Balance: ${{printf "%.2f" .Closing}}</p>
+		<p>Average Daily Balance: ${{printf "%.2f" .AvgDailyBalance}}</p>
+		<p>Number of Transactions: {{.Qty}}</p>
+		<p>Average Debit Amount: ${{printf "%.2f" .AvgDebit}}</p>
+		<p>Average Credit Amount: ${{printf "%.2f" .AvgCredit}}</p>
+		{{template "insights" .Insights}}
+		{{template "categories" .Categories}}
+		{{template "transactions" .Transactions}}
+		{{end}}
+		{{end}}
+	</body>
+	</html>`
+
+	t, err := template.New("customerEmailTemplate").Parse(tmpl + pendingTemplate + statementTemplate + installmentsTemplate + chartsTemplate + insightsTemplate + categoriesTemplate + transactionsTemplate)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse email template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to execute email template: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// sendEmail sends the email using SMTP, with images attached inline if any.
+func (e *EmailBuilder) sendEmail(to, subject, body string, images []InlineImage) error {
 	auth := smtp.PlainAuth("", e.SMTPUser, e.SMTPPassword, e.SMTPHost)
 	msg := []byte(fmt.Sprintf("To: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/html; charset=\"utf-8\"\r\n\r\n%s", to, subject, body))
+	if len(images) > 0 {
+		var err error
+		msg, err = multipartMessage(to, subject, body, images)
+		if err != nil {
+			return err
+		}
+	}
 
 	addr := fmt.Sprintf("%s:%s", e.SMTPHost, e.SMTPPort)
 	if err := smtp.SendMail(addr, auth, e.SMTPUser, []string{to}, msg); err != nil {
@@ -151,3 +879,45 @@ func (e *EmailBuilder) sendEmail(to, subject, body string) error {
 	log.Printf("Email sent to %s successfully", to)
 	return nil
 }
+
+// multipartMessage builds a multipart/related message whose HTML body refers
+// to the images by their Content-ID.
+func multipartMessage(to, subject, body string, images []InlineImage) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "To: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: multipart/related; boundary=\"%s\"\r\n\r\n", to, subject, writer.Boundary())
+
+	part, err := writer.CreatePart(textproto.MIMEHeader{"Content-Type": {`text/html; charset="utf-8"`}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build email: %w", err)
+	}
+	if _, err := part.Write([]byte(body)); err != nil {
+		return nil, fmt.Errorf("failed to build email: %w", err)
+	}
+
+	for _, image := range images {
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {"image/png"},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Id":                {"<" + image.ContentID + ">"},
+			"Content-Disposition":       {fmt.Sprintf("inline; filename=\"%s\"", image.Filename)},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to build email: %w", err)
+		}
+		// Lines of base64 are kept within the 76 characters MIME allows.
+		encoded := base64.StdEncoding.EncodeToString(image.Data)
+		for len(encoded) > 0 {
+			n := min(len(encoded), 76)
+			if _, err := part.Write([]byte(encoded[:n] + "\r\n")); err != nil {
+				return nil, fmt.Errorf("failed to build email: %w", err)
+			}
+			encoded = encoded[n:]
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to build email: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"storichallenge_layer/models"
+)
+
+func TestCustomerDataSplitsDebitAndCredit(t *testing.T) {
+	svc := newTestService(t)
+	builder := &EmailBuilder{AccountService: svc.AccountService}
+	at := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	for _, balance := range []int64{500_00, 250_00} {
+		account := svc.newAccount(t, models.NewAccount(svc.customerID))
+		svc.post(t, account.ID, models.TRANSACTION_TYPE_PAYMENT, balance, at)
+	}
+	credit := svc.newCreditAccount(t, 10_000_00, 10)
+	svc.post(t, credit.ID, models.TRANSACTION_TYPE_PURCHASE, -300_00, at)
+
+	customer, err := svc.GetCustomerByCURP("HEGG560427MVZRRL04")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := builder.customerData(customer, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Accounts) != 3 {
+		t.Fatalf("got %d accounts, want 3", len(data.Accounts))
+	}
+	if !data.HasDebit || data.DebitBalance != 750 {
+		t.Errorf("debit balance %v, want 750", data.DebitBalance)
+	}
+	if !data.HasCredit || data.CreditOwed != 300 {
+		t.Errorf("credit owed %v, want 300", data.CreditOwed)
+	}
+
+	body, err := builder.buildCustomerSummaryEmailBody(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Total Balance: $750.00", "Owed on Credit Cards: $300.00"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("email does not show %q", want)
+		}
+	}
+}
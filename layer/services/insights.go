@@ -0,0 +1,76 @@
+package services
+
+import (
+	"storichallenge_layer/models"
+	"storichallenge_layer/utils"
+	"storichallenge_layer/validation"
+)
+
+// GetInsights computes the highlights of an account month: the change in
+// spending from the month before, the top spending categories, the largest
+// debit, the most frequent merchant and the categories whose spending spiked
+// compared with the INSIGHTS_HISTORY_MONTHS months before.
+func (svc *AccountService) GetInsights(accountID int64, month string) (models.Insights, error) {
+	from, err := utils.ParseMonthTime(month)
+	if err != nil {
+		return models.Insights{}, validation.NewFieldError("month", validation.CodeMonthFormat, month)
+	}
+	insights := models.Insights{Month: month, PreviousMonth: utils.GetMonth(from.AddDate(0, -1, 0))}
+
+	stats, err := svc.MonthlyStatsRepo.GetByAccountIDMonth(accountID, month)
+	if err != nil {
+		return models.Insights{}, err
+	}
+	insights.Spent = -stats.DebitSum
+
+	previous, err := svc.MonthlyStatsRepo.GetByAccountIDMonth(accountID, insights.PreviousMonth)
+	if err != nil {
+		return models.Insights{}, err
+	}
+	insights.PreviousSpent = -previous.DebitSum
+	insights.HasPrevious = previous.TransactionCount > 0
+
+	totals, err := svc.TransactionRepo.CategoryTotals(accountID, from, from.AddDate(0, 1, 0))
+	if err != nil {
+		return models.Insights{}, err
+	}
+	insights.TopCategories = models.TopSpending(totals, models.INSIGHTS_TOP_CATEGORIES)
+
+	// Months without transactions are left out of the history, so that a
+	// new account does not report all its spending as spikes.
+	var history [][]models.CategoryTotal
+	for i := 1; i <= models.INSIGHTS_HISTORY_MONTHS; i++ {
+		monthStart := from.AddDate(0, -i, 0)
+		monthTotals, err := svc.TransactionRepo.CategoryTotals(accountID, monthStart, monthStart.AddDate(0, 1, 0))
+		if err != nil {
+			return models.Insights{}, err
+		}
+		if len(monthTotals) > 0 {
+			history = append(history, monthTotals)
+		}
+	}
+	insights.Spikes = models.DetectSpikes(totals, history)
+
+	merchants := make(map[string]int)
+	query := models.TransactionQuery{AccountID: accountID, From: from, To: from.AddDate(0, 1, 0), Order: models.SORT_ASC}
+	for transaction, err := range svc.StreamTransactions(query) {
+		if err != nil {
+			return models.Insights{}, err
+		}
+		if transaction.Amount < 0 && (!insights.HasLargest || transaction.Amount < insights.Largest.Amount) {
+			insights.Largest = transaction
+			insights.HasLargest = true
+		}
+		if transaction.Merchant != "" {
+			merchants[transaction.Merchant]++
+		}
+	}
+	for merchant, count := range merchants {
+		if count > insights.TopMerchantCount || (count == insights.TopMerchantCount && merchant < insights.TopMerchant) {
+			insights.TopMerchant = merchant
+			insights.TopMerchantCount = count
+		}
+	}
+
+	return insights, nil
+}
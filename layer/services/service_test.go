@@ -0,0 +1,122 @@
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"storichallenge_layer/config"
+	"storichallenge_layer/models"
+	"storichallenge_layer/storage"
+	"storichallenge_layer/validation"
+)
+
+// testService is an AccountService on a fresh SQLite database of its own,
+// with one customer to open accounts for.
+type testService struct {
+	*AccountService
+	DB         *storage.DB
+	customerID int64
+}
+
+func newTestService(tb testing.TB) *testService {
+	tb.Helper()
+	db, err := config.ConnectToDB(config.DBConfig{
+		Driver:       storage.DRIVER_SQLITE,
+		Path:         filepath.Join(tb.TempDir(), "stori.db"),
+		MaxOpenConns: 1,
+		MaxIdleConns: 1,
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { db.DB.Close() })
+
+	svc, err := newAccountService(db, config.Config{
+		Accounts: config.AccountsConfig{BankCode: models.DEFAULT_BANK_CODE, BranchCode: models.DEFAULT_BRANCH_CODE},
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	customer, err := models.NewCustomer("Gloria", "Hernandez", "Garcia", time.Date(1956, 4, 27, 0, 0, 0, 0, time.UTC),
+		"HEGG560427MVZRRL04", "", "", "gloria@example.com")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	customerID, err := svc.CreateCustomer(customer)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return &testService{AccountService: svc, DB: db, customerID: customerID}
+}
+
+// newAccount opens account for the test customer and returns it as stored.
+func (svc *testService) newAccount(tb testing.TB, account models.Account) models.Account {
+	tb.Helper()
+	account.CustomerID = svc.customerID
+	accountID, err := svc.CreateAccount(account)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	account, err = svc.AccountRepo.GetByID(accountID, false, false)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return account
+}
+
+// newCreditAccount opens a credit card with a limit of limit cents.
+func (svc *testService) newCreditAccount(tb testing.TB, limit int64, cutoffDay int) models.Account {
+	tb.Helper()
+	account, err := models.NewCreditAccount(svc.customerID, limit, cutoffDay, 20)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return svc.newAccount(tb, account)
+}
+
+// post posts a transaction of amount cents of type txnType at at.
+func (svc *testService) post(tb testing.TB, accountID int64, txnType models.TransactionType, amount int64, at time.Time) {
+	tb.Helper()
+	transaction, err := models.NewTransactionWithDetails(amount, at, accountID, models.TransactionDetails{Type: txnType})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if err := svc.CreateTransaction(transaction); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+// failInserts makes every insert into table for accountID fail, until the
+// returned function is called.
+func (svc *testService) failInserts(tb testing.TB, table string, accountID int64) func() {
+	tb.Helper()
+	// Triggers take no query parameters.
+	query := fmt.Sprintf("CREATE TRIGGER fail_%s BEFORE INSERT ON `%s` WHEN NEW.account_id = %d BEGIN SELECT RAISE(ABORT, 'injected failure'); END",
+		table, table, accountID)
+	if _, err := svc.DB.DB.Exec(query); err != nil {
+		tb.Fatal(err)
+	}
+	return func() {
+		if _, err := svc.DB.DB.Exec("DROP TRIGGER fail_" + table); err != nil {
+			tb.Fatal(err)
+		}
+	}
+}
+
+// checkFieldError checks that err is a validation error with code.
+func checkFieldError(tb testing.TB, err error, code string) {
+	tb.Helper()
+	validationErr, ok := validation.AsValidationError(err)
+	if !ok {
+		tb.Errorf("error = %v, want a validation error %s", err, code)
+		return
+	}
+	for _, fieldError := range validationErr.Errors {
+		if fieldError.Code == code {
+			return
+		}
+	}
+	tb.Errorf("error = %v, want a validation error %s", err, code)
+}
@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Querier is implemented by both DB and Tx, so repository helpers can run the
+// same statements inside or outside a database transaction.
+type Querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+	InsertID(query string, args ...any) (int64, error)
+}
+
+// RetryPolicy runs again what failed with an error Transient accepts, up to
+// Retries more times, waiting Backoff before the first retry and twice as
+// long before each next one. The zero value never retries.
+type RetryPolicy struct {
+	Retries   int
+	Backoff   time.Duration
+	Transient func(err error) bool
+}
+
+// Do runs fn until it succeeds, fails with an error that is not transient or
+// runs out of retries, and returns its last error.
+func (policy RetryPolicy) Do(fn func() error) error {
+	backoff := policy.Backoff
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= policy.Retries || policy.Transient == nil || !policy.Transient(err) {
+			return err
+		}
+		log.Printf("Database attempt %d failed, retrying in %s: %v", attempt+1, backoff, err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// DB is a connection pool bound to the dialect of the database behind it.
+// Queries go through Rebind and their arguments through Bind, so repositories
+// can share one SQL text and pass times in any zone.
+//
+// Statements and transactions that fail with a transient error, e.g. a
+// dropped connection or a deadlock, are run again as Retry says. Statements
+// outside transactions are only idempotent updates or inserts of uniquely
+// keyed rows, so running them again cannot apply them twice. QueryRow is not
+// retried, as its error only shows when the row is scanned.
+type DB struct {
+	*sql.DB
+	Dialect Dialect
+	Retry   RetryPolicy
+}
+
+func NewDB(db *sql.DB, dialect Dialect) *DB {
+	return &DB{DB: db, Dialect: dialect}
+}
+
+func (db *DB) Exec(query string, args ...any) (sql.Result, error) {
+	var result sql.Result
+	err := db.Retry.Do(func() error {
+		var err error
+		result, err = db.DB.Exec(db.Dialect.Rebind(query), db.Dialect.Bind(args)...)
+		return err
+	})
+	return result, err
+}
+
+func (db *DB) Query(query string, args ...any) (*sql.Rows, error) {
+	var rows *sql.Rows
+	err := db.Retry.Do(func() error {
+		var err error
+		rows, err = db.DB.Query(db.Dialect.Rebind(query), db.Dialect.Bind(args)...)
+		return err
+	})
+	return rows, err
+}
+
+func (db *DB) QueryRow(query string, args ...any) *sql.Row {
+	return db.DB.QueryRow(db.Dialect.Rebind(query), db.Dialect.Bind(args)...)
+}
+
+// InsertID runs an INSERT and returns the generated id column.
+func (db *DB) InsertID(query string, args ...any) (int64, error) {
+	// The statements of the insert are not retried on their own.
+	once := &DB{DB: db.DB, Dialect: db.Dialect}
+	var id int64
+	err := db.Retry.Do(func() error {
+		var err error
+		id, err = insertID(once, db.Dialect, query, args...)
+		return err
+	})
+	return id, err
+}
+
+// WithTx runs fn inside a database transaction, committing when it returns
+// nil and rolling back otherwise. When a statement of fn failed with a
+// transient error the whole transaction is run again, so fn must not keep
+// anything from an attempt that did not commit. A failed commit is not
+// retried, since it may have been applied.
+func (db *DB) WithTx(fn func(tx *Tx) error) error {
+	var result error
+	db.Retry.Do(func() error {
+		var cause error
+		result, cause = db.withTx(fn)
+		return cause
+	})
+	return result
+}
+
+// withTx runs one attempt of WithTx and returns its error and, when the
+// attempt may be retried, the statement error that made it fail.
+func (db *DB) withTx(fn func(tx *Tx) error) (error, error) {
+	sqlTx, err := db.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("error while starting database transaction: %w", err), err
+	}
+
+	tx := &Tx{Tx: sqlTx, Dialect: db.Dialect}
+	if err := fn(tx); err != nil {
+		sqlTx.Rollback()
+		return err, tx.err
+	}
+
+	if err := sqlTx.Commit(); err != nil {
+		return fmt.Errorf("error while committing database transaction: %v", err), nil
+	}
+	return nil, nil
+}
+
+// Tx is a database transaction that rewrites queries like DB does. It keeps
+// the last error of its statements, which callers often wrap as text, to
+// tell whether the transaction is worth retrying.
+type Tx struct {
+	*sql.Tx
+	Dialect Dialect
+	err     error
+}
+
+func (tx *Tx) Exec(query string, args ...any) (sql.Result, error) {
+	result, err := tx.Tx.Exec(tx.Dialect.Rebind(query), tx.Dialect.Bind(args)...)
+	return result, tx.keep(err)
+}
+
+func (tx *Tx) Query(query string, args ...any) (*sql.Rows, error) {
+	rows, err := tx.Tx.Query(tx.Dialect.Rebind(query), tx.Dialect.Bind(args)...)
+	return rows, tx.keep(err)
+}
+
+func (tx *Tx) QueryRow(query string, args ...any) *sql.Row {
+	return tx.Tx.QueryRow(tx.Dialect.Rebind(query), tx.Dialect.Bind(args)...)
+}
+
+func (tx *Tx) InsertID(query string, args ...any) (int64, error) {
+	id, err := insertID(tx, tx.Dialect, query, args...)
+	return id, tx.keep(err)
+}
+
+func (tx *Tx) keep(err error) error {
+	if err != nil {
+		tx.err = err
+	}
+	return err
+}
+
+func insertID(q Querier, dialect Dialect, query string, args ...any) (int64, error) {
+	if !dialect.SupportsLastInsertID() {
+		var id int64
+		err := q.QueryRow(query+" RETURNING id", args...).Scan(&id)
+		return id, err
+	}
+
+	result, err := q.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var errFlaky = errors.New("flaky")
+
+func TestRetryPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   RetryPolicy
+		failures int
+		err      error
+		want     int
+	}{
+		{"zero policy", RetryPolicy{}, 5, errFlaky, 1},
+		{"recovers", RetryPolicy{Retries: 3, Transient: func(err error) bool { return err == errFlaky }}, 2, errFlaky, 3},
+		{"runs out", RetryPolicy{Retries: 3, Transient: func(err error) bool { return err == errFlaky }}, 5, errFlaky, 4},
+		{"not transient", RetryPolicy{Retries: 3, Transient: func(err error) bool { return err == errFlaky }}, 5, errors.New("broken"), 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			attempts := 0
+			err := test.policy.Do(func() error {
+				attempts++
+				if attempts <= test.failures {
+					return test.err
+				}
+				return nil
+			})
+			if attempts != test.want {
+				t.Errorf("ran %d times, want %d", attempts, test.want)
+			}
+			if (err != nil) != (test.failures >= test.want) {
+				t.Errorf("error = %v after %d attempts", err, attempts)
+			}
+		})
+	}
+
+	start := time.Now()
+	policy := RetryPolicy{Retries: 2, Backoff: 10 * time.Millisecond, Transient: func(error) bool { return true }}
+	policy.Do(func() error { return errFlaky })
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("two retries took %s, want at least 10ms and then 20ms", elapsed)
+	}
+}
+
+// newRetryDB opens a SQLite database with a table t whose statements retry
+// errors about a missing table, the failure the tests inject.
+func newRetryDB(tb testing.TB) *DB {
+	tb.Helper()
+	sqlDB, err := sql.Open("sqlite", filepath.Join(tb.TempDir(), "retry.db"))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { sqlDB.Close() })
+	sqlDB.SetMaxOpenConns(1)
+	db := NewDB(sqlDB, SQLiteDialect{})
+	if _, err := db.Exec("CREATE TABLE t (n INTEGER)"); err != nil {
+		tb.Fatal(err)
+	}
+	db.Retry = RetryPolicy{Retries: 2, Transient: func(err error) bool {
+		return strings.Contains(err.Error(), "no such table")
+	}}
+	return db
+}
+
+func count(tb testing.TB, db *DB) int {
+	tb.Helper()
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM t").Scan(&n); err != nil {
+		tb.Fatal(err)
+	}
+	return n
+}
+
+func TestWithTxRetries(t *testing.T) {
+	db := newRetryDB(t)
+	attempts := 0
+	err := db.WithTx(func(tx *Tx) error {
+		attempts++
+		if _, err := tx.Exec("INSERT INTO t (n) VALUES (?)", attempts); err != nil {
+			return err
+		}
+		if attempts == 1 {
+			// Wrapped as text, as repositories do.
+			if _, err := tx.Exec("INSERT INTO missing (n) VALUES (1)"); err != nil {
+				return fmt.Errorf("error while inserting: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 2 {
+		t.Errorf("ran %d attempts, want 2", attempts)
+	}
+	if n := count(t, db); n != 1 {
+		t.Errorf("table has %d rows, want the one of the second attempt", n)
+	}
+
+	// Errors that no statement returned are not retried.
+	attempts = 0
+	err = db.WithTx(func(tx *Tx) error {
+		attempts++
+		if _, err := tx.Exec("INSERT INTO t (n) VALUES (?)", attempts); err != nil {
+			return err
+		}
+		return errFlaky
+	})
+	if err != errFlaky || attempts != 1 {
+		t.Errorf("error %v after %d attempts, want flaky after 1", err, attempts)
+	}
+	if n := count(t, db); n != 1 {
+		t.Errorf("table has %d rows, want 1", n)
+	}
+}
+
+func TestExecRetries(t *testing.T) {
+	db := newRetryDB(t)
+	attempts := 0
+	db.Retry.Transient = func(err error) bool {
+		attempts++
+		return true
+	}
+	if _, err := db.Exec("INSERT INTO missing (n) VALUES (1)"); err == nil {
+		t.Fatal("insert into a missing table succeeded")
+	}
+	// The error of the last retry is returned without asking.
+	if attempts != 2 {
+		t.Errorf("checked %d errors, want 2: one per retry", attempts)
+	}
+}
@@ -0,0 +1,173 @@
+package storage
+
+import (
+	"database/sql"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DRIVER_MYSQL    = "mysql"
+	DRIVER_POSTGRES = "postgres"
+	DRIVER_SQLITE   = "sqlite"
+)
+
+// SQLITE_TIME_FORMAT is how times are bound on SQLite, which stores them as
+// text and compares them as text: always in UTC and with a fixed width, so
+// that the text sorts like the times. The driver reads it back as UTC.
+const SQLITE_TIME_FORMAT = "2006-01-02 15:04:05.000000000"
+
+// Dialect hides the differences between the SQL flavours the repositories
+// run on. Repositories write queries MySQL style, with `?` placeholders and
+// backtick-quoted identifiers, and the dialect rewrites them when needed.
+type Dialect interface {
+	Name() string
+	// DriverName is the database/sql driver registered for the dialect.
+	DriverName() string
+	Rebind(query string) string
+	// Bind converts the query arguments the database would not store or
+	// compare right, i.e. times, and returns the others as they are.
+	Bind(args []any) []any
+	// SupportsLastInsertID reports whether sql.Result.LastInsertId works; when
+	// it does not, inserts append a RETURNING clause instead.
+	SupportsLastInsertID() bool
+	// Upsert is the clause that turns an INSERT of a row whose keys already
+	// exist into an UPDATE of that row with the assignments of set, in one
+	// statement. set refers to the inserted values with Inserted.
+	Upsert(keys []string, set string) string
+	// Inserted refers to the value an upserting INSERT gave column.
+	Inserted(column string) string
+}
+
+func NewDialect(driver string) (Dialect, bool) {
+	switch driver {
+	case DRIVER_MYSQL, "mariadb":
+		return MySQLDialect{}, true
+	case DRIVER_POSTGRES, "postgresql":
+		return PostgresDialect{}, true
+	case DRIVER_SQLITE, "sqlite3":
+		return SQLiteDialect{}, true
+	}
+	return nil, false
+}
+
+// MySQLDialect also covers MariaDB.
+type MySQLDialect struct{}
+
+func (MySQLDialect) Name() string               { return DRIVER_MYSQL }
+func (MySQLDialect) DriverName() string         { return "mysql" }
+func (MySQLDialect) Rebind(query string) string { return query }
+func (MySQLDialect) SupportsLastInsertID() bool { return true }
+
+// Bind leaves times to the driver, which converts them to the zone of the
+// connection, UTC unless configured otherwise.
+func (MySQLDialect) Bind(args []any) []any { return args }
+
+// Upsert ignores keys: MySQL updates the row of whichever unique key clashes.
+func (MySQLDialect) Upsert(keys []string, set string) string {
+	return "ON DUPLICATE KEY UPDATE " + set
+}
+
+// Inserted uses VALUES(), which MariaDB still supports unlike the row alias
+// that replaces it on MySQL 8.
+func (MySQLDialect) Inserted(column string) string { return "VALUES(" + column + ")" }
+
+type PostgresDialect struct{}
+
+func (PostgresDialect) Name() string               { return DRIVER_POSTGRES }
+func (PostgresDialect) DriverName() string         { return "postgres" }
+func (PostgresDialect) SupportsLastInsertID() bool { return false }
+
+func (PostgresDialect) Rebind(query string) string {
+	return rewrite(query, true)
+}
+
+// Bind sends times in UTC: TIMESTAMP columns drop the offset of the time they
+// are given.
+func (PostgresDialect) Bind(args []any) []any {
+	return bindTimes(args, func(t time.Time) any { return t.UTC() })
+}
+
+func (PostgresDialect) Upsert(keys []string, set string) string { return onConflict(keys, set) }
+func (PostgresDialect) Inserted(column string) string           { return "excluded." + column }
+
+type SQLiteDialect struct{}
+
+func (SQLiteDialect) Name() string               { return DRIVER_SQLITE }
+func (SQLiteDialect) DriverName() string         { return "sqlite" }
+func (SQLiteDialect) SupportsLastInsertID() bool { return true }
+
+func (SQLiteDialect) Rebind(query string) string {
+	return rewrite(query, false)
+}
+
+func (SQLiteDialect) Bind(args []any) []any {
+	return bindTimes(args, func(t time.Time) any { return t.UTC().Format(SQLITE_TIME_FORMAT) })
+}
+
+func (SQLiteDialect) Upsert(keys []string, set string) string { return onConflict(keys, set) }
+func (SQLiteDialect) Inserted(column string) string           { return "excluded." + column }
+
+// onConflict is the upsert clause of PostgreSQL, also understood by SQLite.
+func onConflict(keys []string, set string) string {
+	return "ON CONFLICT (" + strings.Join(keys, ", ") + ") DO UPDATE SET " + set
+}
+
+// bindTimes replaces the time and valid sql.NullTime arguments with what bind
+// makes of them. args is copied only when it has any.
+func bindTimes(args []any, bind func(t time.Time) any) []any {
+	bound := args
+	copied := false
+	for i, arg := range args {
+		var t time.Time
+		switch arg := arg.(type) {
+		case time.Time:
+			t = arg
+		case sql.NullTime:
+			if !arg.Valid {
+				continue
+			}
+			t = arg.Time
+		default:
+			continue
+		}
+		if !copied {
+			bound, copied = slices.Clone(args), true
+		}
+		bound[i] = bind(t)
+	}
+	return bound
+}
+
+// rewrite turns backtick-quoted identifiers into ANSI double quotes and, when
+// numbered is set, `?` placeholders into $1, $2, ... String literals are left
+// untouched.
+func rewrite(query string, numbered bool) string {
+	var sb strings.Builder
+	sb.Grow(len(query) + 8)
+
+	inString := false
+	arg := 0
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'':
+			inString = !inString
+			sb.WriteByte(c)
+		case inString:
+			sb.WriteByte(c)
+		case c == '`':
+			sb.WriteByte('"')
+		case c == '?' && numbered:
+			arg++
+			sb.WriteByte('$')
+			sb.WriteString(strconv.Itoa(arg))
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"database/sql"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestSQLiteBind(t *testing.T) {
+	cst := time.FixedZone("CST", -6*60*60)
+	args := []any{
+		int64(1),
+		time.Date(2026, 3, 10, 20, 0, 0, 0, cst),
+		sql.NullTime{Time: time.Date(2026, 3, 11, 1, 0, 0, 500_000_000, time.UTC), Valid: true},
+		sql.NullTime{},
+		"2026/03",
+	}
+	original := slices.Clone(args)
+
+	got := SQLiteDialect{}.Bind(args)
+	want := []any{int64(1), "2026-03-11 02:00:00.000000000", "2026-03-11 01:00:00.500000000", sql.NullTime{}, "2026/03"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Bind = %q, want %q", got, want)
+	}
+	if !slices.Equal(args, original) {
+		t.Errorf("Bind changed its arguments to %q", args)
+	}
+
+	plain := []any{int64(1), "a"}
+	if got := (SQLiteDialect{}).Bind(plain); &got[0] != &plain[0] {
+		t.Error("Bind copied arguments without times")
+	}
+}
+
+// TestSQLiteTimeOrder checks that times given in any zone and with any
+// fraction of a second sort and compare on SQLite like they do in Go.
+func TestSQLiteTimeOrder(t *testing.T) {
+	sqlDB, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "times.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	db := NewDB(sqlDB, SQLiteDialect{})
+	if _, err := db.Exec("CREATE TABLE `event` (`id` INTEGER PRIMARY KEY, `dt` DATETIME NOT NULL)"); err != nil {
+		t.Fatal(err)
+	}
+
+	cst := time.FixedZone("CST", -6*60*60)
+	times := []time.Time{
+		time.Date(2026, 3, 11, 1, 0, 0, 500_000_000, time.UTC),
+		time.Date(2026, 3, 10, 20, 0, 0, 0, cst),
+		time.Date(2026, 3, 11, 1, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 11, 1, 0, 0, 250_000_000, time.UTC),
+		time.Date(2026, 3, 10, 19, 30, 0, 0, cst),
+	}
+	for i, dateTime := range times {
+		if _, err := db.Exec("INSERT INTO `event` (`id`, `dt`) VALUES (?,?)", i, dateTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rows, err := db.Query("SELECT `dt` FROM `event` WHERE `dt` < ? ORDER BY `dt`", time.Date(2026, 3, 10, 20, 0, 0, 0, cst))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var got []time.Time
+	for rows.Next() {
+		var dateTime time.Time
+		if err := rows.Scan(&dateTime); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, dateTime)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []time.Time{times[2], times[3], times[0], times[4]}
+	if !slices.EqualFunc(got, want, time.Time.Equal) {
+		t.Errorf("times before %s = %v, want %v", times[1], got, want)
+	}
+	for _, dateTime := range got {
+		if dateTime.Location() != time.UTC {
+			t.Errorf("read %s, want it in UTC", dateTime)
+		}
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		want    string
+	}{
+		{MySQLDialect{}, "ON DUPLICATE KEY UPDATE n = n + VALUES(n)"},
+		{PostgresDialect{}, "ON CONFLICT (a, b) DO UPDATE SET n = n + excluded.n"},
+		{SQLiteDialect{}, "ON CONFLICT (a, b) DO UPDATE SET n = n + excluded.n"},
+	}
+	for _, test := range tests {
+		got := test.dialect.Upsert([]string{"a", "b"}, "n = n + "+test.dialect.Inserted("n"))
+		if got != test.want {
+			t.Errorf("%s upsert = %q, want %q", test.dialect.Name(), got, test.want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+)
+
+const (
+	mysqlDuplicateEntry        = 1062
+	postgresUniqueViolation    = "23505"
+	sqliteConstraintUnique     = 2067
+	sqliteConstraintPrimaryKey = 1555
+)
+
+// IsUniqueViolation reports whether err comes from an insert or update that
+// broke a unique constraint on column. Every supported driver names the
+// offending key or column in its message, which is how column is matched.
+func IsUniqueViolation(err error, column string) bool {
+	if err == nil {
+		return false
+	}
+
+	unique := false
+
+	var mysqlErr *mysql.MySQLError
+	var pqErr *pq.Error
+	var sqliteErr *sqlite.Error
+	switch {
+	case errors.As(err, &mysqlErr):
+		unique = mysqlErr.Number == mysqlDuplicateEntry
+	case errors.As(err, &pqErr):
+		unique = pqErr.Code == postgresUniqueViolation
+	case errors.As(err, &sqliteErr):
+		unique = sqliteErr.Code() == sqliteConstraintUnique || sqliteErr.Code() == sqliteConstraintPrimaryKey
+	}
+
+	return unique && strings.Contains(err.Error(), column)
+}
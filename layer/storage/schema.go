@@ -0,0 +1,34 @@
+package storage
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+)
+
+// schema/sqlite.sql is the source of the schema. schema/postgres.sql is
+// generated from it, and db/stori_db.sql, the MariaDB dump, is checked to
+// have the same tables and columns; see schema_test.go.
+//go:generate go test -run TestPostgresSchema -update
+
+//go:embed schema/sqlite.sql
+var sqliteSchema string
+
+// EnsureSchema creates any missing table of a SQLite database, so local and
+// test databases need no setup. PostgreSQL and MariaDB are provisioned from
+// schema/postgres.sql and db/stori_db.sql; for them it does nothing.
+func EnsureSchema(db *DB) error {
+	if db.Dialect.Name() != DRIVER_SQLITE {
+		return nil
+	}
+
+	for _, statement := range strings.Split(sqliteSchema, ";") {
+		if strings.TrimSpace(statement) == "" {
+			continue
+		}
+		if _, err := db.DB.Exec(statement); err != nil {
+			return fmt.Errorf("error while creating %s schema: %v", db.Dialect.Name(), err)
+		}
+	}
+	return nil
+}
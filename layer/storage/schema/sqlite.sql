@@ -0,0 +1,214 @@
+CREATE TABLE IF NOT EXISTS `customer` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `name` VARCHAR(100) NOT NULL,
+  `last_name` VARCHAR(100) NOT NULL,
+  `second_last_name` VARCHAR(100) NOT NULL DEFAULT '',
+  `date_of_birth` DATE NOT NULL,
+  `curp` CHAR(18) NOT NULL UNIQUE,
+  `rfc` VARCHAR(13) NOT NULL DEFAULT '',
+  `phone` VARCHAR(16) NOT NULL DEFAULT '',
+  `email` VARCHAR(255) NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS `account` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `customer_id` INTEGER NOT NULL,
+  `account_number` VARCHAR(20) NOT NULL UNIQUE,
+  `account_type` VARCHAR(10) NOT NULL DEFAULT 'debit',
+  `current_balance_amt` BIGINT DEFAULT 0,
+  `credit_limit_amt` BIGINT NOT NULL DEFAULT 0,
+  `cutoff_day` INTEGER NOT NULL DEFAULT 0,
+  `payment_due_days` INTEGER NOT NULL DEFAULT 0,
+  `status` VARCHAR(10) NOT NULL DEFAULT 'active',
+  `status_reason` VARCHAR(255) NOT NULL DEFAULT '',
+  `status_changed_at` DATETIME DEFAULT NULL,
+  `closed_through` VARCHAR(7) NOT NULL DEFAULT '',
+  FOREIGN KEY (`customer_id`) REFERENCES `customer` (`id`)
+);
+
+CREATE INDEX IF NOT EXISTS `account_customer_id` ON `account` (`customer_id`);
+
+CREATE TABLE IF NOT EXISTS `balance` (
+  `account_id` INTEGER NOT NULL,
+  `month` VARCHAR(7) NOT NULL,
+  `amt` BIGINT NOT NULL,
+  `opening_amt` BIGINT NOT NULL DEFAULT 0,
+  PRIMARY KEY (`account_id`, `month`),
+  FOREIGN KEY (`account_id`) REFERENCES `account` (`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS `transaction` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `account_id` INTEGER NOT NULL,
+  `month` VARCHAR(7) NOT NULL,
+  `dt` DATETIME NOT NULL,
+  `amt` BIGINT NOT NULL,
+  `txn_type` VARCHAR(20) NOT NULL DEFAULT '',
+  `description` VARCHAR(255) NOT NULL DEFAULT '',
+  `merchant` VARCHAR(100) NOT NULL DEFAULT '',
+  `mcc` VARCHAR(4) NOT NULL DEFAULT '',
+  `channel` VARCHAR(10) NOT NULL DEFAULT '',
+  `location` VARCHAR(100) NOT NULL DEFAULT '',
+  `category` VARCHAR(40) NOT NULL DEFAULT '',
+  `category_override` VARCHAR(40) NOT NULL DEFAULT '',
+  `transfer_id` INTEGER NULL,
+  `original_transaction_id` INTEGER NULL,
+  `value_dt` DATETIME NULL,
+  FOREIGN KEY (`account_id`, `month`) REFERENCES `balance` (`account_id`, `month`) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS `transaction_account_id_month` ON `transaction` (`account_id`, `month`);
+CREATE INDEX IF NOT EXISTS `transaction_account_id_dt` ON `transaction` (`account_id`, `dt`, `id`);
+CREATE INDEX IF NOT EXISTS `transaction_transfer_id` ON `transaction` (`transfer_id`);
+CREATE INDEX IF NOT EXISTS `transaction_original_transaction_id` ON `transaction` (`original_transaction_id`);
+
+CREATE TABLE IF NOT EXISTS `monthly_stats` (
+  `account_id` INTEGER NOT NULL,
+  `month` VARCHAR(7) NOT NULL,
+  `txn_count` BIGINT NOT NULL DEFAULT 0,
+  `debit_count` BIGINT NOT NULL DEFAULT 0,
+  `debit_sum` BIGINT NOT NULL DEFAULT 0,
+  `credit_count` BIGINT NOT NULL DEFAULT 0,
+  `credit_sum` BIGINT NOT NULL DEFAULT 0,
+  `min_amt` BIGINT NOT NULL DEFAULT 0,
+  `max_amt` BIGINT NOT NULL DEFAULT 0,
+  PRIMARY KEY (`account_id`, `month`),
+  FOREIGN KEY (`account_id`, `month`) REFERENCES `balance` (`account_id`, `month`) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS `account_status_history` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `account_id` INTEGER NOT NULL,
+  `from_status` VARCHAR(10) NOT NULL,
+  `to_status` VARCHAR(10) NOT NULL,
+  `reason` VARCHAR(255) NOT NULL,
+  `changed_at` DATETIME NOT NULL,
+  FOREIGN KEY (`account_id`) REFERENCES `account` (`id`) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS `account_status_history_account_id` ON `account_status_history` (`account_id`, `changed_at`);
+
+CREATE TABLE IF NOT EXISTS `statement` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `account_id` INTEGER NOT NULL,
+  `period_start` DATE NOT NULL,
+  `period_end` DATE NOT NULL,
+  `due_date` DATE NOT NULL,
+  `previous_balance_amt` BIGINT NOT NULL,
+  `purchases_amt` BIGINT NOT NULL,
+  `charges_amt` BIGINT NOT NULL DEFAULT 0,
+  `payments_amt` BIGINT NOT NULL,
+  `statement_balance_amt` BIGINT NOT NULL,
+  `minimum_payment_amt` BIGINT NOT NULL,
+  `credit_limit_amt` BIGINT NOT NULL,
+  `available_credit_amt` BIGINT NOT NULL,
+  UNIQUE (`account_id`, `period_end`),
+  FOREIGN KEY (`account_id`) REFERENCES `account` (`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS `accrual` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `account_id` INTEGER NOT NULL,
+  `accrual_date` DATE NOT NULL,
+  `kind` VARCHAR(20) NOT NULL,
+  `amt` BIGINT NOT NULL,
+  `transaction_id` INTEGER NOT NULL,
+  UNIQUE (`account_id`, `accrual_date`, `kind`),
+  FOREIGN KEY (`account_id`) REFERENCES `account` (`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS `installment_plan` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `account_id` INTEGER NOT NULL,
+  `purchase_dt` DATETIME NOT NULL,
+  `amt` BIGINT NOT NULL,
+  `months` INTEGER NOT NULL,
+  `status` VARCHAR(10) NOT NULL DEFAULT 'active',
+  `closed_at` DATETIME NULL,
+  FOREIGN KEY (`account_id`) REFERENCES `account` (`id`) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS `installment_plan_account_id` ON `installment_plan` (`account_id`, `status`);
+
+CREATE TABLE IF NOT EXISTS `installment` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `plan_id` INTEGER NOT NULL,
+  `account_id` INTEGER NOT NULL,
+  `number` INTEGER NOT NULL,
+  `due_dt` DATETIME NOT NULL,
+  `month` VARCHAR(7) NOT NULL,
+  `amt` BIGINT NOT NULL,
+  `status` VARCHAR(10) NOT NULL DEFAULT 'pending',
+  `transaction_id` INTEGER NULL,
+  UNIQUE (`plan_id`, `number`),
+  FOREIGN KEY (`plan_id`) REFERENCES `installment_plan` (`id`) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS `installment_account_id_status` ON `installment` (`account_id`, `status`);
+CREATE INDEX IF NOT EXISTS `installment_status_due_dt` ON `installment` (`status`, `due_dt`);
+
+CREATE TABLE IF NOT EXISTS `transfer` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `client_reference` VARCHAR(64) NOT NULL UNIQUE,
+  `from_account_id` INTEGER NOT NULL,
+  `to_account_id` INTEGER NOT NULL,
+  `amt` BIGINT NOT NULL,
+  `description` VARCHAR(255) NOT NULL DEFAULT '',
+  `dt` DATETIME NOT NULL,
+  `status` VARCHAR(10) NOT NULL DEFAULT 'posted',
+  `reversed_at` DATETIME NULL,
+  FOREIGN KEY (`from_account_id`) REFERENCES `account` (`id`) ON DELETE CASCADE,
+  FOREIGN KEY (`to_account_id`) REFERENCES `account` (`id`) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS `transfer_from_account_id` ON `transfer` (`from_account_id`);
+CREATE INDEX IF NOT EXISTS `transfer_to_account_id` ON `transfer` (`to_account_id`);
+
+CREATE TABLE IF NOT EXISTS `journal_entry` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `transaction_id` INTEGER NULL UNIQUE,
+  `dt` DATETIME NOT NULL,
+  `description` VARCHAR(255) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS `posting` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `entry_id` INTEGER NOT NULL,
+  `ledger_account` VARCHAR(20) NOT NULL,
+  `account_id` INTEGER NULL,
+  `amt` BIGINT NOT NULL,
+  FOREIGN KEY (`entry_id`) REFERENCES `journal_entry` (`id`) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS `posting_entry_id` ON `posting` (`entry_id`);
+CREATE INDEX IF NOT EXISTS `posting_ledger_account_account_id` ON `posting` (`ledger_account`, `account_id`);
+
+CREATE TABLE IF NOT EXISTS `authorization_hold` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `account_id` INTEGER NOT NULL,
+  `amt` BIGINT NOT NULL,
+  `captured_amt` BIGINT NOT NULL DEFAULT 0,
+  `txn_type` VARCHAR(20) NOT NULL DEFAULT '',
+  `description` VARCHAR(255) NOT NULL DEFAULT '',
+  `merchant` VARCHAR(100) NOT NULL DEFAULT '',
+  `mcc` VARCHAR(4) NOT NULL DEFAULT '',
+  `channel` VARCHAR(10) NOT NULL DEFAULT '',
+  `location` VARCHAR(100) NOT NULL DEFAULT '',
+  `authorized_at` DATETIME NOT NULL,
+  `expires_at` DATETIME NOT NULL,
+  `status` VARCHAR(10) NOT NULL DEFAULT 'pending',
+  `resolved_at` DATETIME NULL,
+  `transaction_id` INTEGER NULL,
+  FOREIGN KEY (`account_id`) REFERENCES `account` (`id`) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS `authorization_hold_account_id_status` ON `authorization_hold` (`account_id`, `status`);
+CREATE INDEX IF NOT EXISTS `authorization_hold_status_expires_at` ON `authorization_hold` (`status`, `expires_at`);
+
+CREATE TABLE IF NOT EXISTS `daily_balance` (
+  `account_id` INTEGER NOT NULL,
+  `balance_date` DATE NOT NULL,
+  `amt` BIGINT NOT NULL,
+  PRIMARY KEY (`account_id`, `balance_date`),
+  FOREIGN KEY (`account_id`) REFERENCES `account` (`id`) ON DELETE CASCADE
+);
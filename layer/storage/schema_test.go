@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"flag"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "regenerate schema/postgres.sql from schema/sqlite.sql")
+
+const postgresSchemaHeader = "-- Code generated by go generate from sqlite.sql; DO NOT EDIT.\n\n"
+
+// postgresSchema translates the SQLite schema, which sticks to SQL both
+// databases take, to PostgreSQL.
+func postgresSchema(sqlite string) string {
+	return postgresSchemaHeader + strings.NewReplacer(
+		"`", `"`,
+		"INTEGER PRIMARY KEY AUTOINCREMENT", "SERIAL PRIMARY KEY",
+		"DATETIME", "TIMESTAMP",
+	).Replace(sqlite)
+}
+
+func TestPostgresSchema(t *testing.T) {
+	want := postgresSchema(sqliteSchema)
+	if *update {
+		if err := os.WriteFile("schema/postgres.sql", []byte(want), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := os.ReadFile("schema/postgres.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Error("schema/postgres.sql is out of date with schema/sqlite.sql, run go generate ./storage")
+	}
+}
+
+// TestMariaDBSchema checks that the MariaDB dump has the tables and columns
+// of the SQLite schema, in the same order.
+func TestMariaDBSchema(t *testing.T) {
+	dump, err := os.ReadFile("../../db/stori_db.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := schemaColumns(sqliteSchema, regexp.MustCompile("^CREATE TABLE IF NOT EXISTS `(\\w+)`"))
+	got := schemaColumns(string(dump), regexp.MustCompile("^CREATE TABLE `(\\w+)`"))
+	for table, columns := range want {
+		if !slices.Equal(got[table], columns) {
+			t.Errorf("db/stori_db.sql table %s has columns %v, want %v", table, got[table], columns)
+		}
+	}
+	for table := range got {
+		if _, ok := want[table]; !ok {
+			t.Errorf("db/stori_db.sql has table %s, which schema/sqlite.sql does not", table)
+		}
+	}
+}
+
+var columnDefinition = regexp.MustCompile("^\\s+`(\\w+)`")
+
+// schemaColumns returns the columns of every table a CREATE TABLE statement
+// matching createTable defines.
+func schemaColumns(schema string, createTable *regexp.Regexp) map[string][]string {
+	tables := map[string][]string{}
+	table := ""
+	for line := range strings.Lines(schema) {
+		if match := createTable.FindStringSubmatch(line); match != nil {
+			table = match[1]
+			tables[table] = nil
+			continue
+		}
+		if table == "" {
+			continue
+		}
+		if match := columnDefinition.FindStringSubmatch(line); match != nil {
+			tables[table] = append(tables[table], match[1])
+		} else if strings.HasPrefix(line, ")") {
+			table = ""
+		}
+	}
+	return tables
+}
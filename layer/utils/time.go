@@ -2,7 +2,7 @@ package utils
 
 import "time"
 
-const STR_MONTH_FORMAT = "0001/01"
+const STR_MONTH_FORMAT = "2006/01"
 
 func GetMonth(dateTime time.Time) string {
 	return dateTime.Format(STR_MONTH_FORMAT)
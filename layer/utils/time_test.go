@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetMonth(t *testing.T) {
+	tests := []struct {
+		dateTime time.Time
+		want     string
+	}{
+		{time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC), "2026/03"},
+		{time.Date(2025, 12, 31, 23, 59, 59, 0, time.UTC), "2025/12"},
+		{time.Date(1999, 1, 1, 0, 0, 0, 0, time.UTC), "1999/01"},
+	}
+	for _, test := range tests {
+		if got := GetMonth(test.dateTime); got != test.want {
+			t.Errorf("GetMonth(%s) = %q, want %q", test.dateTime, got, test.want)
+		}
+	}
+}
+
+func TestParseMonthTime(t *testing.T) {
+	got, err := ParseMonthTime("2026/03")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("ParseMonthTime(2026/03) = %s, want %s", got, want)
+	}
+	if month := GetMonth(got); month != "2026/03" {
+		t.Errorf("GetMonth(ParseMonthTime(2026/03)) = %q", month)
+	}
+
+	for _, month := range []string{"", "2026-03", "2026/13", "03/2026"} {
+		if _, err := ParseMonthTime(month); err == nil {
+			t.Errorf("ParseMonthTime(%q) succeeded, want an error", month)
+		}
+	}
+}
@@ -4,4 +4,187 @@ const (
 	ErrFieldRequired = "%s must be provided"
 	ErrAgeTooLow     = "age must be at least 18, instead given: %d"
 	ErrEmailFormat   = "email must be given in mail format <local>@<domain>.<top-level-domain>, instead given: %s"
+	ErrDateOfBirth   = "date of birth must be in the past, instead given: %s"
+	ErrCURPFormat    = "CURP must be 18 characters with a valid check digit, instead given: %s"
+	ErrCURPMismatch  = "CURP %s does not match the customer name and date of birth"
+	ErrRFCFormat     = "RFC must be 13 characters with a valid check digit, instead given: %s"
+	ErrRFCMismatch   = "RFC %s does not match the customer name and date of birth"
+	ErrPhoneFormat   = "phone must be given in E.164 format +<country code><number>, instead given: %s"
+	ErrAccountNumber = "account number must be an 18 digit CLABE with a valid check digit, instead given: %s"
+	ErrCodeDigits    = "%s must be %d digits, instead given: %s"
+	ErrMonthFormat   = "month must be given in format YYYY/MM, instead given: %s"
+	ErrInteger       = "%s must be an integer, instead given: %s"
+	ErrAccountStatus = "status must be one of active, frozen or closed, instead given: %s"
+	ErrTransition    = "account status cannot change from %s to %s"
+	ErrPositive      = "%s must be greater than zero, instead given: %d"
+	ErrRange         = "%s must be between %d and %d, instead given: %d"
+	ErrInstallments  = "months must be one of 3, 6 or 12, instead given: %d"
+	ErrTxnType       = "type must be one of purchase, payment, transfer, fee, interest, refund, reversal or installment, instead given: %s"
+	ErrDebitType     = "%s transactions must be debits, instead given: %d"
+	ErrCreditType    = "%s transactions must be credits, instead given: %d"
+	ErrChannel       = "channel must be one of pos, online, atm, branch, app or system, instead given: %s"
+	ErrMaxLength     = "%s must be at most %d characters long, instead given: %d"
+	ErrCategory      = "category must be one of the configured categories, instead given: %s"
+	ErrDifferent     = "%s must be different from %s"
+	ErrMax           = "%s must be at most %d, instead given: %d"
+	ErrNotBefore     = "%s must not be before %s, instead given: %s"
+	ErrInvalid       = "%s"
 )
+
+// Codes identify each kind of validation failure in API responses, so that
+// clients do not have to parse the messages.
+const (
+	CodeRequired          = "required"
+	CodeAgeTooLow         = "age_too_low"
+	CodeEmailFormat       = "email_format"
+	CodeDateOfBirth       = "date_of_birth"
+	CodeCURPFormat        = "curp_format"
+	CodeCURPMismatch      = "curp_mismatch"
+	CodeRFCFormat         = "rfc_format"
+	CodeRFCMismatch       = "rfc_mismatch"
+	CodePhoneFormat       = "phone_format"
+	CodeAccountNumber     = "account_number"
+	CodeCodeDigits        = "code_digits"
+	CodeMonthFormat       = "month_format"
+	CodeInteger           = "integer"
+	CodeAccountStatus     = "account_status"
+	CodeTransition        = "status_transition"
+	CodePositive          = "positive"
+	CodeRange             = "range"
+	CodeInstallmentMonths = "installment_months"
+	CodeTransactionType   = "transaction_type"
+	CodeDebitType         = "debit_type"
+	CodeCreditType        = "credit_type"
+	CodeChannel           = "channel"
+	CodeMaxLength         = "max_length"
+	CodeCategory          = "category"
+	CodeDifferent         = "different"
+	CodeMax               = "max"
+	CodeNotBefore         = "not_before"
+	// CodeInvalid wraps a plain error whose only parameter is its message.
+	CodeInvalid = "invalid"
+)
+
+const (
+	LOCALE_EN      = "en"
+	LOCALE_ES      = "es"
+	DEFAULT_LOCALE = LOCALE_EN
+)
+
+// messages holds the format string of every code per locale. Each format
+// takes the FieldError params in order, its field names translated with labels.
+var messages = map[string]map[string]string{
+	LOCALE_EN: {
+		CodeRequired:          ErrFieldRequired,
+		CodeAgeTooLow:         ErrAgeTooLow,
+		CodeEmailFormat:       ErrEmailFormat,
+		CodeDateOfBirth:       ErrDateOfBirth,
+		CodeCURPFormat:        ErrCURPFormat,
+		CodeCURPMismatch:      ErrCURPMismatch,
+		CodeRFCFormat:         ErrRFCFormat,
+		CodeRFCMismatch:       ErrRFCMismatch,
+		CodePhoneFormat:       ErrPhoneFormat,
+		CodeAccountNumber:     ErrAccountNumber,
+		CodeCodeDigits:        ErrCodeDigits,
+		CodeMonthFormat:       ErrMonthFormat,
+		CodeInteger:           ErrInteger,
+		CodeAccountStatus:     ErrAccountStatus,
+		CodeTransition:        ErrTransition,
+		CodePositive:          ErrPositive,
+		CodeRange:             ErrRange,
+		CodeInstallmentMonths: ErrInstallments,
+		CodeTransactionType:   ErrTxnType,
+		CodeDebitType:         ErrDebitType,
+		CodeCreditType:        ErrCreditType,
+		CodeChannel:           ErrChannel,
+		CodeMaxLength:         ErrMaxLength,
+		CodeCategory:          ErrCategory,
+		CodeDifferent:         ErrDifferent,
+		CodeMax:               ErrMax,
+		CodeNotBefore:         ErrNotBefore,
+		CodeInvalid:           ErrInvalid,
+	},
+	LOCALE_ES: {
+		CodeRequired:          "el campo %s es obligatorio",
+		CodeAgeTooLow:         "la edad debe ser de al menos 18 años, se recibió: %d",
+		CodeEmailFormat:       "el correo debe tener el formato <local>@<dominio>.<dominio-de-nivel-superior>, se recibió: %s",
+		CodeDateOfBirth:       "la fecha de nacimiento debe estar en el pasado, se recibió: %s",
+		CodeCURPFormat:        "la CURP debe tener 18 caracteres y un dígito verificador válido, se recibió: %s",
+		CodeCURPMismatch:      "la CURP %s no coincide con el nombre y la fecha de nacimiento del cliente",
+		CodeRFCFormat:         "el RFC debe tener 13 caracteres y un dígito verificador válido, se recibió: %s",
+		CodeRFCMismatch:       "el RFC %s no coincide con el nombre y la fecha de nacimiento del cliente",
+		CodePhoneFormat:       "el teléfono debe estar en formato E.164 +<código de país><número>, se recibió: %s",
+		CodeAccountNumber:     "el número de cuenta debe ser una CLABE de 18 dígitos con dígito verificador válido, se recibió: %s",
+		CodeCodeDigits:        "el campo %s debe tener %d dígitos, se recibió: %s",
+		CodeMonthFormat:       "el mes debe tener el formato AAAA/MM, se recibió: %s",
+		CodeInteger:           "el campo %s debe ser un número entero, se recibió: %s",
+		CodeAccountStatus:     "el estado debe ser active, frozen o closed, se recibió: %s",
+		CodeTransition:        "el estado de la cuenta no puede cambiar de %s a %s",
+		CodePositive:          "el campo %s debe ser mayor que cero, se recibió: %d",
+		CodeRange:             "el campo %s debe estar entre %d y %d, se recibió: %d",
+		CodeInstallmentMonths: "los meses deben ser 3, 6 o 12, se recibió: %d",
+		CodeTransactionType:   "el tipo debe ser purchase, payment, transfer, fee, interest, refund, reversal o installment, se recibió: %s",
+		CodeDebitType:         "las transacciones %s deben ser cargos, se recibió: %d",
+		CodeCreditType:        "las transacciones %s deben ser abonos, se recibió: %d",
+		CodeChannel:           "el canal debe ser pos, online, atm, branch, app o system, se recibió: %s",
+		CodeMaxLength:         "el campo %s debe tener como máximo %d caracteres, se recibió: %d",
+		CodeCategory:          "la categoría debe ser una de las configuradas, se recibió: %s",
+		CodeDifferent:         "el campo %s debe ser distinto del campo %s",
+		CodeMax:               "el campo %s debe ser como máximo %d, se recibió: %d",
+		CodeNotBefore:         "el campo %s no debe ser anterior a %s, se recibió: %s",
+		CodeInvalid:           ErrInvalid,
+	},
+}
+
+// fieldParams lists, per code, the positions of the params that name a field
+// rather than carry a value. Message translates them with labels.
+var fieldParams = map[string][]int{
+	CodeRequired:   {0},
+	CodeCodeDigits: {0},
+	CodeInteger:    {0},
+	CodePositive:   {0},
+	CodeRange:      {0},
+	CodeMaxLength:  {0},
+	CodeDifferent:  {0, 1},
+	CodeMax:        {0},
+	CodeNotBefore:  {0},
+}
+
+// labels holds the field names of each locale that does not use the API
+// names as they are. Names without a label are shown unchanged.
+var labels = map[string]map[string]string{
+	LOCALE_ES: {
+		"accountID":       "cuenta",
+		"accountNumber":   "número de cuenta",
+		"accounts":        "cuentas",
+		"account serial":  "número de serie de la cuenta",
+		"amount":          "monto",
+		"bank code":       "código de banco",
+		"branch code":     "código de sucursal",
+		"clientReference": "referencia del cliente",
+		"creditLimit":     "límite de crédito",
+		"curp":            "CURP",
+		"customerID":      "cliente",
+		"cutoffDay":       "día de corte",
+		"dateOfBirth":     "fecha de nacimiento",
+		"dateTime":        "fecha y hora",
+		"day_of_month":    "día del mes",
+		"days":            "días",
+		"description":     "descripción",
+		"email_domain":    "dominio de correo",
+		"end":             "fin",
+		"fromAccountID":   "cuenta de origen",
+		"lastName":        "apellido paterno",
+		"location":        "ubicación",
+		"merchant":        "comercio",
+		"months":          "meses",
+		"name":            "nombre",
+		"paymentDueDays":  "días para pagar",
+		"profiles":        "perfiles",
+		"reason":          "motivo",
+		"seed":            "semilla",
+		"start":           "inicio",
+		"to":              "hasta",
+		"toAccountID":     "cuenta de destino",
+	},
+}
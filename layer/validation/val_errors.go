@@ -0,0 +1,154 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// FieldError is a single failed check. Field is the path of the offending
+// value, e.g. "email" or "transactions[3].amount", and Params are the
+// arguments of the message format registered for Code.
+type FieldError struct {
+	Field  string
+	Code   string
+	Params []any
+}
+
+// Message renders the error in the given locale, falling back to
+// DEFAULT_LOCALE for unknown locales or codes.
+func (e FieldError) Message(locale string) string {
+	format, ok := messages[locale][e.Code]
+	if !ok {
+		locale = DEFAULT_LOCALE
+		format, ok = messages[locale][e.Code]
+	}
+	if !ok {
+		return fmt.Sprintf("%s is invalid", e.Field)
+	}
+	return fmt.Sprintf(format, e.params(locale)...)
+}
+
+// params returns Params with the field names they hold translated to locale.
+func (e FieldError) params(locale string) []any {
+	localeLabels, ok := labels[locale]
+	if !ok || len(fieldParams[e.Code]) == 0 {
+		return e.Params
+	}
+	params := slices.Clone(e.Params)
+	for _, i := range fieldParams[e.Code] {
+		if i >= len(params) {
+			continue
+		}
+		if name, ok := params[i].(string); ok {
+			if label, ok := localeLabels[name]; ok {
+				params[i] = label
+			}
+		}
+	}
+	return params
+}
+
+func (e FieldError) Error() string {
+	return e.Message(DEFAULT_LOCALE)
+}
+
+// ValidationError collects every failed check of a value instead of stopping
+// at the first one. The zero value is ready to use; call Err once all checks
+// have run.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, fieldError := range e.Errors {
+		messages[i] = fieldError.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Add records a failed check.
+func (e *ValidationError) Add(field string, code string, params ...any) {
+	e.Errors = append(e.Errors, FieldError{Field: field, Code: code, Params: params})
+}
+
+// Check records a failed check when ok is false and returns ok, so that
+// dependent checks can be skipped.
+func (e *ValidationError) Check(ok bool, field string, code string, params ...any) bool {
+	if !ok {
+		e.Add(field, code, params...)
+	}
+	return ok
+}
+
+// Required records a CodeRequired error when value is empty.
+func (e *ValidationError) Required(field string, value string) bool {
+	return e.Check(value != "", field, CodeRequired, field)
+}
+
+// Merge adds the errors of a nested value under prefix. Errors that are not
+// a ValidationError are kept as a single CodeInvalid error on prefix.
+func (e *ValidationError) Merge(prefix string, err error) {
+	if err == nil {
+		return
+	}
+	var nested *ValidationError
+	if !errors.As(err, &nested) {
+		e.Add(prefix, CodeInvalid, err.Error())
+		return
+	}
+	for _, fieldError := range nested.Errors {
+		fieldError.Field = Path(prefix, fieldError.Field)
+		e.Errors = append(e.Errors, fieldError)
+	}
+}
+
+// HasErrors reports whether any check failed.
+func (e *ValidationError) HasErrors() bool {
+	return len(e.Errors) > 0
+}
+
+// Err returns e when a check failed and nil otherwise. Always return the
+// result of Err rather than e itself, since a nil *ValidationError stored in
+// an error is not nil.
+func (e *ValidationError) Err() error {
+	if !e.HasErrors() {
+		return nil
+	}
+	return e
+}
+
+// Path joins field path segments with dots, skipping empty ones.
+func Path(parts ...string) string {
+	var kept []string
+	for _, part := range parts {
+		if part != "" {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, ".")
+}
+
+// Index is the path of the i-th element of a list field, e.g.
+// Index("transactions", 3) is "transactions[3]".
+func Index(field string, i int) string {
+	return field + "[" + strconv.Itoa(i) + "]"
+}
+
+// AsValidationError returns the ValidationError in err's chain, if any.
+func AsValidationError(err error) (*ValidationError, bool) {
+	var validationErr *ValidationError
+	ok := errors.As(err, &validationErr)
+	return validationErr, ok
+}
+
+// NewFieldError is a ValidationError holding a single failed check, for code
+// paths that only validate one value.
+func NewFieldError(field string, code string, params ...any) *ValidationError {
+	e := &ValidationError{}
+	e.Add(field, code, params...)
+	return e
+}
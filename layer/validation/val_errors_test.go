@@ -0,0 +1,110 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMessagesCoverEveryCode(t *testing.T) {
+	for locale, formats := range messages {
+		for code := range messages[DEFAULT_LOCALE] {
+			if _, ok := formats[code]; !ok {
+				t.Errorf("locale %s has no message for %s", locale, code)
+			}
+		}
+	}
+}
+
+func TestFieldErrorMessage(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    FieldError
+		locale string
+		want   string
+	}{
+		{"english", FieldError{"bankCode", CodeCodeDigits, []any{"bank code", 3, "12"}}, LOCALE_EN,
+			"bank code must be 3 digits, instead given: 12"},
+		{"spanish label", FieldError{"bankCode", CodeCodeDigits, []any{"bank code", 3, "12"}}, LOCALE_ES,
+			"el campo código de banco debe tener 3 dígitos, se recibió: 12"},
+		{"both fields", FieldError{"toAccountID", CodeDifferent, []any{"toAccountID", "fromAccountID"}}, LOCALE_ES,
+			"el campo cuenta de destino debe ser distinto del campo cuenta de origen"},
+		{"values kept", FieldError{"amount", CodeDebitType, []any{"purchase", int64(500)}}, LOCALE_ES,
+			"las transacciones purchase deben ser cargos, se recibió: 500"},
+		{"unknown label", FieldError{"nickname", CodeRequired, []any{"nickname"}}, LOCALE_ES,
+			"el campo nickname es obligatorio"},
+		{"unknown locale", FieldError{"amount", CodePositive, []any{"amount", int64(0)}}, "fr",
+			"amount must be greater than zero, instead given: 0"},
+		{"unknown code", FieldError{"amount", "too_round", []any{"amount"}}, LOCALE_ES,
+			"amount is invalid"},
+	}
+	for _, test := range tests {
+		if got := test.err.Message(test.locale); got != test.want {
+			t.Errorf("%s: Message(%s) = %q, want %q", test.name, test.locale, got, test.want)
+		}
+	}
+
+	err := FieldError{"name", CodeRequired, []any{"name"}}
+	if err.Error() != "name must be provided" {
+		t.Errorf("Error() = %q, want the English message", err.Error())
+	}
+}
+
+func TestValidationErrorErr(t *testing.T) {
+	var errs ValidationError
+	if errs.Err() != nil || errs.HasErrors() {
+		t.Fatal("zero value reports errors")
+	}
+
+	if !errs.Required("name", "Ana") || errs.Required("lastName", "") {
+		t.Error("Required does not report whether the value was given")
+	}
+	if errs.Check(false, "amount", CodePositive, "amount", int64(-1)) {
+		t.Error("failed Check returned true")
+	}
+	err := errs.Err()
+	if err == nil {
+		t.Fatal("Err returned nil after failed checks")
+	}
+	want := "lastName must be provided; amount must be greater than zero, instead given: -1"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+
+	wrapped := fmt.Errorf("error while creating customer: %w", err)
+	if found, ok := AsValidationError(wrapped); !ok || found != &errs {
+		t.Errorf("AsValidationError(%v) = %v, %v", wrapped, found, ok)
+	}
+	if _, ok := AsValidationError(errors.New("plain")); ok {
+		t.Error("plain error taken for a ValidationError")
+	}
+}
+
+func TestValidationErrorMerge(t *testing.T) {
+	var errs ValidationError
+	errs.Merge("ignored", nil)
+	errs.Merge(Index("transactions", 3), NewFieldError("amount", CodeRequired, "amount"))
+	errs.Merge("transfer", errors.New("account is frozen"))
+
+	var fields, codes []string
+	for _, fieldError := range errs.Errors {
+		fields = append(fields, fieldError.Field)
+		codes = append(codes, fieldError.Code)
+	}
+	if got := strings.Join(fields, ","); got != "transactions[3].amount,transfer" {
+		t.Errorf("fields %s, want transactions[3].amount,transfer", got)
+	}
+	if got := strings.Join(codes, ","); got != CodeRequired+","+CodeInvalid {
+		t.Errorf("codes %s, want required,invalid", got)
+	}
+	if got := errs.Errors[1].Message(LOCALE_ES); got != "account is frozen" {
+		t.Errorf("plain error message %q, want its text", got)
+	}
+}
+
+func TestPath(t *testing.T) {
+	if got := Path("", "profiles[0]", "", "amount"); got != "profiles[0].amount" {
+		t.Errorf("Path = %s, want profiles[0].amount", got)
+	}
+}
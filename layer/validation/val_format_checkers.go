@@ -8,3 +8,39 @@ func IsEmailFormatOK(_string string) bool {
 	re := regexp.MustCompile(EMAIL_REGEX)
 	return re.MatchString(_string)
 }
+
+const (
+	CLABE_REGEX      = `^[0-9]{18}$`
+	CLABE_CODE_REGEX = `^[0-9]{3}$`
+)
+
+var clabeCodeRegex = regexp.MustCompile(CLABE_CODE_REGEX)
+
+// clabeWeights are applied cyclically to the first 17 digits of a CLABE.
+var clabeWeights = [3]int{3, 7, 1}
+
+// CLABECheckDigit computes the 18th digit of a CLABE from its first 17 digits,
+// which must all be decimal digits.
+func CLABECheckDigit(digits string) int {
+	sum := 0
+	for i := 0; i < 17; i++ {
+		sum += (int(digits[i]-'0') * clabeWeights[i%3]) % 10
+	}
+	return (10 - sum%10) % 10
+}
+
+// IsCLABEFormatOK reports whether _string is an 18 digit CLABE (3 bank, 3
+// branch, 11 account and 1 check digit) whose check digit is right.
+func IsCLABEFormatOK(_string string) bool {
+	re := regexp.MustCompile(CLABE_REGEX)
+	if !re.MatchString(_string) {
+		return false
+	}
+	return CLABECheckDigit(_string) == int(_string[17]-'0')
+}
+
+// IsCLABECodeFormatOK reports whether _string is a 3 digit bank or branch
+// code, the two prefixes of a CLABE.
+func IsCLABECodeFormatOK(_string string) bool {
+	return clabeCodeRegex.MatchString(_string)
+}
@@ -0,0 +1,79 @@
+package validation
+
+import "testing"
+
+func TestIsCLABEFormatOK(t *testing.T) {
+	tests := []struct {
+		clabe string
+		want  bool
+	}{
+		{"002010077777777771", true},
+		{"032180000118359719", true},
+		{"646180157000000004", true},
+		{"002010077777777772", false}, // wrong check digit
+		{"032180000118359710", false}, // wrong check digit
+		{"00201007777777777", false},
+		{"0020100777777777710", false},
+		{"00201007777777777A", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if got := IsCLABEFormatOK(test.clabe); got != test.want {
+			t.Errorf("IsCLABEFormatOK(%q) = %v, want %v", test.clabe, got, test.want)
+		}
+	}
+}
+
+func TestIsCLABECodeFormatOK(t *testing.T) {
+	tests := []struct {
+		code string
+		want bool
+	}{
+		{"999", true},
+		{"001", true},
+		{"12", false},
+		{"1234", false},
+		{"1a3", false},
+		{" 12", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if got := IsCLABECodeFormatOK(test.code); got != test.want {
+			t.Errorf("IsCLABECodeFormatOK(%q) = %v, want %v", test.code, got, test.want)
+		}
+	}
+}
+
+func TestCLABECheckDigit(t *testing.T) {
+	tests := []struct {
+		first17 string
+		want    int
+	}{
+		{"00201007777777777", 1},
+		{"03218000011835971", 9},
+		{"64618015700000000", 4},
+	}
+	for _, test := range tests {
+		if got := CLABECheckDigit(test.first17); got != test.want {
+			t.Errorf("CLABECheckDigit(%q) = %d, want %d", test.first17, got, test.want)
+		}
+	}
+}
+
+func TestIsEmailFormatOK(t *testing.T) {
+	tests := []struct {
+		email string
+		want  bool
+	}{
+		{"gloria@example.com", true},
+		{"gloria.hernandez+stori@mail.example.mx", true},
+		{"gloria@example", false},
+		{"gloria.example.com", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if got := IsEmailFormatOK(test.email); got != test.want {
+			t.Errorf("IsEmailFormatOK(%q) = %v, want %v", test.email, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package validation
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	CURP_REGEX  = `^[A-Z][AEIOUX][A-Z]{2}[0-9]{6}[HMX](AS|BC|BS|CC|CL|CM|CS|CH|DF|DG|GT|GR|HG|JC|MC|MN|MS|NT|NL|OC|PL|QT|QR|SP|SL|SR|TC|TS|TL|VZ|YN|ZS|NE)[B-DF-HJ-NP-TV-Z]{3}[0-9A-Z][0-9]$`
+	RFC_REGEX   = `^[A-ZÑ&]{4}[0-9]{6}[A-Z0-9]{2}[0-9A]$`
+	PHONE_REGEX = `^\+[1-9][0-9]{7,14}$`
+)
+
+var (
+	curpRegex  = regexp.MustCompile(CURP_REGEX)
+	rfcRegex   = regexp.MustCompile(RFC_REGEX)
+	phoneRegex = regexp.MustCompile(PHONE_REGEX)
+)
+
+// curpAlphabet gives each CURP character its value for the check digit.
+const curpAlphabet = "0123456789ABCDEFGHIJKLMNÑOPQRSTUVWXYZ"
+
+// rfcAlphabet gives each RFC character its value for the check digit; the
+// space is never part of a person's RFC but keeps Ñ at its official value.
+const rfcAlphabet = "0123456789ABCDEFGHIJKLMN&OPQRSTUVWXYZ Ñ"
+
+// Particles and compound first names skipped when building the name part of
+// a CURP or RFC, e.g. "DE LA CRUZ" counts as "CRUZ" and "MARIA LUISA" as
+// "LUISA".
+var (
+	nameParticles       = map[string]bool{"DA": true, "DAS": true, "DE": true, "DEL": true, "DER": true, "DI": true, "DIE": true, "DD": true, "EL": true, "LA": true, "LOS": true, "LAS": true, "LE": true, "LES": true, "MAC": true, "MC": true, "VAN": true, "VON": true, "Y": true}
+	commonFirstNames    = map[string]bool{"MARIA": true, "MA": true, "MA.": true, "JOSE": true, "J": true, "J.": true}
+	accentReplacer      = strings.NewReplacer("Á", "A", "É", "E", "Í", "I", "Ó", "O", "Ú", "U", "Ü", "U", "á", "A", "é", "E", "í", "I", "ó", "O", "ú", "U", "ü", "U", "ñ", "Ñ")
+	curpAllowedInPrefix = regexp.MustCompile(`[^A-Z]`)
+)
+
+// IsCURPFormatOK reports whether _string has the CURP layout and a valid check
+// digit. It does not compare it with the customer data, see IsCURPConsistent.
+func IsCURPFormatOK(_string string) bool {
+	if !curpRegex.MatchString(_string) {
+		return false
+	}
+	return CURPCheckDigit(_string[:17]) == int(_string[17]-'0')
+}
+
+// CURPCheckDigit computes the last digit of a CURP from its first 17
+// characters.
+func CURPCheckDigit(first17 string) int {
+	sum := 0
+	for i, r := range []rune(first17) {
+		sum += alphabetValue(curpAlphabet, r) * (18 - i)
+	}
+	return (10 - sum%10) % 10
+}
+
+// IsCURPConsistent reports whether the name and birth date encoded in the
+// CURP match the customer. The second letter is also accepted as X, which
+// RENAPO uses to break up inconvenient words.
+func IsCURPConsistent(curp string, name string, lastName string, secondLastName string, dateOfBirth time.Time) bool {
+	if len(curp) != 18 {
+		return false
+	}
+	expected := NamePrefix(name, lastName, secondLastName)
+	if !prefixMatches(curp[:4], expected) {
+		return false
+	}
+	if curp[4:10] != dateOfBirth.Format("060102") {
+		return false
+	}
+	return curp[13:16] == InnerConsonants(name, lastName, secondLastName)
+}
+
+// IsRFCFormatOK reports whether _string is the 13 character RFC of a person
+// with a valid check digit.
+func IsRFCFormatOK(_string string) bool {
+	if !rfcRegex.MatchString(_string) {
+		return false
+	}
+	runes := []rune(_string)
+	return RFCCheckDigit(string(runes[:12])) == runes[12]
+}
+
+// RFCCheckDigit computes the last character of a person's RFC from its first
+// 12 characters.
+func RFCCheckDigit(first12 string) rune {
+	runes := []rune(first12)
+	sum := 0
+	for i, r := range runes {
+		sum += alphabetValue(rfcAlphabet, r) * (13 - i)
+	}
+	switch digit := 11 - sum%11; digit {
+	case 11:
+		return '0'
+	case 10:
+		return 'A'
+	default:
+		return rune('0' + digit)
+	}
+}
+
+// IsRFCConsistent reports whether the name and birth date encoded in the RFC
+// match the customer. The fourth letter is also accepted as X, which the SAT
+// uses to break up inconvenient words.
+func IsRFCConsistent(rfc string, name string, lastName string, secondLastName string, dateOfBirth time.Time) bool {
+	runes := []rune(rfc)
+	if len(runes) != 13 {
+		return false
+	}
+	if !rfcPrefixMatches(string(runes[:4]), RFCNamePrefix(name, lastName, secondLastName)) {
+		return false
+	}
+	return string(runes[4:10]) == dateOfBirth.Format("060102")
+}
+
+// IsPhoneFormatOK reports whether _string is a phone number in E.164 format,
+// e.g. +525512345678.
+func IsPhoneFormatOK(_string string) bool {
+	return phoneRegex.MatchString(_string)
+}
+
+// NamePrefix returns the four letters that open the CURP of a person: the
+// initial and first inner vowel of the first last name, the initial of the
+// second last name (X when there is none) and the initial of the first name.
+// The RFC mostly opens with the same letters, see RFCNamePrefix.
+func NamePrefix(name string, lastName string, secondLastName string) string {
+	first := significantWord(lastName, false)
+	second := significantWord(secondLastName, false)
+	given := significantWord(name, true)
+
+	var sb strings.Builder
+	sb.WriteByte(initial(first))
+	sb.WriteByte(firstInner(first, "AEIOU"))
+	sb.WriteByte(initial(second))
+	sb.WriteByte(initial(given))
+	return sb.String()
+}
+
+// RFCNamePrefix returns the four letters that open the RFC of a person. They
+// are those of NamePrefix, except that the SAT takes the first two letters of
+// the first last name and of the first name when there is no second last
+// name, and the initials of both last names and the first two letters of the
+// first name when the first last name has only one or two letters.
+func RFCNamePrefix(name string, lastName string, secondLastName string) string {
+	first := significantWord(lastName, false)
+	second := significantWord(secondLastName, false)
+	given := significantWord(name, true)
+
+	switch {
+	case second == "":
+		return leading(first, 2) + leading(given, 2)
+	case len(first) <= 2:
+		return string([]byte{initial(first), initial(second)}) + leading(given, 2)
+	}
+	return NamePrefix(name, lastName, secondLastName)
+}
+
+// InnerConsonants returns the first inner consonant of the first last name,
+// the second last name and the first name, as used in positions 14 to 16 of
+// the CURP.
+func InnerConsonants(name string, lastName string, secondLastName string) string {
+	const consonants = "BCDFGHJKLMNPQRSTVWXYZ"
+	return string([]byte{
+		firstInner(significantWord(lastName, false), consonants),
+		firstInner(significantWord(secondLastName, false), consonants),
+		firstInner(significantWord(name, true), consonants),
+	})
+}
+
+// significantWord normalizes a name to plain upper case letters and returns
+// its first word that is not a particle. For first names it also skips a
+// leading MARIA or JOSE when another name follows.
+func significantWord(value string, isFirstName bool) string {
+	words := strings.Fields(strings.ToUpper(accentReplacer.Replace(value)))
+	var kept []string
+	for _, word := range words {
+		if !nameParticles[word] {
+			kept = append(kept, word)
+		}
+	}
+	if isFirstName && len(kept) > 1 && commonFirstNames[kept[0]] {
+		kept = kept[1:]
+	}
+	if len(kept) == 0 {
+		return ""
+	}
+	// Ñ and any other non A-Z letter are written as X in these codes.
+	return curpAllowedInPrefix.ReplaceAllString(strings.ReplaceAll(kept[0], "Ñ", "X"), "X")
+}
+
+// alphabetValue is the position of r in alphabet counted in runes, as Ñ takes
+// two bytes.
+func alphabetValue(alphabet string, r rune) int {
+	for i, letter := range []rune(alphabet) {
+		if letter == r {
+			return i
+		}
+	}
+	return -1
+}
+
+func initial(word string) byte {
+	if word == "" {
+		return 'X'
+	}
+	return word[0]
+}
+
+// leading returns the first n letters of word, padded with X.
+func leading(word string, n int) string {
+	if len(word) >= n {
+		return word[:n]
+	}
+	return word + strings.Repeat("X", n-len(word))
+}
+
+func firstInner(word string, letters string) byte {
+	for i := 1; i < len(word); i++ {
+		if strings.IndexByte(letters, word[i]) >= 0 {
+			return word[i]
+		}
+	}
+	return 'X'
+}
+
+func prefixMatches(actual string, expected string) bool {
+	if len(actual) != 4 || len(expected) != 4 {
+		return false
+	}
+	return actual[0] == expected[0] && (actual[1] == expected[1] || actual[1] == 'X') && actual[2:] == expected[2:]
+}
+
+// rfcPrefixMatches is prefixMatches for the RFC, where the letter replaced by
+// X is the fourth one.
+func rfcPrefixMatches(actual string, expected string) bool {
+	if len(actual) != 4 || len(expected) != 4 {
+		return false
+	}
+	return actual[:3] == expected[:3] && (actual[3] == expected[3] || actual[3] == 'X')
+}
@@ -0,0 +1,155 @@
+package validation
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsCURPFormatOK(t *testing.T) {
+	tests := []struct {
+		curp string
+		want bool
+	}{
+		{"HEGG560427MVZRRL04", true},
+		{"HEGG560427MVZRRL05", false}, // wrong check digit
+		{"HEGG560427MXXRRL04", false}, // unknown state
+		{"HEGG560427AVZRRL04", false}, // unknown sex
+		{"hegg560427mvzrrl04", false},
+		{"HEGG560427MVZRRL0", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if got := IsCURPFormatOK(test.curp); got != test.want {
+			t.Errorf("IsCURPFormatOK(%q) = %v, want %v", test.curp, got, test.want)
+		}
+	}
+}
+
+func TestCURPCheckDigit(t *testing.T) {
+	if got := CURPCheckDigit("HEGG560427MVZRRL0"); got != 4 {
+		t.Errorf("CURPCheckDigit = %d, want 4", got)
+	}
+}
+
+func TestIsRFCFormatOK(t *testing.T) {
+	tests := []struct {
+		rfc  string
+		want bool
+	}{
+		{"GODE561231GR8", true},
+		{"MAJU900517AB1", true},
+		{"BUEX900517AB5", true},
+		{"GODE561231GR9", false}, // wrong check digit
+		{"MAJU900517AB2", false}, // wrong check digit
+		{"ABC680524P76", false},  // a company RFC, not a person's
+		{"gode561231gr8", false},
+		{"GODE5612310GR8", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if got := IsRFCFormatOK(test.rfc); got != test.want {
+			t.Errorf("IsRFCFormatOK(%q) = %v, want %v", test.rfc, got, test.want)
+		}
+	}
+}
+
+func TestRFCCheckDigit(t *testing.T) {
+	tests := []struct {
+		first12 string
+		want    rune
+	}{
+		{"GODE561231GR", '8'},
+		{"MAJU900517AB", '1'},
+		{"BUEX900517AB", '5'},
+	}
+	for _, test := range tests {
+		if got := RFCCheckDigit(test.first12); got != test.want {
+			t.Errorf("RFCCheckDigit(%q) = %c, want %c", test.first12, got, test.want)
+		}
+	}
+}
+
+func TestNamePrefix(t *testing.T) {
+	tests := []struct {
+		name, lastName, secondLastName string
+		want                           string
+	}{
+		{"Gloria", "Hernández", "García", "HEGG"},
+		{"Juan", "Martinez", "", "MAXJ"},
+		{"María Luisa", "Ramos", "Pérez", "RAPL"},
+		{"José", "Ramos", "Pérez", "RAPJ"},
+		{"Juan", "de la Cruz", "López", "CULJ"},
+		{"Juan", "Núñez", "López", "NULJ"},
+		{"Juan", "Ñeco", "López", "XELJ"},
+	}
+	for _, test := range tests {
+		if got := NamePrefix(test.name, test.lastName, test.secondLastName); got != test.want {
+			t.Errorf("NamePrefix(%q, %q, %q) = %s, want %s", test.name, test.lastName, test.secondLastName, got, test.want)
+		}
+	}
+}
+
+func TestRFCNamePrefix(t *testing.T) {
+	tests := []struct {
+		name, lastName, secondLastName string
+		want                           string
+	}{
+		{"Gloria", "Hernández", "García", "HEGG"},
+		{"Yolanda", "Bueno", "Esparza", "BUEY"},
+		{"Juan", "Martinez", "", "MAJU"},
+		{"María Luisa", "Ramos", "", "RALU"},
+		{"Ana", "Ek", "Pérez", "EPAN"},
+		{"Juan", "de la Cruz", "López", "CULJ"},
+	}
+	for _, test := range tests {
+		if got := RFCNamePrefix(test.name, test.lastName, test.secondLastName); got != test.want {
+			t.Errorf("RFCNamePrefix(%q, %q, %q) = %s, want %s", test.name, test.lastName, test.secondLastName, got, test.want)
+		}
+	}
+}
+
+func TestIsCURPConsistent(t *testing.T) {
+	born := time.Date(1956, 4, 27, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		curp        string
+		dateOfBirth time.Time
+		want        bool
+	}{
+		{"HEGG560427MVZRRL04", born, true},
+		{"HXGG560427MVZRRL04", born, true}, // X breaking up a word
+		{"HEGG560427MVZRRL04", born.AddDate(0, 0, 1), false},
+		{"HEGX560427MVZRRL04", born, false},
+		{"HEGG560427MVZRRX04", born, false},
+		{"HEGG560427MVZ", born, false},
+	}
+	for _, test := range tests {
+		if got := IsCURPConsistent(test.curp, "Gloria", "Hernández", "García", test.dateOfBirth); got != test.want {
+			t.Errorf("IsCURPConsistent(%q, %s) = %v, want %v", test.curp, test.dateOfBirth.Format(time.DateOnly), got, test.want)
+		}
+	}
+}
+
+func TestIsRFCConsistent(t *testing.T) {
+	born := time.Date(1990, 5, 17, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		rfc                            string
+		name, lastName, secondLastName string
+		dateOfBirth                    time.Time
+		want                           bool
+	}{
+		{"MAJU900517AB1", "Juan", "Martinez", "", born, true},
+		{"MAXJ900517AB1", "Juan", "Martinez", "", born, false}, // the CURP rule
+		{"BUEX900517AB5", "Yolanda", "Bueno", "Esparza", born, true},
+		{"BUEY900517AB5", "Yolanda", "Bueno", "Esparza", born, true},
+		{"BXEY900517AB5", "Yolanda", "Bueno", "Esparza", born, false}, // X is the fourth letter in an RFC
+		{"BUEY900518AB5", "Yolanda", "Bueno", "Esparza", born, false},
+		{"HEGG560427AB1", "Gloria", "Hernández", "García", time.Date(1956, 4, 27, 0, 0, 0, 0, time.UTC), true},
+		{"MAJU900517", "Juan", "Martinez", "", born, false},
+	}
+	for _, test := range tests {
+		got := IsRFCConsistent(test.rfc, test.name, test.lastName, test.secondLastName, test.dateOfBirth)
+		if got != test.want {
+			t.Errorf("IsRFCConsistent(%q, %q, %q, %q) = %v, want %v", test.rfc, test.name, test.lastName, test.secondLastName, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package validation
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// ErrorResponse is the JSON body returned by the API for a rejected request.
+type ErrorResponse struct {
+	Message string               `json:"message"`
+	Errors  []FieldErrorResponse `json:"errors,omitempty"`
+}
+
+type FieldErrorResponse struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+var responseMessages = map[string]string{
+	LOCALE_EN: "The request is invalid",
+	LOCALE_ES: "La solicitud no es válida",
+}
+
+// Response renders the errors in the given locale for an API response.
+func (e *ValidationError) Response(locale string) ErrorResponse {
+	message, ok := responseMessages[locale]
+	if !ok {
+		locale = DEFAULT_LOCALE
+		message = responseMessages[locale]
+	}
+	response := ErrorResponse{Message: message}
+	for _, fieldError := range e.Errors {
+		response.Errors = append(response.Errors, FieldErrorResponse{
+			Field:   fieldError.Field,
+			Code:    fieldError.Code,
+			Message: fieldError.Message(locale),
+		})
+	}
+	return response
+}
+
+// MarshalJSON writes the DEFAULT_LOCALE response, so a ValidationError can be
+// logged or returned as is.
+func (e *ValidationError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.Response(DEFAULT_LOCALE))
+}
+
+// Locale picks the first supported language of an Accept-Language header,
+// e.g. "es-MX,es;q=0.9,en;q=0.8" gives "es". Quality values are ignored as
+// browsers already list languages by preference.
+func Locale(acceptLanguage string) string {
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		language, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if _, ok := messages[language]; ok {
+			return language
+		}
+	}
+	return DEFAULT_LOCALE
+}
+
+// APIGatewayResponse answers a rejected API Gateway request with 400: the
+// field errors of err as JSON, in the language asked for by the
+// Accept-Language header, or the text of err when it is not a
+// ValidationError.
+func APIGatewayResponse(err error, headers map[string]string) events.APIGatewayProxyResponse {
+	validationErr, ok := AsValidationError(err)
+	if !ok {
+		return events.APIGatewayProxyResponse{
+			StatusCode: http.StatusBadRequest,
+			Body:       err.Error(),
+		}
+	}
+
+	acceptLanguage := headers["Accept-Language"]
+	if acceptLanguage == "" {
+		acceptLanguage = headers["accept-language"]
+	}
+	body, err := json.Marshal(validationErr.Response(Locale(acceptLanguage)))
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: http.StatusBadRequest,
+			Body:       validationErr.Error(),
+		}
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusBadRequest,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(body),
+	}
+}
@@ -0,0 +1,79 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLocale(t *testing.T) {
+	tests := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{"", LOCALE_EN},
+		{"es", LOCALE_ES},
+		{"es-MX,es;q=0.9,en;q=0.8", LOCALE_ES},
+		{"fr-FR, ES-mx;q=0.8, en;q=0.5", LOCALE_ES},
+		{"en-US,es;q=0.9", LOCALE_EN},
+		{"fr, de", LOCALE_EN},
+		{"*", LOCALE_EN},
+	}
+	for _, test := range tests {
+		if got := Locale(test.acceptLanguage); got != test.want {
+			t.Errorf("Locale(%q) = %s, want %s", test.acceptLanguage, got, test.want)
+		}
+	}
+}
+
+func TestResponse(t *testing.T) {
+	var errs ValidationError
+	errs.Add("creditLimit", CodePositive, "creditLimit", int64(0))
+	errs.Add(Index("months", 1), CodeMonthFormat, "2026-13")
+
+	body, err := json.Marshal(errs.Response(LOCALE_ES))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"message":"La solicitud no es válida","errors":[` +
+		`{"field":"creditLimit","code":"positive","message":"el campo límite de crédito debe ser mayor que cero, se recibió: 0"},` +
+		`{"field":"months[1]","code":"month_format","message":"el mes debe tener el formato AAAA/MM, se recibió: 2026-13"}]}`
+	if string(body) != want {
+		t.Errorf("body\n%s\nwant\n%s", body, want)
+	}
+
+	// Unknown locales and MarshalJSON fall back to English.
+	if response := errs.Response("fr"); response.Message != "The request is invalid" ||
+		response.Errors[0].Message != "creditLimit must be greater than zero, instead given: 0" {
+		t.Errorf("response %+v, want it in English", response)
+	}
+	body, err = json.Marshal(&errs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(body), `"message":"The request is invalid"`) {
+		t.Errorf("MarshalJSON = %s, want the English response", body)
+	}
+}
+
+func TestAPIGatewayResponse(t *testing.T) {
+	err := NewFieldError("accounts", CodeInteger, "accounts", "many")
+	response := APIGatewayResponse(err, map[string]string{"accept-language": "es-MX,es;q=0.9"})
+	if response.StatusCode != http.StatusBadRequest || response.Headers["Content-Type"] != "application/json" {
+		t.Errorf("status %d with headers %v, want 400 with JSON", response.StatusCode, response.Headers)
+	}
+	var body ErrorResponse
+	if err := json.Unmarshal([]byte(response.Body), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Message != responseMessages[LOCALE_ES] || len(body.Errors) != 1 || body.Errors[0].Field != "accounts" {
+		t.Errorf("body %+v, want the Spanish error on accounts", body)
+	}
+
+	response = APIGatewayResponse(errors.New("bad request"), nil)
+	if response.StatusCode != http.StatusBadRequest || response.Body != "bad request" || response.Headers != nil {
+		t.Errorf("response %+v, want the plain error text", response)
+	}
+}